@@ -0,0 +1,195 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"fmt"
+
+	confirmOption "github.com/turnerbenjamin/go_odata/constants/confirm_option"
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
+	"github.com/turnerbenjamin/go_odata/model"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// Relationships between accounts and contacts that can be changed from the
+// accounts menu.
+var (
+	// accountPrimaryContact is the single-valued primary contact lookup of an
+	// account
+	accountPrimaryContact = service.Relationship{
+		NavigationProperty: logicalNames.ColumnAccountPrimaryContact,
+	}
+	// accountContacts is the collection of contacts whose parent customer is
+	// an account
+	accountContacts = service.Relationship{
+		NavigationProperty: logicalNames.RelationshipAccountContacts,
+		IsCollection:       true,
+	}
+)
+
+// accountMenuActions returns the additional actions of the accounts menu.
+func (a *app) accountMenuActions() []entityMenuAction {
+	return []entityMenuAction{
+		{label: "Show related contacts", key: 'r', run: a.displayRelatedContactsMenu},
+		{label: "Set primary contact", key: 'p', run: a.setPrimaryContact},
+		{label: "Clear primary contact", key: 'x', run: a.clearPrimaryContact},
+	}
+}
+
+// relatedContactsMenuActions returns the additional actions of the menu of
+// contacts related to the account with the given GUID.
+func (a *app) relatedContactsMenuActions(accountGuid string) []entityMenuAction {
+	return []entityMenuAction{
+		{
+			label: "Link existing contact",
+			key:   'l',
+			run: func(string) error {
+				return a.linkContact(accountGuid)
+			},
+			withoutSelection: true,
+		},
+		{
+			label: "Unlink contact",
+			key:   'x',
+			run: func(contactGuid string) error {
+				return a.unlinkContact(accountGuid, contactGuid)
+			},
+		},
+	}
+}
+
+// setPrimaryContact lets the user pick a contact and sets it as the primary
+// contact of the account with the given GUID.
+// Returns an error if the contact cannot be set.
+func (a *app) setPrimaryContact(accountGuid string) error {
+	contactGuid, ok, err := a.pickContact("Select primary contact")
+	if err != nil || !ok {
+		return err
+	}
+
+	err = a.accountsService.Associate(accountGuid, accountPrimaryContact, contactReference(contactGuid))
+	if err != nil {
+		return err
+	}
+	return a.displaySuccessMessage("Primary contact set")
+}
+
+// clearPrimaryContact removes the primary contact of the account with the
+// given GUID after confirmation.
+// Returns an error if the contact cannot be removed.
+func (a *app) clearPrimaryContact(accountGuid string) error {
+	account, err := a.accountsService.Get(accountGuid)
+	if err != nil {
+		return err
+	}
+	if account.PrimaryContact == nil {
+		return a.displayInfoMessage(fmt.Sprintf("%s has no primary contact", account.Label()))
+	}
+
+	msg := fmt.Sprintf("Remove %s as the primary contact of %s?",
+		account.PrimaryContact.Label(), account.Label())
+	confirmed, err := a.confirm(msg)
+	if err != nil || !confirmed {
+		return err
+	}
+
+	err = a.accountsService.Disassociate(accountGuid, accountPrimaryContact, requestBuilder.EntityReference{})
+	if err != nil {
+		return err
+	}
+	return a.displaySuccessMessage("Primary contact cleared")
+}
+
+// linkContact lets the user pick an existing contact and makes the account
+// with the given GUID its parent customer.
+// Returns an error if the contact cannot be linked.
+func (a *app) linkContact(accountGuid string) error {
+	contactGuid, ok, err := a.pickContact("Select contact to link")
+	if err != nil || !ok {
+		return err
+	}
+
+	err = a.accountsService.Associate(accountGuid, accountContacts, contactReference(contactGuid))
+	if err != nil {
+		return err
+	}
+	return a.displaySuccessMessage("Contact linked")
+}
+
+// unlinkContact removes the account with the given GUID as the parent
+// customer of the contact with the given GUID, after confirmation.
+// Returns an error if the contact cannot be unlinked.
+func (a *app) unlinkContact(accountGuid, contactGuid string) error {
+	contact, err := a.contactsService.Get(contactGuid)
+	if err != nil {
+		return err
+	}
+
+	confirmed, err := a.confirm(fmt.Sprintf("Unlink %s from this account?", contact.Label()))
+	if err != nil || !confirmed {
+		return err
+	}
+
+	err = a.accountsService.Disassociate(accountGuid, accountContacts, contactReference(contactGuid))
+	if err != nil {
+		return err
+	}
+	return a.displaySuccessMessage("Contact unlinked")
+}
+
+// pickContact lets the user choose a contact from a searchable list.
+// Returns the GUID of the contact and true, or false if the user cancelled.
+func (a *app) pickContact(title string) (string, bool, error) {
+	picker := recordPicker[*model.Contact]{
+		ui:          a.ui,
+		service:     a.contactsService,
+		listColumns: a.contactsListColumns,
+		entityLabel: "Contact",
+		title:       title,
+	}
+	return picker.pick()
+}
+
+// contactReference returns a reference to the contact with the given GUID.
+func contactReference(contactGuid string) requestBuilder.EntityReference {
+	return requestBuilder.EntityReference{
+		EntitySet: logicalNames.TableContactResource,
+		ID:        contactGuid,
+	}
+}
+
+// confirm asks the user a yes or no question.
+// Returns true if the user answered yes.
+func (a *app) confirm(msg string) (bool, error) {
+	confirmationScreen, err := NewConfirmationScreen(msg)
+	if err != nil {
+		return false, err
+	}
+
+	response, err := a.ui.NavigateTo(confirmationScreen)
+	if err != nil {
+		return false, err
+	}
+	return confirmOption.ConfirmOption(response.UserInput()) == confirmOption.Yes, nil
+}
+
+// displaySuccessMessage shows a success message to the user.
+func (a *app) displaySuccessMessage(msg string) error {
+	ss, err := newSuccessScreen(msg)
+	if err != nil {
+		return err
+	}
+	_, err = a.ui.NavigateTo(ss)
+	return err
+}
+
+// displayInfoMessage shows an informational message to the user.
+func (a *app) displayInfoMessage(msg string) error {
+	is, err := newInfoScreen(msg)
+	if err != nil {
+		return err
+	}
+	_, err = a.ui.NavigateTo(is)
+	return err
+}
@@ -0,0 +1,39 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// Options of the account picker, listed after the cached accounts.
+const (
+	addAccountOption    = "Sign in with another account"
+	removeAccountOption = "Remove an account"
+	cancelAccountOption = "Cancel"
+)
+
+// newAccountScreen creates a screen allowing the user to choose one of the
+// accounts in the token cache. It is displayed before signing in with
+// delegated authentication when accounts are cached.
+//
+// Parameters:
+//   - text: Instructions displayed above the menu
+//   - options: The label of each account, followed by any other options
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if menu component creation fails
+func newAccountScreen(text string, options []string) (view.Screen, error) {
+	menu, err := view.NewMenuComponent(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent("ACCOUNTS", colours.Purple),
+		view.NewTextComponent(text),
+		menu,
+	})
+}
@@ -0,0 +1,113 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// chooseAccount lets the user choose which cached account to sign in with,
+// sign in with another account, or remove cached accounts. It returns
+// without displaying anything if no accounts are cached.
+func (a *app) chooseAccount(manager msal.AccountManager) error {
+	ctx := context.Background()
+	for {
+		accounts, err := manager.Accounts(ctx)
+		if err != nil {
+			return err
+		}
+		if len(accounts) == 0 {
+			return nil
+		}
+
+		labels := accountLabels(accounts)
+		choice, err := a.displayAccountScreen(
+			"Choose an account",
+			append(slices.Clone(labels), addAccountOption, removeAccountOption))
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case addAccountOption:
+			manager.UseNewAccount()
+			return nil
+		case removeAccountOption:
+			err = a.removeAccount(manager, accounts, labels)
+			if err != nil {
+				return err
+			}
+		default:
+			i := slices.Index(labels, choice)
+			if i < 0 {
+				return fmt.Errorf("invalid account %s", choice)
+			}
+			manager.UseAccount(accounts[i].ID)
+			return nil
+		}
+	}
+}
+
+// removeAccount lets the user choose a cached account to remove, or cancel.
+func (a *app) removeAccount(manager msal.AccountManager, accounts []msal.Account, labels []string) error {
+	choice, err := a.displayAccountScreen(
+		"Choose an account to remove",
+		append(slices.Clone(labels), cancelAccountOption))
+	if err != nil || choice == cancelAccountOption {
+		return err
+	}
+
+	i := slices.Index(labels, choice)
+	if i < 0 {
+		return fmt.Errorf("invalid account %s", choice)
+	}
+	return manager.RemoveAccount(context.Background(), accounts[i].ID)
+}
+
+// displayAccountScreen displays the account screen and returns the chosen
+// option.
+func (a *app) displayAccountScreen(text string, options []string) (string, error) {
+	accountScreen, err := newAccountScreen(text, options)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := a.ui.NavigateTo(accountScreen)
+	if err != nil {
+		return "", err
+	}
+	return output.UserInput(), nil
+}
+
+// showIdentity displays the identity the application is signed in as in the
+// header of every screen: the active account for delegated authentication,
+// or the application's client ID. While a user is impersonated, they are
+// named in a highlighted banner after the identity.
+func (a *app) showIdentity() {
+	identity := "Signed in as application " + a.config.ClientID
+	if manager, ok := a.client.(msal.AccountManager); ok {
+		if account, ok := manager.ActiveAccount(); ok {
+			identity = "Signed in as " + account.String()
+		}
+	}
+
+	if a.impersonatedUser != "" {
+		identity += " | " + colours.ApplyColour(
+			"IMPERSONATING "+a.impersonatedUser, colours.Orange)
+	}
+	a.ui.SetHeader(identity)
+}
+
+// accountLabels returns the label of each account, for display in a menu.
+func accountLabels(accounts []msal.Account) []string {
+	labels := make([]string, len(accounts))
+	for i, account := range accounts {
+		labels[i] = account.String()
+	}
+	return labels
+}
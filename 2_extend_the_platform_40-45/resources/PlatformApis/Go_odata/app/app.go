@@ -3,30 +3,49 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
+	"sync/atomic"
 
 	authMode "github.com/turnerbenjamin/go_odata/constants/authmode"
 	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
 	mainMenuOption "github.com/turnerbenjamin/go_odata/constants/mainmenuoption"
+	"github.com/turnerbenjamin/go_odata/csdl"
 	"github.com/turnerbenjamin/go_odata/model"
 	"github.com/turnerbenjamin/go_odata/msal"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+	"github.com/turnerbenjamin/go_odata/secrets"
 	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/tokencache"
 	"github.com/turnerbenjamin/go_odata/view"
 )
 
 // AppConfig contains the configuration settings required for the application
 // to connect to and interact with Dataverse.
 type AppConfig struct {
-	ClientID     string // EntraID client ID for authentication
-	TenantID     string // EntraID tenant ID
-	ClientSecret string // EntraID client secret for application authentication
-	ResourceURL  string // Resource URL for accessing web api
-	APIBaseURL   string // Base URL for the Dataverse API
-	Authority    string // Authority URL for authentication
-	PageLimit    int    // Maximum number of records to retrieve per page
+	Name                      string                      // Name of the environment, shown when choosing one
+	ClientID                  string                      // EntraID client ID for authentication
+	TenantID                  string                      // EntraID tenant ID
+	ClientSecret              string                      // Source of the client secret for application authentication, see secrets.NewProvider
+	CertificatePath           string                      // PEM or PFX certificate file used in place of the client secret
+	CertificatePasswordSource string                      // Source of the certificate file's password, see secrets.NewProvider
+	SendX5C                   bool                        // Send the certificate chain for subject name/issuer authentication
+	ClientAssertionFile       string                      // File holding a signed client assertion used in place of the client secret
+	TokenCachePath            string                      // Encrypted file persisting tokens between runs, or empty to keep them in memory
+	ResourceURL               string                      // Resource URL for accessing web api
+	APIBaseURL                string                      // Base URL for the Dataverse API
+	Authority                 string                      // Authority URL for authentication
+	PageLimit                 int                         // Maximum number of records to retrieve per page
+	AuthMode                  authMode.AuthenticationMode // Authentication mode, or empty to ask the user
 }
 
+// ErrNoEnvironments is returned when the application is created without any
+// configuration.
+var ErrNoEnvironments = errors.New("no environments configured")
+
 // App defines the interface for the OData client application.
 type App interface {
 	// Run starts the application, handles authentication, and begins the UI
@@ -40,20 +59,48 @@ type App interface {
 // UI components, and application flow.
 type app struct {
 	config              *AppConfig
+	environments        []AppConfig
 	accountsService     service.EntityService[*model.Account]
 	contactsService     service.EntityService[*model.Contact]
 	accountsListColumns []view.ListColumn[*model.Account]
 	contactsListColumns []view.ListColumn[*model.Contact]
+	tableBrowser        *tableBrowser
 	ui                  view.UI
+	// client acquires the access tokens of the signed in identity
+	client msal.DataverseClient
+	// systemUsersService lists the users that can be impersonated
+	systemUsersService     service.EntityService[*model.SystemUser]
+	systemUsersListColumns []view.ListColumn[*model.SystemUser]
+	// dataverseService sends every request, on behalf of the impersonated
+	// user if any
+	dataverseService service.DataverseService
+	// impersonatedUser is the label of the impersonated user, or empty
+	impersonatedUser string
+	// metadataService retrieves table and column definitions
+	metadataService service.MetadataService
+	// choiceOptions holds the options of choice columns shown in prompts
+	choiceOptions *choiceOptionsCache
+	// entityServiceOptions holds the options of each entity service, for
+	// validation against the environment's schema
+	entityServiceOptions []service.EntityServiceOptions
+	// signInRequired is set when sign in was needed while
+	// another task was running, so that it is completed from the main menu
+	signInRequired atomic.Bool
 }
 
 // NewApp creates a new instance of the application with the provided
-// configuration.
+// configuration. If more than one configuration is given, the user chooses
+// the environment to connect to when the application starts.
 // It initializes entity list columns and returns the App interface or an
 // error.
-func NewApp(config AppConfig) (App, error) {
+func NewApp(configs ...AppConfig) (App, error) {
+	if len(configs) == 0 {
+		return nil, ErrNoEnvironments
+	}
+
 	a := app{
-		config: &config,
+		config:       &configs[0],
+		environments: configs,
 	}
 
 	err := a.initEntityListColumns()
@@ -75,11 +122,19 @@ func (a *app) Run() error {
 	a.ui = ui
 	defer a.ui.Exit()
 
-	authMode, err := a.getConfigInput()
+	err = a.selectEnvironment()
 	if err != nil {
 		return a.displayErrorScreen(err)
 	}
 
+	authMode := a.config.AuthMode
+	if authMode == "" {
+		authMode, err = a.getConfigInput()
+		if err != nil {
+			return a.displayErrorScreen(err)
+		}
+	}
+
 	ds, err := a.newDataverseService(authMode)
 	if err != nil {
 		return a.displayErrorScreen(err)
@@ -90,9 +145,54 @@ func (a *app) Run() error {
 		return a.displayErrorScreen(err)
 	}
 
+	err = a.validateEntityServices(ds)
+	if err != nil {
+		return a.displayErrorScreen(err)
+	}
+
 	return a.startProgramLoop()
 }
 
+// selectEnvironment displays the environment screen if more than one
+// environment is configured, and sets the configuration of the chosen
+// environment.
+func (a *app) selectEnvironment() error {
+	if len(a.environments) < 2 {
+		return nil
+	}
+
+	labels := make([]string, len(a.environments))
+	for i, e := range a.environments {
+		labels[i] = e.label()
+	}
+
+	environmentScreen, err := newEnvironmentScreen(labels)
+	if err != nil {
+		return err
+	}
+
+	output, err := a.ui.NavigateTo(environmentScreen)
+	if err != nil {
+		return err
+	}
+
+	i := slices.Index(labels, output.UserInput())
+	if i < 0 {
+		return fmt.Errorf("invalid environment %s", output.UserInput())
+	}
+	a.config = &a.environments[i]
+	return nil
+}
+
+// label returns the environment's name and URL, for display when choosing an
+// environment.
+func (c AppConfig) label() string {
+	if c.Name == "" {
+		return c.ResourceURL
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.ResourceURL)
+}
+
 // getConfigInput displays the configuration screen and returns the selected
 // authentication mode or an error.
 func (a *app) getConfigInput() (authMode.AuthenticationMode, error) {
@@ -110,7 +210,7 @@ func (a *app) getConfigInput() (authMode.AuthenticationMode, error) {
 }
 
 // startProgramLoop displays the main menu and handles the main application flow
-// until the user chooses to exit.
+// until the user chooses to exit or to sign out.
 func (a *app) startProgramLoop() error {
 	mainMenuChoice, err := a.displayMainMenu()
 	if err != nil {
@@ -118,7 +218,16 @@ func (a *app) startProgramLoop() error {
 	}
 
 	for mainMenuChoice != mainMenuOption.Exit {
-		a.displayTable(mainMenuChoice)
+		switch mainMenuChoice {
+		case mainMenuOption.SignOut:
+			return a.signOut()
+		case mainMenuOption.Impersonate:
+			a.impersonate()
+		case mainMenuOption.StopImpersonating:
+			a.stopImpersonating()
+		default:
+			a.displayTable(mainMenuChoice)
+		}
 		mainMenuChoice, err = a.displayMainMenu()
 		if err != nil {
 			return err
@@ -127,10 +236,26 @@ func (a *app) startProgramLoop() error {
 	return nil
 }
 
+// signOut removes the signed in accounts and clears the persistent token
+// cache, so that the next run signs in again, then confirms this to the user
+// before the application exits.
+func (a *app) signOut() error {
+	err := a.client.SignOut(context.Background())
+	if err != nil {
+		return a.displayErrorScreen(err)
+	}
+	return a.displaySuccessMessage("Signed out and cleared the token cache")
+}
+
 // displayMainMenu shows the main menu screen and returns the selected option.
+// Any sign in that could not be shown during the previous task is requested
+// first.
 func (a *app) displayMainMenu() (mainMenuOption.MainMenuOption, error) {
+	if a.signInRequired.Load() {
+		a.signIn()
+	}
 
-	mainMenuScreen, err := newMainMenuScreen()
+	mainMenuScreen, err := newMainMenuScreen(a.impersonatedUser != "")
 	if err != nil {
 		return mainMenuOption.Invalid, err
 	}
@@ -150,6 +275,8 @@ func (a *app) displayTable(tableChoice mainMenuOption.MainMenuOption) error {
 		return a.displayAccountsMenu()
 	case mainMenuOption.Contacts:
 		return a.displayContactsMenu()
+	case mainMenuOption.Tables:
+		return a.tableBrowser.run()
 	}
 	return nil
 }
@@ -166,25 +293,66 @@ func (a *app) displayAccountsMenu() error {
 			return getEntityDetails(
 				&defaultValues,
 				"New Account",
-				accountPropertyPrompts,
+				accountPropertyPrompts(a.choiceOptions.load, true),
 				a.getScreenOutput)
 		},
 		getUpdatedEntity: func(accountToUpdate *model.Account) (*model.Account, error) {
 			return getEntityDetails(
 				accountToUpdate,
 				"New Account",
-				accountPropertyPrompts,
+				accountPropertyPrompts(a.choiceOptions.load, false),
 				a.getScreenOutput)
 		},
 		entityLabel: "Account",
+		actions:     a.accountMenuActions(),
 	}
 	return accountsMenu.run()
 }
 
+// displayRelatedContactsMenu shows the contacts whose parent customer is the
+// account with the given GUID. Contacts created from this menu are associated
+// with the account, and existing contacts can be linked to or unlinked from
+// it.
+func (a *app) displayRelatedContactsMenu(accountGuid string) error {
+	account, err := a.accountsService.Get(accountGuid)
+	if err != nil {
+		return err
+	}
+
+	contactsMenu := a.newContactsMenu()
+	contactsMenu.parentLabel = account.Label()
+	contactsMenu.baseFilter = requestBuilder.Eq(
+		logicalNames.ColumnContactParentCustomer, requestBuilder.Guid(accountGuid))
+	contactsMenu.actions = a.relatedContactsMenuActions(accountGuid)
+	contactsMenu.getNewEntity = func() (*model.Contact, error) {
+		defaultValues := model.Contact{
+			ParentCustomer: &model.Lookup{
+				ID:                 accountGuid,
+				LogicalName:        logicalNames.TableAccount,
+				Name:               account.Label(),
+				NavigationProperty: logicalNames.NavigationContactParentAccount,
+				EntitySet:          logicalNames.TableAccountResource,
+			},
+		}
+		return getEntityDetails(
+			&defaultValues,
+			"New Contact",
+			contactPropertyPrompts(a.searchAccounts),
+			a.getScreenOutput)
+	}
+	return contactsMenu.run()
+}
+
 // displayContactsMenu shows the contacts menu and handles contact-related
 // operations.
 func (a *app) displayContactsMenu() error {
-	contactsMenu := entityMenu[*model.Contact]{
+	contactsMenu := a.newContactsMenu()
+	return contactsMenu.run()
+}
+
+// newContactsMenu creates a menu over every contact.
+func (a *app) newContactsMenu() *entityMenu[*model.Contact] {
+	return &entityMenu[*model.Contact]{
 		ui:          a.ui,
 		service:     a.contactsService,
 		listColumns: a.contactsListColumns,
@@ -193,19 +361,34 @@ func (a *app) displayContactsMenu() error {
 			return getEntityDetails(
 				&defaultValues,
 				"New Contact",
-				contactPropertyPrompts,
+				contactPropertyPrompts(a.searchAccounts),
 				a.getScreenOutput)
 		},
 		getUpdatedEntity: func(contactToUpdate *model.Contact) (*model.Contact, error) {
 			return getEntityDetails(
 				contactToUpdate,
 				"New Contact",
-				contactPropertyPrompts,
+				contactPropertyPrompts(a.searchAccounts),
 				a.getScreenOutput)
 		},
 		entityLabel: "Contact",
 	}
-	return contactsMenu.run()
+}
+
+// searchAccounts returns the first page of accounts matching searchTerm, for
+// use by lookup inputs referencing accounts. The request is abandoned if ctx
+// is cancelled.
+func (a *app) searchAccounts(ctx context.Context, searchTerm string) ([]view.Entity, error) {
+	accounts, err := a.accountsService.ListContext(ctx, a.accountsService.SearchFilter(searchTerm))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]view.Entity, len(accounts.Data()))
+	for i, account := range accounts.Data() {
+		results[i] = account
+	}
+	return results, nil
 }
 
 // getScreenOutput is a helper method that abstracts the process of displaying a
@@ -219,37 +402,247 @@ func (a *app) getScreenOutput(getScreen func() (view.Screen, error)) (view.Scree
 }
 
 // newDataverseService creates a new Dataverse service using the specified
-// authentication mode. It configures the appropriate client based on the mode
-// and tests the connection.
+// authentication mode and the application's configuration. If the client
+// secret's source is "prompt", the user enters it on a masked input screen,
+// and other sources are read behind a loading screen so that the user can
+// stop waiting for them.
+// For device code and browser authentication, a sign in screen is shown while
+// waiting for the user to sign in.
+// For delegated authentication, the user first chooses a cached account or
+// to sign in with another. The client is kept so that the user can sign out,
+// and the signed in identity is shown in the header of every screen.
 func (a *app) newDataverseService(mode authMode.AuthenticationMode) (service.DataverseService, error) {
+	ctx := context.Background()
+	client, err := NewDataverseClient(ctx, *a.config, mode, Prompts{
+		Secret:        msal.SecretFunc(a.promptForClientSecret),
+		WaitForSecret: a.waitForSecret,
+		DeviceCode:    a.showDeviceCode,
+		BrowserSignIn: a.showBrowserSignIn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.client = client
+
+	if manager, ok := client.(msal.AccountManager); ok {
+		err = a.chooseAccount(manager)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dataverseService, err := newConnectedService(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	a.showIdentity()
+	return dataverseService, nil
+}
+
+// showDeviceCode displays the device code sign in screen, with a countdown
+// to the code's expiry, while waiting for the user to sign in. The wait is
+// cancelled if the user presses Esc or ctx is cancelled.
+//
+// Sign in may be required while another task is displaying a screen, such as
+// a list load whose token refresh falls back to device code. The sign in
+// screen is not shown over that task; an error is returned instead.
+func (a *app) showDeviceCode(
+	ctx context.Context,
+	code msal.DeviceCode,
+	wait func(ctx context.Context) error) error {
+
+	deviceCodeScreen, err := newDeviceCodeScreen(code)
+	if err != nil {
+		return err
+	}
+	return a.runSignInScreen(ctx, deviceCodeScreen, wait)
+}
+
+// showBrowserSignIn displays the browser sign in screen while waiting for the
+// user to sign in with the browser opened by interactive authentication. The
+// wait is cancelled if the user presses Esc or ctx is cancelled. As with
+// showDeviceCode, the screen is not shown over another task.
+func (a *app) showBrowserSignIn(ctx context.Context, wait func(ctx context.Context) error) error {
+	browserSignInScreen, err := newBrowserSignInScreen()
+	if err != nil {
+		return err
+	}
+	return a.runSignInScreen(ctx, browserSignInScreen, wait)
+}
+
+// runSignInScreen displays a sign in screen while wait runs. The wait is
+// cancelled if the user presses Esc or ctx is cancelled. If another task is
+// displaying a screen, sign in is requested from the main menu instead and
+// an error is returned.
+func (a *app) runSignInScreen(
+	ctx context.Context,
+	signInScreen view.Screen,
+	wait func(ctx context.Context) error) error {
+
+	err := a.ui.RunCancellable(signInScreen, func(taskCtx context.Context) error {
+		waitCtx, cancel := context.WithCancel(taskCtx)
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+
+		return wait(waitCtx)
+	})
+	if errors.Is(err, view.ErrTaskRunning) {
+		a.signInRequired.Store(true)
+		return fmt.Errorf("sign in is required and will be requested from the main menu: %w", err)
+	}
+	return err
+}
+
+// signIn acquires an access token outside any other task, so that a sign in
+// refused while a task was running can be shown.
+func (a *app) signIn() {
+	a.signInRequired.Store(false)
+	_, err := a.client.AcquireTokenContext(context.Background())
+	if err != nil {
+		a.displayErrorScreen(err)
+	}
+}
+
+// waitForSecret reads secret while displaying a loading screen, so that the
+// user can press Esc to stop waiting for a slow source such as a command or
+// the OS keyring. The read is also stopped if ctx is cancelled.
+func (a *app) waitForSecret(ctx context.Context, secret msal.SecretProvider) (string, error) {
+	loadingScreen, err := newLoadingScreen("Reading secret...")
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	err = a.ui.RunCancellable(loadingScreen, func(taskCtx context.Context) error {
+		readCtx, cancel := context.WithCancel(taskCtx)
+		defer cancel()
+		stop := context.AfterFunc(ctx, cancel)
+		defer stop()
+
+		var err error
+		value, err = secret.Secret(readCtx)
+		return err
+	})
+	return value, err
+}
+
+// promptForClientSecret asks the user to enter the client secret on a masked
+// input screen. The screen is not shown if ctx is already cancelled.
+func (a *app) promptForClientSecret(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	inputScreen, err := newMaskedInputScreen(
+		"CONFIGURATION", "Enter the client secret", "Client secret")
+	if err != nil {
+		return "", err
+	}
+
+	output, err := a.ui.NavigateTo(inputScreen)
+	if err != nil {
+		return "", err
+	}
+	return output.UserInput(), nil
+}
+
+// Prompts holds the functions used to ask the user for input while
+// connecting to Dataverse.
+type Prompts struct {
+	// Secret asks for any secret whose source is "prompt"
+	Secret msal.SecretProvider
+	// WaitForSecret reads a secret from any other source, showing that the
+	// application is waiting for it. If nil, the secret is read directly
+	WaitForSecret func(ctx context.Context, secret msal.SecretProvider) (string, error)
+	// DeviceCode shows the device code in device code authentication
+	DeviceCode msal.DeviceCodeHandler
+	// BrowserSignIn tells the user to sign in with the browser in user
+	// authentication
+	BrowserSignIn msal.BrowserSignInHandler
+}
+
+// NewDataverseService creates a new Dataverse service from config using the
+// specified authentication mode. It configures the appropriate client based
+// on the mode, as described by NewDataverseClient, and tests the connection.
+// Reading secrets and testing the connection stop if ctx is cancelled.
+// It is used by both the interactive application and the command line
+// subcommands.
+func NewDataverseService(
+	ctx context.Context,
+	config AppConfig,
+	mode authMode.AuthenticationMode,
+	prompts Prompts) (service.DataverseService, error) {
+
+	client, err := NewDataverseClient(ctx, config, mode, prompts)
+	if err != nil {
+		return nil, err
+	}
+	return newConnectedService(ctx, client)
+}
+
+// NewDataverseClient creates the client acquiring access tokens for config
+// using the specified authentication mode. If config.TokenCachePath is set,
+// tokens are persisted to that encrypted file between runs.
+//
+// For application authentication, the application proves its identity with
+// the certificate in config.CertificatePath if set, else the client assertion
+// in config.ClientAssertionFile if set, else the client secret read from the
+// source in config.ClientSecret. Secrets, including the certificate
+// password, are read with ctx. prompts.Secret is used for any source that is
+// "prompt", and prompts.WaitForSecret for any other source. For device code
+// authentication, prompts.DeviceCode shows the code to the user, and for
+// user authentication, prompts.BrowserSignIn tells the user to sign in with
+// the browser.
+func NewDataverseClient(
+	ctx context.Context,
+	config AppConfig,
+	mode authMode.AuthenticationMode,
+	prompts Prompts) (msal.DataverseClient, error) {
+
 	var getClientFunc func(msal.ClientOptions) (msal.DataverseClient, error)
+	clientOptions := msal.ClientOptions{
+		ClientID:    config.ClientID,
+		ResourceURL: config.ResourceURL,
+		Authority:   config.Authority,
+	}
+	if config.TokenCachePath != "" {
+		clientOptions.TokenCache = tokencache.NewEncryptedFileCache(
+			config.TokenCachePath, tokencache.NewDefaultKeyStore(config.TokenCachePath))
+	}
 
 	switch mode {
 	case authMode.Application:
-		getClientFunc = msal.GetAppService
+		getClientFunc = func(c msal.ClientOptions) (msal.DataverseClient, error) {
+			return msal.GetAppServiceContext(ctx, c)
+		}
+		err := setAppCredential(&clientOptions, config, prompts)
+		if err != nil {
+			return nil, err
+		}
 	case authMode.User:
 		getClientFunc = msal.GetDelegatedService
+		clientOptions.BrowserSignInHandler = prompts.BrowserSignIn
+	case authMode.DeviceCode:
+		getClientFunc = msal.GetDeviceCodeService
+		clientOptions.DeviceCodeHandler = prompts.DeviceCode
 	default:
 		return nil, fmt.Errorf("invalid auth mode: %s", mode)
 	}
 
-	client, err := getClientFunc(msal.ClientOptions{
-		ClientID:     a.config.ClientID,
-		ResourceURL:  a.config.ResourceURL,
-		Authority:    a.config.Authority,
-		ClientSecret: a.config.ClientSecret,
-	})
-	if err != nil {
-		return nil, err
-	}
+	return getClientFunc(clientOptions)
+}
 
+// newConnectedService creates a Dataverse service using client and tests the
+// connection, acquiring a token. The test stops if ctx is cancelled.
+func newConnectedService(ctx context.Context, client msal.DataverseClient) (service.DataverseService, error) {
 	dataverseService, err := service.NewDataverseService(service.DataverseServiceOptions{
 		Client: client,
 	})
 	if err != nil {
 		return nil, err
 	}
-	err = dataverseService.TestConnection()
+	err = dataverseService.TestConnectionContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -257,20 +650,90 @@ func (a *app) newDataverseService(mode authMode.AuthenticationMode) (service.Dat
 	return dataverseService, nil
 }
 
-// initialiseEntityServices sets up the Account and Contact services with the
-// provided Dataverse service.
+// initialiseEntityServices sets up the Account and Contact services and the
+// table browser with the provided Dataverse service.
 func (a *app) initialiseEntityServices(dataverseService service.DataverseService) error {
 	baseURL, err := url.Parse(a.config.APIBaseURL)
 	if err != nil {
 		return err
 	}
 
+	a.dataverseService = dataverseService
+	a.metadataService = service.NewMetadataService(service.MetadataServiceOptions{
+		DataverseService: dataverseService,
+		BaseUrl:          baseURL,
+	})
+	a.choiceOptions = newChoiceOptionsCache(a.metadataService)
+
 	err = a.initAccountsService(dataverseService, baseURL)
 	if err != nil {
 		return err
 	}
 
-	return a.initContactsService(dataverseService, baseURL)
+	err = a.initContactsService(dataverseService, baseURL)
+	if err != nil {
+		return err
+	}
+
+	a.initSystemUsersService(dataverseService, baseURL)
+
+	a.initTableBrowser(dataverseService, baseURL)
+	return nil
+}
+
+// validateEntityServices checks the configured fields of each entity service
+// against the environment's $metadata document, which is cached between runs
+// and only requested again once the cached copy is a day old. The user may
+// press Esc to skip validation while the document is loading.
+// Returns an error listing every unknown table or column.
+func (a *app) validateEntityServices(dataverseService service.DataverseService) error {
+	baseURL, err := url.Parse(a.config.APIBaseURL)
+	if err != nil {
+		return err
+	}
+
+	loaderOptions := csdl.LoaderOptions{
+		DataverseService: dataverseService,
+		BaseUrl:          baseURL,
+	}
+
+	loadingScreen, err := newLoadingScreen("Loading $metadata...")
+	if err != nil {
+		return err
+	}
+
+	var metadata *csdl.Metadata
+	err = a.ui.RunCancellable(loadingScreen, func(ctx context.Context) error {
+		var err error
+		metadata, err = csdl.Load(ctx, loaderOptions)
+		return err
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, options := range a.entityServiceOptions {
+		errs = append(errs, options.Validate(metadata))
+	}
+	return errors.Join(errs...)
+}
+
+// initTableBrowser initializes the browser for tables without a dedicated
+// model.
+func (a *app) initTableBrowser(dataverseService service.DataverseService, baseURL *url.URL) {
+	a.tableBrowser = &tableBrowser{
+		ui:                a.ui,
+		metadataService:   a.metadataService,
+		loadChoiceOptions: a.choiceOptions.load,
+		dataverseService:  dataverseService,
+		baseURL:           baseURL,
+		pageLimit:         a.config.PageLimit,
+		getScreenOutput:   a.getScreenOutput,
+	}
 }
 
 // initAccountsService initializes the service for working with account
@@ -289,9 +752,23 @@ func (a *app) initAccountsService(dataverseService service.DataverseService, bas
 			logicalNames.ColumnAccountId,
 			logicalNames.ColumnAccountName,
 			logicalNames.ColumnAccountCity,
+			logicalNames.ColumnAccountIndustry,
+			logicalNames.ColumnAccountStatus,
+		},
+		IncludeAnnotations: []string{service.AllAnnotations},
+		Expand: []requestBuilder.Expand{
+			{
+				NavigationProperty: logicalNames.ColumnAccountPrimaryContact,
+				Select: []string{
+					logicalNames.ColumnContactId,
+					logicalNames.ColumnContactFirstName,
+					logicalNames.ColumnContactLastName,
+				},
+			},
 		},
 	}
 
+	a.entityServiceOptions = append(a.entityServiceOptions, accountServiceOptions)
 	a.accountsService = service.NewEntityService[*model.Account](accountServiceOptions)
 	return nil
 }
@@ -314,13 +791,41 @@ func (a *app) initContactsService(dataverseService service.DataverseService, bas
 			logicalNames.ColumnContactFirstName,
 			logicalNames.ColumnContactLastName,
 			logicalNames.ColumnContactEmail,
+			logicalNames.ColumnContactParentCustomer,
 		},
+		IncludeAnnotations: []string{service.AllAnnotations},
 	}
 
+	a.entityServiceOptions = append(a.entityServiceOptions, contactServiceOptions)
 	a.contactsService = service.NewEntityService[*model.Contact](contactServiceOptions)
 	return nil
 }
 
+// initSystemUsersService initializes the service listing the environment's
+// users, for choosing a user to impersonate.
+func (a *app) initSystemUsersService(dataverseService service.DataverseService, baseURL *url.URL) {
+	systemUserServiceOptions := service.EntityServiceOptions{
+		DataverseService: dataverseService,
+		BaseUrl:          baseURL,
+		PageLimit:        a.config.PageLimit,
+		ResourcePath:     logicalNames.TableSystemUserResource,
+		SearchFields: []string{
+			logicalNames.ColumnSystemUserFullName,
+			logicalNames.ColumnSystemUserDomainName,
+		},
+		SelectsFields: []string{
+			logicalNames.ColumnSystemUserId,
+			logicalNames.ColumnSystemUserFullName,
+			logicalNames.ColumnSystemUserDomainName,
+			logicalNames.ColumnSystemUserObjectId,
+			logicalNames.ColumnSystemUserIsDisabled,
+		},
+	}
+
+	a.entityServiceOptions = append(a.entityServiceOptions, systemUserServiceOptions)
+	a.systemUsersService = service.NewEntityService[*model.SystemUser](systemUserServiceOptions)
+}
+
 // initEntityListColumns initializes the column definitions for both account and
 // contact lists.
 func (a *app) initEntityListColumns() error {
@@ -328,7 +833,11 @@ func (a *app) initEntityListColumns() error {
 	if err != nil {
 		return err
 	}
-	return a.initContactListColumns()
+	err = a.initContactListColumns()
+	if err != nil {
+		return err
+	}
+	return a.initSystemUserListColumns()
 }
 
 // initAccountListColumns initializes the column definitions for the account
@@ -353,6 +862,17 @@ func (a *app) initContactListColumns() error {
 	return nil
 }
 
+// initSystemUserListColumns initializes the column definitions for the
+// system user list shown when choosing a user to impersonate.
+func (a *app) initSystemUserListColumns() error {
+	columns, err := model.SystemUserListColumns()
+	if err != nil {
+		return err
+	}
+	a.systemUsersListColumns = columns
+	return nil
+}
+
 // displayErrorScreen shows an error message to the user.
 // Returns the original error if displaying the error screen fails,
 // otherwise returns nil to indicate the error was displayed successfully.
@@ -361,7 +881,7 @@ func (a *app) displayErrorScreen(originalError error) error {
 		return originalError
 	}
 
-	es, err := newErrorScreen(originalError.Error())
+	es, err := newErrorScreen(errorScreenMessage(originalError))
 	if err != nil {
 		return originalError
 	}
@@ -372,3 +892,55 @@ func (a *app) displayErrorScreen(originalError error) error {
 	a.ui.Exit()
 	return nil
 }
+
+// setAppCredential sets the credential used for application authentication
+// in options from config. Only the credential that will be used is set, so
+// that the user is not prompted for a client secret when a certificate or
+// client assertion is configured. Secret sources are read with prompts, as
+// described by newSecretProvider.
+func setAppCredential(
+	options *msal.ClientOptions,
+	config AppConfig,
+	prompts Prompts) error {
+
+	switch {
+	case config.CertificatePath != "":
+		certificate := &msal.CertificateOptions{
+			Path:    config.CertificatePath,
+			SendX5C: config.SendX5C,
+		}
+		if config.CertificatePasswordSource != "" {
+			password, err := newSecretProvider(config.CertificatePasswordSource, prompts)
+			if err != nil {
+				return err
+			}
+			certificate.Password = password
+		}
+		options.Certificate = certificate
+	case config.ClientAssertionFile != "":
+		options.ClientAssertion = secrets.NewFileProvider(config.ClientAssertionFile)
+	default:
+		clientSecret, err := newSecretProvider(config.ClientSecret, prompts)
+		if err != nil {
+			return err
+		}
+		options.ClientSecret = clientSecret
+	}
+	return nil
+}
+
+// newSecretProvider creates a provider for the secret source specification.
+// prompts.Secret asks for the secret if the source is "prompt". Any other
+// source is read with prompts.WaitForSecret if it is set.
+func newSecretProvider(source string, prompts Prompts) (msal.SecretProvider, error) {
+	provider, err := secrets.NewProvider(source, prompts.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if prompts.WaitForSecret == nil || secrets.IsPrompt(source) {
+		return provider, nil
+	}
+	return msal.SecretFunc(func(ctx context.Context) (string, error) {
+		return prompts.WaitForSecret(ctx, provider)
+	}), nil
+}
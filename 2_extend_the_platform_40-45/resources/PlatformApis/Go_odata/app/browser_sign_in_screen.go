@@ -0,0 +1,25 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// newBrowserSignInScreen creates a screen that is displayed while waiting for
+// the user to sign in with the browser opened by interactive authentication.
+// The screen includes a sign in title in blue, an instruction to sign in with
+// the browser, and a prompt for the user to press Esc to cancel.
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if screen creation fails
+func newBrowserSignInScreen() (view.Screen, error) {
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent("SIGN IN", colours.Blue),
+		view.NewTextComponent("Please authenticate in the browser window that has opened."),
+		view.NewTextComponent("Waiting for you to sign in..."),
+		view.NewCancelPromptComponent(),
+	})
+}
@@ -0,0 +1,66 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"sync"
+
+	"github.com/turnerbenjamin/go_odata/model"
+	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// choiceOptionsFunc returns the options of a choice column of the table with
+// the given logical name.
+type choiceOptionsFunc func(entityLogicalName string, attribute model.AttributeDefinition) ([]view.ChoiceOption, error)
+
+// choiceOptionsCache loads the options of choice columns from the metadata
+// service and keeps them for the rest of the session, so that each column's
+// options are only requested once.
+type choiceOptionsCache struct {
+	// Service for retrieving column options
+	metadataService service.MetadataService
+	// Options keyed by table and column logical name
+	options map[string][]view.ChoiceOption
+	mu      sync.Mutex
+}
+
+// newChoiceOptionsCache creates an empty cache over the given metadata
+// service.
+func newChoiceOptionsCache(metadataService service.MetadataService) *choiceOptionsCache {
+	return &choiceOptionsCache{
+		metadataService: metadataService,
+		options:         make(map[string][]view.ChoiceOption),
+	}
+}
+
+// load returns the options of a choice column, retrieving them on first use.
+// It implements choiceOptionsFunc.
+func (c *choiceOptionsCache) load(entityLogicalName string, attribute model.AttributeDefinition) ([]view.ChoiceOption, error) {
+	key := entityLogicalName + "." + attribute.LogicalName
+
+	c.mu.Lock()
+	options, ok := c.options[key]
+	c.mu.Unlock()
+	if ok {
+		return options, nil
+	}
+
+	// The lock is not held during the request, so that prompts for other
+	// columns are not held up by a slow request. Concurrent first uses of the
+	// same column may each request its options.
+	metadata, err := c.metadataService.ChoiceOptions(entityLogicalName, attribute)
+	if err != nil {
+		return nil, err
+	}
+
+	options = make([]view.ChoiceOption, len(metadata))
+	for i, o := range metadata {
+		options[i] = view.ChoiceOption{Value: o.Value, Label: o.Label.Text()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.options[key] = options
+	return options, nil
+}
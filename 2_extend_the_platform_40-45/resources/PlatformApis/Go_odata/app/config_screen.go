@@ -10,8 +10,8 @@ import (
 
 // GetConfigScreen creates and returns a configuration screen for the
 // application.
-// The screen allows the user to select an authentication mode (Application or
-// User) for connecting to the Dataverse API.
+// The screen allows the user to select an authentication mode (Application,
+// User or Device Code) for connecting to the Dataverse API.
 //
 // The screen includes:
 // - A title "CONFIGURATION" in purple color
@@ -26,6 +26,7 @@ func GetConfigScreen() (view.Screen, error) {
 	menu, err := view.NewMenuComponent([]string{
 		string(authMode.Application),
 		string(authMode.User),
+		string(authMode.DeviceCode),
 	},
 	)
 
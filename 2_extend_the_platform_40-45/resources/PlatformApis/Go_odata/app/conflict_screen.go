@@ -0,0 +1,74 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"fmt"
+
+	conflictOption "github.com/turnerbenjamin/go_odata/constants/conflictoption"
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// newConflictScreen creates a screen that tells the user their update could
+// not be saved because the record was changed by someone else, and asks how
+// to proceed.
+//
+// Parameters:
+//   - entityLabel: The human-readable label of the entity type
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if menu component creation fails
+func newConflictScreen(entityLabel string) (view.Screen, error) {
+	return newConflictMenuScreen("Update Conflict", entityLabel, []conflictOption.ConflictOption{
+		conflictOption.Reload,
+		conflictOption.Overwrite,
+		conflictOption.Cancel,
+	})
+}
+
+// newDeleteConflictScreen creates a screen that tells the user their delete
+// was refused because the record was changed by someone else, and asks how
+// to proceed.
+//
+// Parameters:
+//   - entityLabel: The human-readable label of the entity type
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if menu component creation fails
+func newDeleteConflictScreen(entityLabel string) (view.Screen, error) {
+	return newConflictMenuScreen("Delete Conflict", entityLabel, []conflictOption.ConflictOption{
+		conflictOption.ReloadDelete,
+		conflictOption.DeleteAnyway,
+		conflictOption.Cancel,
+	})
+}
+
+// newConflictMenuScreen creates a conflict screen with the given title and
+// a menu of options.
+func newConflictMenuScreen(
+	title string,
+	entityLabel string,
+	options []conflictOption.ConflictOption) (view.Screen, error) {
+
+	menuOptions := make([]string, len(options))
+	for i, o := range options {
+		menuOptions[i] = string(o)
+	}
+	menu, err := view.NewMenuComponent(menuOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf(
+		"This %s has been changed by someone else since you opened it.\n"+
+			"How would you like to proceed?", entityLabel)
+
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent(title, colours.Orange),
+		view.NewTextComponent(msg),
+		menu,
+	})
+}
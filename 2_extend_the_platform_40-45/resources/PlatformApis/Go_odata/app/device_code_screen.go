@@ -0,0 +1,36 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"fmt"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// newDeviceCodeScreen creates a screen that is displayed while waiting for
+// the user to sign in with a device code.
+// The screen includes a sign in title in blue, the verification URL and the
+// code to enter there, a countdown to the code's expiry, and a prompt for
+// the user to press Esc to cancel.
+//
+// Parameters:
+//   - code: The device code issued by the identity platform
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if screen creation fails
+func newDeviceCodeScreen(code msal.DeviceCode) (view.Screen, error) {
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent("SIGN IN", colours.Blue),
+		view.NewTextComponent(fmt.Sprintf(
+			"On any device, open %s in a browser and enter the code:",
+			colours.ApplyColour(code.VerificationURL, colours.Blue))),
+		view.NewTextComponent(colours.ApplyColour(code.UserCode, colours.Green)),
+		view.NewCountdownComponent("Code expires in", code.ExpiresOn),
+		view.NewTextComponent("Waiting for you to sign in..."),
+		view.NewCancelPromptComponent(),
+	})
+}
@@ -62,11 +62,79 @@ var entityListControls = []view.ListControl{
 	},
 }
 
+// newEntityListControls returns the standard entity list controls with a
+// control for each of the menu's additional actions, placed before the back
+// control. If backLabel is not empty, it replaces the label of the back
+// control.
+func newEntityListControls(actions []entityMenuAction, backLabel string) []view.ListControl {
+	controls := make([]view.ListControl, 0, len(entityListControls)+len(actions))
+	for _, c := range entityListControls {
+		if c.Value() == string(tableMenuOption.Back) {
+			for _, action := range actions {
+				controls = append(controls, listControl{
+					label: action.label,
+					value: action.label,
+					key:   action.key,
+				})
+			}
+			if backLabel != "" {
+				c = listControl{label: backLabel, value: c.Value(), key: c.Key()}
+			}
+		}
+		controls = append(controls, c)
+	}
+	return controls
+}
+
+// tableListControls defines the controls available on the table browser's
+// list of tables.
+var tableListControls = []view.ListControl{
+	listControl{
+		label: "Open table",
+		value: string(tableMenuOption.Open),
+		key:   'v',
+	},
+	listControl{
+		label: "Set/Clear search term",
+		value: string(tableMenuOption.Search),
+		key:   's',
+	},
+	listControl{
+		label: "Back to main menu",
+		value: string(tableMenuOption.Back),
+		key:   'b',
+	},
+}
+
+// recordPickerControls defines the controls available when choosing a record
+// with a recordPicker.
+var recordPickerControls = []view.ListControl{
+	listControl{
+		label: "Pick",
+		value: string(tableMenuOption.Pick),
+		key:   'p',
+	},
+	listControl{
+		label: "Set/Clear search term",
+		value: string(tableMenuOption.Search),
+		key:   's',
+	},
+	listControl{
+		label: "Cancel",
+		value: string(tableMenuOption.Back),
+		key:   'b',
+	},
+}
+
 // listScreenOptions contains configuration for creating an entity list screen.
 // The generic type T represents the entity type to be displayed.
+// If controls is nil, entityListControls is used.
 type listScreenOptions[T view.Entity] struct {
+	controls   []view.ListControl
 	entityList view.EntityList[T]
 	columns    []view.ListColumn[T]
+	sort       view.ListSort
+	selectSort func(view.ListSort)
 }
 
 // newEntityListScreen creates a new screen displaying a list of entities.
@@ -76,10 +144,17 @@ type listScreenOptions[T view.Entity] struct {
 // creation.
 func newEntityListScreen[T view.Entity](entityLabel string, listScreenOptions listScreenOptions[T]) (view.Screen, error) {
 
+	controls := listScreenOptions.controls
+	if controls == nil {
+		controls = entityListControls
+	}
+
 	listOptions := view.ListComponentOptions[T]{
-		Controls:   entityListControls,
+		Controls:   controls,
 		EntityList: listScreenOptions.entityList,
 		Columns:    listScreenOptions.columns,
+		Sort:       listScreenOptions.sort,
+		SelectSort: listScreenOptions.selectSort,
 	}
 
 	listComponent, err := view.BuildListComponent(listOptions)
@@ -92,3 +167,28 @@ func newEntityListScreen[T view.Entity](entityLabel string, listScreenOptions li
 		listComponent,
 	})
 }
+
+// newEmptyListScreen creates a screen shown in place of an empty list of
+// related records, with a menu of the actions that do not need a selected
+// record.
+//
+// Parameters:
+//   - title: The title of the list
+//   - msg: The message explaining that the list is empty
+//   - options: The labels of the available actions
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if menu component creation fails
+func newEmptyListScreen(title, msg string, options []string) (view.Screen, error) {
+	menu, err := view.NewMenuComponent(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent(title, colours.Purple),
+		view.NewTextComponent(msg),
+		menu,
+	})
+}
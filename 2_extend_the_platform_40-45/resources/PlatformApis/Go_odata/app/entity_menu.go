@@ -3,10 +3,14 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	confirmOption "github.com/turnerbenjamin/go_odata/constants/confirm_option"
+	conflictOption "github.com/turnerbenjamin/go_odata/constants/conflictoption"
 	tableMenuOption "github.com/turnerbenjamin/go_odata/constants/tablemenuoption"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
 	"github.com/turnerbenjamin/go_odata/service"
 	"github.com/turnerbenjamin/go_odata/view"
 )
@@ -29,6 +33,31 @@ type entityMenu[T view.Entity] struct {
 	entityLabel string
 	// Current search term for filtering entities
 	searchTerm string
+	// Current sort order applied to the entity list
+	sort view.ListSort
+	// Filter applied to every list, in addition to the search term. It is
+	// used to restrict the menu to the records related to a parent record
+	baseFilter requestBuilder.Filter
+	// Label of the parent record for a menu of related records, or an empty
+	// string for a top-level menu
+	parentLabel string
+	// Additional actions on the selected entity, such as showing or linking
+	// related records
+	actions []entityMenuAction
+}
+
+// entityMenuAction is an additional action offered by an entity menu, shown
+// as a list control before the back control.
+type entityMenuAction struct {
+	// Label of the list control, which must be unique within the menu
+	label string
+	// Key of the list control
+	key rune
+	// Function to perform the action on the entity with the given GUID
+	run func(guid string) error
+	// True if the action does not use the selected entity, such as linking
+	// an existing record, so that it is also offered when the list is empty
+	withoutSelection bool
 }
 
 // run starts the entity menu's main loop, handling user interactions until
@@ -37,13 +66,27 @@ type entityMenu[T view.Entity] struct {
 // Returns an error if any operation fails.
 func (em *entityMenu[T]) run() error {
 	for {
-		entityList, err := em.service.List(em.searchTerm)
+		entityList, err := em.loadEntities()
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
 		if err != nil {
 			return em.displayErrorScreen(err)
 		}
 
 		if len(entityList.Data()) == 0 {
-			err := em.notifyNoErrorsFound()
+			if em.parentLabel != "" && em.searchTerm == "" {
+				done, err := em.runEmptyMenu()
+				if err != nil {
+					err = em.displayErrorScreen(err)
+				}
+				if err != nil || done {
+					return err
+				}
+				continue
+			}
+
+			err := notifyNoRecordsFound(em.ui, em.entityLabel)
 			if err != nil || em.searchTerm == "" {
 				return err
 			}
@@ -68,7 +111,7 @@ func (em *entityMenu[T]) run() error {
 		case tableMenuOption.Delete:
 			err = em.deleteEntity(menuOutput.Target())
 		default:
-			err = fmt.Errorf("invalid menu option %s", menuOutput.UserInput())
+			err = em.runAction(menuOutput.UserInput(), menuOutput.Target())
 		}
 
 		if err != nil {
@@ -77,39 +120,151 @@ func (em *entityMenu[T]) run() error {
 	}
 }
 
-// notifyNoErrorsFound displays a message when no entities match the current
-// search criteria.
-// Returns an error if the notification screen cannot be displayed.
-func (em *entityMenu[T]) notifyNoErrorsFound() error {
-	is, err := newInfoScreen("No rows found")
+// runAction performs the additional action with the given label on the entity
+// with the given GUID.
+// Returns an error if there is no such action or the action fails.
+func (em *entityMenu[T]) runAction(label, guid string) error {
+	for _, action := range em.actions {
+		if action.label == label {
+			return action.run(guid)
+		}
+	}
+	return fmt.Errorf("invalid menu option %s", label)
+}
+
+// loadEntities fetches the entities while displaying a loading screen. The
+// user may press Esc to cancel the request, in which case context.Canceled is
+// returned.
+func (em *entityMenu[T]) loadEntities() (view.EntityList[T], error) {
+	return loadEntityList(em.ui, em.entityLabel, em.listEntities)
+}
+
+// listEntities fetches the entities matching the current search term in the
+// current sort order.
+func (em *entityMenu[T]) listEntities(ctx context.Context) (view.EntityList[T], error) {
+	filter := requestBuilder.And(em.baseFilter, em.service.SearchFilter(em.searchTerm))
+	if em.sort.LogicalName == "" {
+		return em.service.ListContext(ctx, filter)
+	}
+
+	orderBy := requestBuilder.OrderBy{
+		Property:   em.sort.LogicalName,
+		Descending: em.sort.Descending,
+	}
+	return em.service.ListContext(ctx, filter, orderBy)
+}
+
+// sortEntities applies a new sort order and fetches the entities again while
+// displaying a loading screen. The sort order is retained for subsequent
+// visits to the list unless the entities cannot be fetched. If the user
+// cancels the request, the previous sort order and current are kept.
+func (em *entityMenu[T]) sortEntities(sort view.ListSort, current view.EntityList[T]) (view.EntityList[T], error) {
+	previousSort := em.sort
+	em.sort = sort
+
+	entityList, err := em.loadEntities()
 	if err != nil {
-		return err
+		em.sort = previousSort
+	}
+	if errors.Is(err, context.Canceled) {
+		return current, nil
 	}
+	return entityList, err
+}
 
-	_, err = em.ui.NavigateTo(is)
-	return err
+// runEmptyMenu offers the actions available when a menu of related records
+// is empty: creating a record, the actions that do not need a selected
+// record, such as linking an existing record, and going back.
+// Returns true if the user chose to go back.
+func (em *entityMenu[T]) runEmptyMenu() (bool, error) {
+	createLabel := "Create " + em.entityLabel
+	backLabel := "Back to " + em.parentLabel
+
+	options := []string{createLabel}
+	for _, action := range em.actions {
+		if action.withoutSelection {
+			options = append(options, action.label)
+		}
+	}
+	options = append(options, backLabel)
+
+	msg := fmt.Sprintf("%s has no %s records.", em.parentLabel, em.entityLabel)
+	emptyMenuScreen, err := newEmptyListScreen(em.listTitle(), msg, options)
+	if err != nil {
+		return false, err
+	}
+
+	response, err := em.ui.NavigateTo(emptyMenuScreen)
+	if err != nil {
+		return false, err
+	}
+
+	switch response.UserInput() {
+	case createLabel:
+		return false, em.createEntity()
+	case backLabel:
+		return true, nil
+	default:
+		return false, em.runAction(response.UserInput(), "")
+	}
 }
 
 // displayEntityMenu creates and shows the entity list screen with the provided
-// entity data.
+// entity data. When the user changes the sort order, the entities are fetched
+// again and the list is shown in the new order.
 // Returns the user's selection and any error encountered.
 func (em *entityMenu[T]) displayEntityMenu(entityList view.EntityList[T]) (view.ScreenOutput, error) {
-	entityListScreen, err := newEntityListScreen(
-		em.entityLabel,
-		listScreenOptions[T]{
-			entityList: entityList,
-			columns:    em.listColumns,
-		},
-	)
-	if err != nil {
-		return nil, err
+	for {
+		var selectedSort view.ListSort
+		entityListScreen, err := newEntityListScreen(
+			em.listTitle(),
+			listScreenOptions[T]{
+				controls:   em.listControls(),
+				entityList: entityList,
+				columns:    em.listColumns,
+				sort:       em.sort,
+				selectSort: func(sort view.ListSort) {
+					selectedSort = sort
+				},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := em.ui.NavigateTo(entityListScreen)
+		if err != nil || output.UserInput() != view.SortRequested {
+			return output, err
+		}
+
+		entityList, err = em.sortEntities(selectedSort, entityList)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// listTitle returns the title of the entity list screen, which names the
+// parent record for a menu of related records.
+func (em *entityMenu[T]) listTitle() string {
+	if em.parentLabel == "" {
+		return em.entityLabel
 	}
+	return fmt.Sprintf("%s (%s)", em.entityLabel, em.parentLabel)
+}
 
-	outputs, err := em.ui.NavigateTo(entityListScreen)
-	if err != nil {
-		return nil, err
+// listControls returns the controls of the entity list screen, or nil to use
+// the standard controls.
+func (em *entityMenu[T]) listControls() []view.ListControl {
+	if len(em.actions) == 0 && em.parentLabel == "" {
+		return nil
 	}
-	return outputs, nil
+
+	backLabel := ""
+	if em.parentLabel != "" {
+		backLabel = "Back to " + em.parentLabel
+	}
+	return newEntityListControls(em.actions, backLabel)
 }
 
 // createEntity handles the workflow for creating a new entity.
@@ -134,6 +289,8 @@ func (em *entityMenu[T]) createEntity() error {
 // It fetches the current entity data, prompts for updates, calls the service to
 // update it, and displays a success message.
 // The guid parameter identifies the entity to update.
+// If the entity was changed by someone else in the meantime, the user is
+// asked whether to reload, overwrite or cancel.
 // Returns an error if any step in the process fails.
 func (em *entityMenu[T]) updateEntity(guid string) error {
 	currentEntity, err := em.service.Get(guid)
@@ -146,6 +303,9 @@ func (em *entityMenu[T]) updateEntity(guid string) error {
 	}
 
 	err = em.service.Update(guid, entityToUpdate)
+	if errors.Is(err, service.ErrConcurrencyConflict) {
+		return em.resolveUpdateConflict(guid, entityToUpdate)
+	}
 	if err != nil {
 		return err
 	}
@@ -153,10 +313,43 @@ func (em *entityMenu[T]) updateEntity(guid string) error {
 	return em.displaySuccessScreen(successMsg)
 }
 
+// resolveUpdateConflict asks the user how to handle an update rejected
+// because the entity changed since it was retrieved. Reloading restarts the
+// update with the latest values, overwriting saves entityToUpdate regardless
+// and cancelling discards the changes.
+// Returns an error if any step in the process fails.
+func (em *entityMenu[T]) resolveUpdateConflict(guid string, entityToUpdate T) error {
+	conflictScreen, err := newConflictScreen(em.entityLabel)
+	if err != nil {
+		return err
+	}
+
+	response, err := em.ui.NavigateTo(conflictScreen)
+	if err != nil {
+		return err
+	}
+
+	switch conflictOption.ConflictOption(response.UserInput()) {
+	case conflictOption.Reload:
+		return em.updateEntity(guid)
+	case conflictOption.Overwrite:
+		err = em.service.Overwrite(guid, entityToUpdate)
+		if err != nil {
+			return err
+		}
+		successMsg := fmt.Sprintf("%s updated", em.entityLabel)
+		return em.displaySuccessScreen(successMsg)
+	default:
+		return nil
+	}
+}
+
 // deleteEntity handles the workflow for deleting an existing entity.
 // It fetches the entity data, prompts for confirmation, calls the service to
 // delete it, and displays a success message if confirmed.
 // The guid parameter identifies the entity to delete.
+// If the entity was changed by someone else in the meantime, the user is
+// asked whether to reload, delete anyway or cancel.
 // Returns an error if any step in the process fails.
 func (em *entityMenu[T]) deleteEntity(guid string) error {
 	entityToDelete, err := em.service.Get(guid)
@@ -179,6 +372,9 @@ func (em *entityMenu[T]) deleteEntity(guid string) error {
 	}
 
 	err = em.service.Delete(guid)
+	if errors.Is(err, service.ErrConcurrencyConflict) {
+		return em.resolveDeleteConflict(guid)
+	}
 	if err != nil {
 		return err
 	}
@@ -186,24 +382,46 @@ func (em *entityMenu[T]) deleteEntity(guid string) error {
 	return em.displaySuccessScreen(successMsg)
 }
 
-// setSearchTerm prompts the user to enter a search term for filtering entities.
-// An empty search term clears the filter.
-// Returns an error if the input screen cannot be displayed.
-func (em *entityMenu[T]) setSearchTerm() error {
-	title := fmt.Sprintf("Set search term: %ss", em.entityLabel)
-	msg := "Enter a search term (or leave blank to unset)"
-
-	inputScreen, err := newStringInputScreen(title, msg, "SearchTerm", em.searchTerm, false)
+// resolveDeleteConflict asks the user how to handle a delete rejected
+// because the entity changed since it was retrieved. Reloading restarts the
+// delete so that the user confirms it against the latest values, deleting
+// anyway removes the entity regardless and cancelling keeps it.
+// Returns an error if any step in the process fails.
+func (em *entityMenu[T]) resolveDeleteConflict(guid string) error {
+	conflictScreen, err := newDeleteConflictScreen(em.entityLabel)
 	if err != nil {
 		return err
 	}
 
-	inputScreenOutputs, err := em.ui.NavigateTo(inputScreen)
+	response, err := em.ui.NavigateTo(conflictScreen)
 	if err != nil {
 		return err
 	}
 
-	em.searchTerm = inputScreenOutputs.UserInput()
+	switch conflictOption.ConflictOption(response.UserInput()) {
+	case conflictOption.ReloadDelete:
+		return em.deleteEntity(guid)
+	case conflictOption.DeleteAnyway:
+		err = em.service.ForceDelete(guid)
+		if err != nil {
+			return err
+		}
+		successMsg := fmt.Sprintf("%s deleted", em.entityLabel)
+		return em.displaySuccessScreen(successMsg)
+	default:
+		return nil
+	}
+}
+
+// setSearchTerm prompts the user to enter a search term for filtering entities.
+// An empty search term clears the filter.
+// Returns an error if the input screen cannot be displayed.
+func (em *entityMenu[T]) setSearchTerm() error {
+	searchTerm, err := promptForSearchTerm(em.ui, em.entityLabel, em.searchTerm)
+	if err != nil {
+		return err
+	}
+	em.searchTerm = searchTerm
 	return nil
 }
 
@@ -222,7 +440,7 @@ func (em *entityMenu[T]) displaySuccessScreen(message string) error {
 // Returns the original error if displaying the error screen fails,
 // otherwise returns nil to indicate the error was displayed successfully.
 func (em *entityMenu[T]) displayErrorScreen(originalError error) error {
-	es, err := newErrorScreen(originalError.Error())
+	es, err := newErrorScreen(errorScreenMessage(originalError))
 	if err != nil {
 		return originalError
 	}
@@ -0,0 +1,71 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// loadEntityList fetches a list of entities with list while displaying a
+// loading screen. It is shared by the entity menu and the record picker.
+// The user may press Esc to cancel the request, in which case
+// context.Canceled is returned.
+func loadEntityList[T view.Entity](
+	ui view.UI,
+	entityLabel string,
+	list func(ctx context.Context) (view.EntityList[T], error)) (view.EntityList[T], error) {
+
+	loadingScreen, err := newLoadingScreen(
+		fmt.Sprintf("Loading %s records...", entityLabel))
+	if err != nil {
+		return nil, err
+	}
+
+	var entityList view.EntityList[T]
+	err = ui.RunCancellable(loadingScreen, func(ctx context.Context) error {
+		var err error
+		entityList, err = list(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entityList, nil
+}
+
+// promptForSearchTerm asks the user to enter a search term for filtering
+// entities, showing the current search term as the default value. An empty
+// search term clears the filter.
+// Returns the new search term, or an error if the input screen cannot be
+// displayed.
+func promptForSearchTerm(ui view.UI, entityLabel, searchTerm string) (string, error) {
+	title := fmt.Sprintf("Set search term: %ss", entityLabel)
+	msg := "Enter a search term (or leave blank to unset)"
+
+	inputScreen, err := newStringInputScreen(title, msg, "SearchTerm", searchTerm, false)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := ui.NavigateTo(inputScreen)
+	if err != nil {
+		return "", err
+	}
+	return output.UserInput(), nil
+}
+
+// notifyNoRecordsFound displays a message when no entities match the current
+// search criteria.
+// Returns an error if the notification screen cannot be displayed.
+func notifyNoRecordsFound(ui view.UI, entityLabel string) error {
+	is, err := newInfoScreen(fmt.Sprintf("No %s records found", entityLabel))
+	if err != nil {
+		return err
+	}
+
+	_, err = ui.NavigateTo(is)
+	return err
+}
@@ -0,0 +1,31 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// newEnvironmentScreen creates a screen allowing the user to choose the
+// environment to connect to. It is displayed before the configuration
+// screen when more than one environment profile is configured.
+//
+// Parameters:
+//   - labels: The label of each environment, used as the menu options
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if menu component creation fails
+func newEnvironmentScreen(labels []string) (view.Screen, error) {
+	menu, err := view.NewMenuComponent(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent("CONFIGURATION", colours.Purple),
+		view.NewTextComponent("Select environment"),
+		menu,
+	})
+}
@@ -3,8 +3,10 @@
 package app
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/turnerbenjamin/go_odata/service"
 	"github.com/turnerbenjamin/go_odata/view"
 	"github.com/turnerbenjamin/go_odata/view/colours"
 )
@@ -29,3 +31,22 @@ func newErrorScreen(msg string) (view.Screen, error) {
 		view.NewAnyKeyToContinueComponent(),
 	})
 }
+
+// errorScreenMessage returns the message to display for err. Errors returned
+// by the Dataverse Web API are followed by their error code and request ID to
+// help with troubleshooting.
+func errorScreenMessage(err error) string {
+	msg := err.Error()
+	dvErr, ok := service.AsDataverseError(err)
+	if !ok {
+		return msg
+	}
+
+	if dvErr.Code != "" {
+		msg += fmt.Sprintf("\n\nError code: %s", dvErr.Code)
+	}
+	if dvErr.RequestID != "" {
+		msg += fmt.Sprintf("\nRequest ID: %s", dvErr.RequestID)
+	}
+	return msg
+}
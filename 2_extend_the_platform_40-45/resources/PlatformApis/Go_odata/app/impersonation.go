@@ -0,0 +1,63 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"github.com/turnerbenjamin/go_odata/model"
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// impersonate lets the user pick a system user, then makes every subsequent
+// request on behalf of that user until impersonation is stopped. The user is
+// named in the header of every screen while impersonated. Errors are shown
+// to the user, who returns to the main menu.
+func (a *app) impersonate() {
+	picker := recordPicker[*model.SystemUser]{
+		ui:          a.ui,
+		service:     a.systemUsersService,
+		listColumns: a.systemUsersListColumns,
+		entityLabel: "User",
+		title:       "Select user to impersonate",
+	}
+
+	userGuid, ok, err := picker.pick()
+	if err != nil {
+		a.displayErrorMessage(err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	user, err := a.systemUsersService.Get(userGuid)
+	if err != nil {
+		a.displayErrorMessage(err)
+		return
+	}
+
+	a.dataverseService.Impersonate(service.Caller{
+		ObjectID:     user.ObjectID,
+		SystemUserID: user.Id,
+	})
+	a.impersonatedUser = user.Label()
+	a.showIdentity()
+	a.displaySuccessMessage("Impersonating " + a.impersonatedUser)
+}
+
+// stopImpersonating makes subsequent requests as the signed in identity.
+func (a *app) stopImpersonating() {
+	a.dataverseService.StopImpersonating()
+	a.impersonatedUser = ""
+	a.showIdentity()
+	a.displaySuccessMessage("Stopped impersonating")
+}
+
+// displayErrorMessage shows an error message to the user, who may then
+// continue using the application.
+func (a *app) displayErrorMessage(originalError error) {
+	es, err := newErrorScreen(errorScreenMessage(originalError))
+	if err != nil {
+		return
+	}
+	a.ui.NavigateTo(es)
+}
@@ -0,0 +1,27 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"github.com/turnerbenjamin/go_odata/view"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// newLoadingScreen creates a screen that is displayed while a request is in
+// progress.
+// The screen includes a loading title in blue, the provided message text, and
+// a prompt for the user to press Esc to cancel the request.
+//
+// Parameters:
+//   - msg: The message describing the work in progress
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if screen creation fails
+func newLoadingScreen(msg string) (view.Screen, error) {
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent("LOADING", colours.Blue),
+		view.NewTextComponent(msg),
+		view.NewCancelPromptComponent(),
+	})
+}
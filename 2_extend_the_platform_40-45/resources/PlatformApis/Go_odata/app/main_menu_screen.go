@@ -9,22 +9,35 @@ import (
 )
 
 // newMainMenuScreen creates the main menu screen for the application.
-// It constructs a menu with options for different tables (Accounts, Contacts)
-// and an Exit option.
+// It constructs a menu with options for different tables (Accounts, Contacts),
+// an option to browse any table, an option to start or stop impersonating a
+// user, an option to sign out and clear the token cache, and an Exit option.
 //
 // The screen includes:
 // - A title "Table Selection" in purple color
 // - Instructional text "Choose a table"
-// - A menu with table and exit options
+// - A menu with table, impersonation, sign out and exit options
+//
+// Parameters:
+//   - impersonating: Whether a user is being impersonated, in which case the
+//     menu offers to stop rather than start impersonating
 //
 // Returns:
 //   - A Screen object ready to be rendered
 //   - An error if menu component creation fails
-func newMainMenuScreen() (view.Screen, error) {
+func newMainMenuScreen(impersonating bool) (view.Screen, error) {
+
+	impersonationOption := mainMenuOption.Impersonate
+	if impersonating {
+		impersonationOption = mainMenuOption.StopImpersonating
+	}
 
 	menu, err := view.NewMenuComponent([]string{
 		string(mainMenuOption.Accounts),
 		string(mainMenuOption.Contacts),
+		string(mainMenuOption.Tables),
+		string(impersonationOption),
+		string(mainMenuOption.SignOut),
 		string(mainMenuOption.Exit),
 	})
 
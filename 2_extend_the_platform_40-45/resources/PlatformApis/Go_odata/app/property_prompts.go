@@ -3,90 +3,394 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
 	"github.com/turnerbenjamin/go_odata/model"
 	"github.com/turnerbenjamin/go_odata/view"
 )
 
-// propertyPrompt defines a text prompt for collecting entity property values
-// from the user. It includes information about the property and functions to
-// get and set its value for a specific entity type.
+// propertyPrompt defines a prompt for collecting entity property values from
+// the user. It includes information about the property and functions to get
+// and set its value for a specific entity type.
+//
+// Text properties use getter and setter with a string input. Other properties,
+// such as lookups and choices, provide newInput and setOutput instead.
 type propertyPrompt[T view.Entity] struct {
 	propertyName string          // Name of the property to display to the user
 	promptText   string          // Text to display when prompting for input
 	isRequired   bool            // Whether the property is required
 	getter       func(T) string  // Function to retrieve current property value
 	setter       func(T, string) // Function to set the property value
+	// Function to create the input component for a property that is not
+	// edited as text, initialised from the entity's current value
+	newInput func(T) (view.InteractiveComponent, error)
+	// Function to set a property edited with newInput from the input's
+	// output. Returns an error if the output is not a valid value
+	setOutput func(T, view.ScreenOutput) error
+}
+
+// input creates the input component for the property, initialised from the
+// entity's current value.
+func (p propertyPrompt[T]) input(entity T) (view.InteractiveComponent, error) {
+	if p.newInput != nil {
+		return p.newInput(entity)
+	}
+	return view.NewStringInputComponent(p.propertyName, p.getter(entity), p.isRequired), nil
+}
+
+// apply sets the property from the output of its input screen.
+// Returns an error if the output is not a valid value for the property.
+func (p propertyPrompt[T]) apply(entity T, output view.ScreenOutput) error {
+	if p.setOutput != nil {
+		return p.setOutput(entity, output)
+	}
+	p.setter(entity, output.UserInput())
+	return nil
+}
+
+// lookupPrompt describes the table referenced by a lookup property prompt.
+type lookupPrompt struct {
+	// Logical name of the referenced table, e.g. "account"
+	logicalName string
+	// Entity set of the referenced table, e.g. "accounts"
+	entitySet string
+	// Navigation property that sets the lookup, e.g.
+	// "parentcustomerid_account"
+	navigationProperty string
+	// Function to search the referenced table
+	search view.LookupSearchFunc
+}
+
+// newLookupPropertyPrompt creates a prompt that lets the user search the
+// referenced table and pick the record a lookup refers to. Keeping the
+// current value leaves the lookup unchanged, and clearing an optional lookup
+// sets it to null.
+func newLookupPropertyPrompt[T view.Entity](
+	propertyName, promptText string,
+	isRequired bool,
+	target lookupPrompt,
+	getLookup func(T) *model.Lookup,
+	setLookup func(T, *model.Lookup)) propertyPrompt[T] {
+	return propertyPrompt[T]{
+		propertyName: propertyName,
+		promptText:   promptText,
+		isRequired:   isRequired,
+		newInput: func(entity T) (view.InteractiveComponent, error) {
+			return view.NewLookupInputComponent(view.LookupInputOptions{
+				PropertyName: propertyName,
+				CurrentLabel: getLookup(entity).Label(),
+				IsRequired:   isRequired,
+				Search:       target.search,
+			})
+		},
+		setOutput: func(entity T, output view.ScreenOutput) error {
+			switch output.Target() {
+			case "":
+				return nil
+			case view.ClearedTarget:
+				setLookup(entity, clearedLookup(getLookup(entity), target.navigationProperty))
+				return nil
+			}
+			setLookup(entity, &model.Lookup{
+				ID:                 output.Target(),
+				LogicalName:        target.logicalName,
+				Name:               output.UserInput(),
+				NavigationProperty: target.navigationProperty,
+				EntitySet:          target.entitySet,
+			})
+			return nil
+		},
+	}
+}
+
+// clearedLookup returns a lookup that clears current when its record is
+// updated, or nil if current is not set. The navigation property of a
+// retrieved lookup is used, as it identifies the table referenced by a
+// polymorphic lookup, falling back to navigationProperty.
+func clearedLookup(current *model.Lookup, navigationProperty string) *model.Lookup {
+	if current == nil || current.Cleared {
+		return current
+	}
+	if current.NavigationProperty != "" {
+		navigationProperty = current.NavigationProperty
+	}
+	return &model.Lookup{NavigationProperty: navigationProperty, Cleared: true}
+}
+
+// newChoicePropertyPrompt creates a prompt that lets the user choose one or,
+// if multiSelect is true, more options of a choice column. The options are
+// loaded when the prompt is displayed. Keeping the current value leaves the
+// column unchanged, and clearing an optional choice calls setValues with no
+// values so that the column is set to null.
+func newChoicePropertyPrompt[T view.Entity](
+	propertyName, promptText string,
+	isRequired, multiSelect bool,
+	loadOptions func() ([]view.ChoiceOption, error),
+	getValues func(T) []int,
+	setValues func(T, []int, string)) propertyPrompt[T] {
+	return propertyPrompt[T]{
+		propertyName: propertyName,
+		promptText:   promptText,
+		isRequired:   isRequired,
+		newInput: func(entity T) (view.InteractiveComponent, error) {
+			options, err := loadOptions()
+			if err != nil {
+				return nil, err
+			}
+			return view.NewChoiceInputComponent(view.ChoiceInputOptions{
+				PropertyName: propertyName,
+				Options:      options,
+				Selected:     getValues(entity),
+				IsRequired:   isRequired,
+				MultiSelect:  multiSelect,
+			})
+		},
+		setOutput: func(entity T, output view.ScreenOutput) error {
+			switch output.Target() {
+			case "":
+				return nil
+			case view.ClearedTarget:
+				setValues(entity, nil, "")
+				return nil
+			}
+			values, err := model.ParseMultiChoiceValues(output.Target())
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", strings.ToLower(propertyName), err)
+			}
+			setValues(entity, values, output.UserInput())
+			return nil
+		},
+	}
 }
 
 // accountPropertyPrompts defines the collection of prompts for Account entity
-// properties.
+// properties. The options of choice columns are retrieved with loadOptions.
+// The status can only be changed when forCreate is false, as accounts are
+// always created active.
 // Each prompt includes display text, validation rules, and getter/setter
 // functions.
-var accountPropertyPrompts = []propertyPrompt[*model.Account]{
-	{
-		propertyName: "Name",
-		promptText:   "Enter account name",
-		isRequired:   true,
-		getter: func(a *model.Account) string {
-			return a.Name
+func accountPropertyPrompts(loadOptions choiceOptionsFunc, forCreate bool) []propertyPrompt[*model.Account] {
+	prompts := []propertyPrompt[*model.Account]{
+		{
+			propertyName: "Name",
+			promptText:   "Enter account name",
+			isRequired:   true,
+			getter: func(a *model.Account) string {
+				return a.Name
+			},
+			setter: func(a *model.Account, value string) {
+				a.Name = value
+			},
 		},
-		setter: func(a *model.Account, value string) {
-			a.Name = value
+		{
+			propertyName: "City",
+			promptText:   "Enter account city",
+			isRequired:   true,
+			getter: func(a *model.Account) string {
+				return a.City
+			},
+			setter: func(a *model.Account, value string) {
+				a.City = value
+			},
 		},
-	},
-	{
-		propertyName: "City",
-		promptText:   "Enter account city",
-		isRequired:   true,
-		getter: func(a *model.Account) string {
-			return a.City
+		newChoicePropertyPrompt(
+			"Industry",
+			"Choose the account's industry",
+			false,
+			false,
+			func() ([]view.ChoiceOption, error) {
+				return loadOptions(logicalNames.TableAccount, model.AttributeDefinition{
+					LogicalName:   logicalNames.ColumnAccountIndustry,
+					AttributeType: model.AttributeTypePicklist,
+				})
+			},
+			func(a *model.Account) []int {
+				return choiceValues(a.Industry)
+			},
+			func(a *model.Account, values []int, label string) {
+				if len(values) == 0 {
+					a.Industry = &model.Choice{Cleared: true}
+					return
+				}
+				a.Industry = &model.Choice{Value: values[0], Label: label}
+			}),
+	}
+
+	if forCreate {
+		return prompts
+	}
+	return append(prompts, newChoicePropertyPrompt(
+		"Status",
+		"Choose the account's status",
+		true,
+		false,
+		func() ([]view.ChoiceOption, error) {
+			return loadOptions(logicalNames.TableAccount, model.AttributeDefinition{
+				LogicalName:   logicalNames.ColumnAccountStatus,
+				AttributeType: model.AttributeTypeState,
+			})
 		},
-		setter: func(a *model.Account, value string) {
-			a.City = value
+		func(a *model.Account) []int {
+			return choiceValues(a.Status)
 		},
-	},
+		func(a *model.Account, values []int, label string) {
+			a.Status = &model.Choice{Value: values[0], Label: label}
+		}))
+}
+
+// choiceValues returns the value of a choice as a slice for a choice input,
+// which is empty if the choice is not set or has been cleared.
+func choiceValues(c *model.Choice) []int {
+	if c == nil || c.Cleared {
+		return nil
+	}
+	return []int{c.Value}
 }
 
 // contactPropertyPrompts defines the collection of prompts for Contact entity
-// properties.
+// properties. The parent customer is chosen from the accounts found by
+// searchAccounts.
 // Each prompt includes display text, validation rules, and getter/setter
 // functions.
-var contactPropertyPrompts = []propertyPrompt[*model.Contact]{
-	{
-		propertyName: "First name",
-		promptText:   "Enter contact's first name",
-		isRequired:   true,
-		getter: func(a *model.Contact) string {
-			return a.FirstName
+func contactPropertyPrompts(searchAccounts view.LookupSearchFunc) []propertyPrompt[*model.Contact] {
+	return []propertyPrompt[*model.Contact]{
+		{
+			propertyName: "First name",
+			promptText:   "Enter contact's first name",
+			isRequired:   true,
+			getter: func(a *model.Contact) string {
+				return a.FirstName
+			},
+			setter: func(a *model.Contact, value string) {
+				a.FirstName = value
+			},
 		},
-		setter: func(a *model.Contact, value string) {
-			a.FirstName = value
+		{
+			propertyName: "Last name",
+			promptText:   "Enter contact's last name",
+			isRequired:   false,
+			getter: func(a *model.Contact) string {
+				return a.LastName
+			},
+			setter: func(a *model.Contact, value string) {
+				a.LastName = value
+			},
 		},
-	},
-	{
-		propertyName: "Last name",
-		promptText:   "Enter contact's last name",
-		isRequired:   false,
-		getter: func(a *model.Contact) string {
-			return a.LastName
+		{
+			propertyName: "Email",
+			promptText:   "Enter contact's email address",
+			isRequired:   false,
+			getter: func(a *model.Contact) string {
+				return a.Email
+			},
+			setter: func(a *model.Contact, value string) {
+				a.Email = value
+			},
 		},
-		setter: func(a *model.Contact, value string) {
-			a.LastName = value
-		},
-	},
-	{
-		propertyName: "Email",
-		promptText:   "Enter contact's email address",
-		isRequired:   false,
-		getter: func(a *model.Contact) string {
-			return a.Email
+		newLookupPropertyPrompt(
+			"Company",
+			"Search for the contact's company",
+			false,
+			lookupPrompt{
+				logicalName:        logicalNames.TableAccount,
+				entitySet:          logicalNames.TableAccountResource,
+				navigationProperty: logicalNames.NavigationContactParentAccount,
+				search:             searchAccounts,
+			},
+			func(c *model.Contact) *model.Lookup {
+				return c.ParentCustomer
+			},
+			func(c *model.Contact, l *model.Lookup) {
+				c.ParentCustomer = l
+			}),
+	}
+}
+
+// dynamicRecordPropertyPrompts builds prompts for the text and choice columns
+// of a table browsed without a dedicated model. Only columns that can be set
+// in the operation are included: those valid for create if forCreate is
+// true, and those valid for update otherwise. The options of choice columns
+// are retrieved with loadOptions.
+func dynamicRecordPropertyPrompts(
+	entityLogicalName string,
+	attributes []model.AttributeDefinition,
+	forCreate bool,
+	loadOptions choiceOptionsFunc) []propertyPrompt[*model.DynamicRecord] {
+	prompts := []propertyPrompt[*model.DynamicRecord]{}
+	for _, a := range attributes {
+		isValid := a.IsValidForUpdate
+		if forCreate {
+			isValid = a.IsValidForCreate
+		}
+		if !isValid {
+			continue
+		}
+		if a.IsChoice() {
+			prompts = append(prompts, dynamicRecordChoicePrompt(entityLogicalName, a, loadOptions))
+			continue
+		}
+		if !a.IsText() {
+			continue
+		}
+
+		logicalName := a.LogicalName
+		prompts = append(prompts, propertyPrompt[*model.DynamicRecord]{
+			propertyName: a.Label(),
+			promptText:   fmt.Sprintf("Enter %s", strings.ToLower(a.Label())),
+			isRequired:   a.IsRequired(),
+			getter: func(r *model.DynamicRecord) string {
+				return r.GetString(logicalName)
+			},
+			setter: func(r *model.DynamicRecord, value string) {
+				r.Set(logicalName, value)
+			},
+		})
+	}
+	return prompts
+}
+
+// dynamicRecordChoicePrompt builds a prompt for a choice column of a table
+// browsed without a dedicated model. Single-select choices are stored as
+// numbers, and multi-select choices as comma separated strings, as returned
+// by the Web API.
+func dynamicRecordChoicePrompt(
+	entityLogicalName string,
+	attribute model.AttributeDefinition,
+	loadOptions choiceOptionsFunc) propertyPrompt[*model.DynamicRecord] {
+	logicalName := attribute.LogicalName
+	multiSelect := attribute.IsMultiSelectChoice()
+
+	return newChoicePropertyPrompt(
+		attribute.Label(),
+		fmt.Sprintf("Choose %s", strings.ToLower(attribute.Label())),
+		attribute.IsRequired(),
+		multiSelect,
+		func() ([]view.ChoiceOption, error) {
+			return loadOptions(entityLogicalName, attribute)
 		},
-		setter: func(a *model.Contact, value string) {
-			a.Email = value
+		func(r *model.DynamicRecord) []int {
+			values, _ := model.ParseMultiChoiceValues(r.GetString(logicalName))
+			return values
 		},
-	},
+		func(r *model.DynamicRecord, values []int, _ string) {
+			if len(values) == 0 {
+				r.Set(logicalName, nil)
+				return
+			}
+			if multiSelect {
+				strs := make([]string, len(values))
+				for i, v := range values {
+					strs[i] = strconv.Itoa(v)
+				}
+				r.Set(logicalName, strings.Join(strs, ","))
+				return
+			}
+			r.Set(logicalName, json.Number(strconv.Itoa(values[0])))
+		})
 }
 
 // screenOutputFunc represents a function that displays a screen to the user
@@ -120,18 +424,20 @@ func getEntityDetails[T view.Entity](
 
 	for _, p := range prompts {
 		promptOutput, err := getScreenOutput(func() (view.Screen, error) {
-			return newStringInputScreen(
-				title,
-				p.promptText,
-				p.propertyName,
-				p.getter(defaultValuesCopy),
-				p.isRequired)
+			input, err := p.input(defaultValuesCopy)
+			if err != nil {
+				return nil, err
+			}
+			return newPropertyInputScreen(title, p.promptText, input)
 		})
 		if err != nil {
 			var zeroValue T
 			return zeroValue, fmt.Errorf("getting property %s: %w", p.propertyName, err)
 		}
-		p.setter(entityDetails, promptOutput.UserInput())
+		if err := p.apply(entityDetails, promptOutput); err != nil {
+			var zeroValue T
+			return zeroValue, fmt.Errorf("setting property %s: %w", p.propertyName, err)
+		}
 	}
 	return entityDetails, nil
 }
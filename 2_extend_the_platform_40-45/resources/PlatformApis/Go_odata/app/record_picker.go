@@ -0,0 +1,113 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tableMenuOption "github.com/turnerbenjamin/go_odata/constants/tablemenuoption"
+	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// recordPicker lets the user choose a single record of type T, for example
+// the target of an association. The list can be searched like an entity menu
+// but offers no create, update or delete controls.
+type recordPicker[T view.Entity] struct {
+	// User interface instance for screen navigation
+	ui view.UI
+	// Service used to list the records
+	service service.EntityService[T]
+	// Column definitions for the record list display
+	listColumns []view.ListColumn[T]
+	// Human-readable label for the record type
+	entityLabel string
+	// Title of the record list screen
+	title string
+	// Current search term for filtering records
+	searchTerm string
+}
+
+// pick displays the records until the user picks one or cancels.
+// Returns the GUID of the picked record and true, or false if the user
+// cancelled or there were no records to pick from.
+func (rp *recordPicker[T]) pick() (string, bool, error) {
+	for {
+		entityList, err := rp.loadEntities()
+		if errors.Is(err, context.Canceled) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		if len(entityList.Data()) == 0 {
+			err := notifyNoRecordsFound(rp.ui, rp.entityLabel)
+			if err != nil || rp.searchTerm == "" {
+				return "", false, err
+			}
+			rp.searchTerm = ""
+			continue
+		}
+
+		output, err := rp.displayRecordList(entityList)
+		if err != nil {
+			return "", false, err
+		}
+
+		switch tableMenuOption.TableMenuOption(output.UserInput()) {
+		case tableMenuOption.Pick:
+			return output.Target(), true, nil
+		case tableMenuOption.Search:
+			err = rp.setSearchTerm()
+		case tableMenuOption.Back:
+			return "", false, nil
+		default:
+			err = fmt.Errorf("invalid menu option %s", output.UserInput())
+		}
+
+		if err != nil {
+			return "", false, err
+		}
+	}
+}
+
+// loadEntities fetches the records matching the current search term while
+// displaying a loading screen. The user may press Esc to cancel the request,
+// in which case context.Canceled is returned.
+func (rp *recordPicker[T]) loadEntities() (view.EntityList[T], error) {
+	return loadEntityList(rp.ui, rp.entityLabel, func(ctx context.Context) (view.EntityList[T], error) {
+		return rp.service.ListContext(ctx, rp.service.SearchFilter(rp.searchTerm))
+	})
+}
+
+// displayRecordList shows the records with the picker controls.
+// Returns the user's selection and any error encountered.
+func (rp *recordPicker[T]) displayRecordList(entityList view.EntityList[T]) (view.ScreenOutput, error) {
+	listScreen, err := newEntityListScreen(
+		rp.title,
+		listScreenOptions[T]{
+			controls:   recordPickerControls,
+			entityList: entityList,
+			columns:    rp.listColumns,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rp.ui.NavigateTo(listScreen)
+}
+
+// setSearchTerm prompts the user to enter a search term for filtering
+// records. An empty search term clears the filter.
+// Returns an error if the input screen cannot be displayed.
+func (rp *recordPicker[T]) setSearchTerm() error {
+	searchTerm, err := promptForSearchTerm(rp.ui, rp.entityLabel, rp.searchTerm)
+	if err != nil {
+		return err
+	}
+	rp.searchTerm = searchTerm
+	return nil
+}
@@ -28,3 +28,41 @@ func newStringInputScreen(title, text, propertyName, value string, isRequired bo
 		view.NewStringInputComponent(propertyName, value, isRequired),
 	})
 }
+
+// newPropertyInputScreen creates a screen that prompts the user to input the
+// value of an entity property with the given input component.
+//
+// Parameters:
+//   - title: The title text to display at the top of the screen
+//   - text: Instructions or explanation text to display
+//   - input: The component used to edit the property's value
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if screen creation fails
+func newPropertyInputScreen(title, text string, input view.InteractiveComponent) (view.Screen, error) {
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent(title, colours.Purple),
+		view.NewTextComponent(text),
+		input,
+	})
+}
+
+// newMaskedInputScreen creates a screen that prompts the user to input a
+// secret, displaying an asterisk for each character entered.
+//
+// Parameters:
+//   - title: The title text to display at the top of the screen
+//   - text: Instructions or explanation text to display
+//   - propertyName: Name of the secret (shown as field label)
+//
+// Returns:
+//   - A Screen object ready to be rendered
+//   - An error if screen creation fails
+func newMaskedInputScreen(title, text, propertyName string) (view.Screen, error) {
+	return view.MakeScreen([]view.Component{
+		view.NewTitleComponent(title, colours.Purple),
+		view.NewTextComponent(text),
+		view.NewMaskedStringInputComponent(propertyName, true),
+	})
+}
@@ -0,0 +1,353 @@
+// Package app implements the application-level functionality for the OData
+// client, including screens, navigation, and business logic.
+package app
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	tableMenuOption "github.com/turnerbenjamin/go_odata/constants/tablemenuoption"
+	"github.com/turnerbenjamin/go_odata/model"
+	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// maxDynamicRecordColumns is the maximum number of columns displayed when
+// browsing a table without a dedicated model.
+const maxDynamicRecordColumns = 4
+
+// tableBrowser lists the tables in the environment and opens an entity menu
+// over the rows of the selected table. Rows are represented by
+// model.DynamicRecord, with list columns and property prompts generated from
+// the table's column definitions.
+type tableBrowser struct {
+	// User interface instance for screen navigation
+	ui view.UI
+	// Service for retrieving table and column definitions
+	metadataService service.MetadataService
+	// Function to retrieve the options of choice columns
+	loadChoiceOptions choiceOptionsFunc
+	// Service used to create an entity service for the selected table
+	dataverseService service.DataverseService
+	// Root URL of the Web API
+	baseURL *url.URL
+	// Maximum number of rows to retrieve per page
+	pageLimit int
+	// Function to display screens and collect user input
+	getScreenOutput screenOutputFunc
+	// Table definitions, sorted by display name and loaded on first use
+	definitions []model.EntityDefinition
+	// Current search term for filtering tables
+	searchTerm string
+}
+
+// run starts the table browser's main loop, handling user interactions until
+// the user returns to the main menu.
+// Returns an error if any operation fails.
+func (tb *tableBrowser) run() error {
+	if tb.definitions == nil {
+		err := tb.loadDefinitions()
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return tb.displayErrorScreen(err)
+		}
+	}
+
+	for {
+		definitions := tb.matchingDefinitions()
+		if len(definitions) == 0 {
+			err := tb.notifyNoTablesFound()
+			if err != nil || tb.searchTerm == "" {
+				return err
+			}
+			tb.searchTerm = ""
+			continue
+		}
+
+		menuOutput, err := tb.displayTableList(definitions)
+		if err != nil {
+			return tb.displayErrorScreen(err)
+		}
+
+		switch tableMenuOption.TableMenuOption(menuOutput.UserInput()) {
+		case tableMenuOption.Back:
+			return nil
+		case tableMenuOption.Search:
+			err = tb.setSearchTerm()
+		case tableMenuOption.Open:
+			err = tb.openTable(menuOutput.Target())
+		default:
+			err = fmt.Errorf("invalid menu option %s", menuOutput.UserInput())
+		}
+
+		if err != nil {
+			return tb.displayErrorScreen(err)
+		}
+	}
+}
+
+// loadDefinitions fetches the table definitions while displaying a loading
+// screen, and sorts them by display name. The user may press Esc to cancel
+// the request, in which case context.Canceled is returned.
+func (tb *tableBrowser) loadDefinitions() error {
+	loadingScreen, err := newLoadingScreen("Loading table definitions...")
+	if err != nil {
+		return err
+	}
+
+	var definitions []model.EntityDefinition
+	err = tb.ui.RunCancellable(loadingScreen, func(ctx context.Context) error {
+		var err error
+		definitions, err = tb.metadataService.EntityDefinitionsContext(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(definitions, func(a, b model.EntityDefinition) int {
+		return cmp.Compare(strings.ToLower(a.Label()), strings.ToLower(b.Label()))
+	})
+	tb.definitions = definitions
+	return nil
+}
+
+// matchingDefinitions returns the table definitions whose display name or
+// logical name contains the current search term, ignoring case.
+func (tb *tableBrowser) matchingDefinitions() []*model.EntityDefinition {
+	searchTerm := strings.ToLower(tb.searchTerm)
+	matches := make([]*model.EntityDefinition, 0, len(tb.definitions))
+	for i := range tb.definitions {
+		d := &tb.definitions[i]
+		if strings.Contains(strings.ToLower(d.Label()), searchTerm) ||
+			strings.Contains(d.LogicalName, searchTerm) {
+			matches = append(matches, d)
+		}
+	}
+	return matches
+}
+
+// displayTableList shows the list of tables.
+// Returns the user's selection and any error encountered.
+func (tb *tableBrowser) displayTableList(definitions []*model.EntityDefinition) (view.ScreenOutput, error) {
+	columns, err := model.EntityDefinitionListColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	tableListScreen, err := newEntityListScreen(
+		"Tables",
+		listScreenOptions[*model.EntityDefinition]{
+			controls:   tableListControls,
+			entityList: model.CreateStaticEntityList(definitions, tb.pageLimit),
+			columns:    columns,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tb.ui.NavigateTo(tableListScreen)
+}
+
+// openTable loads the column definitions of the table with the given
+// metadata ID and opens an entity menu over its rows.
+// Returns an error if the table cannot be opened.
+func (tb *tableBrowser) openTable(metadataID string) error {
+	i := slices.IndexFunc(tb.definitions, func(d model.EntityDefinition) bool {
+		return d.MetadataId == metadataID
+	})
+	if i < 0 {
+		return fmt.Errorf("table %s not found", metadataID)
+	}
+	definition := tb.definitions[i]
+
+	attributes, err := tb.loadAttributes(definition)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	displayAttributes := selectDisplayAttributes(definition, attributes)
+	columns, err := model.DynamicRecordListColumns(displayAttributes)
+	if err != nil {
+		return err
+	}
+
+	recordsMenu := entityMenu[*model.DynamicRecord]{
+		ui:          tb.ui,
+		service:     tb.newRecordService(definition, displayAttributes),
+		listColumns: columns,
+		getNewEntity: func() (*model.DynamicRecord, error) {
+			return getEntityDetails(
+				model.NewDynamicRecordFor(definition),
+				"New "+definition.Label(),
+				dynamicRecordPropertyPrompts(definition.LogicalName, displayAttributes, true, tb.loadChoiceOptions),
+				tb.getScreenOutput)
+		},
+		getUpdatedEntity: func(recordToUpdate *model.DynamicRecord) (*model.DynamicRecord, error) {
+			return getEntityDetails(
+				recordToUpdate,
+				"Update "+definition.Label(),
+				dynamicRecordPropertyPrompts(definition.LogicalName, displayAttributes, false, tb.loadChoiceOptions),
+				tb.getScreenOutput)
+		},
+		entityLabel: definition.Label(),
+	}
+	return recordsMenu.run()
+}
+
+// loadAttributes fetches the column definitions of a table while displaying
+// a loading screen. The user may press Esc to cancel the request, in which
+// case context.Canceled is returned.
+func (tb *tableBrowser) loadAttributes(definition model.EntityDefinition) ([]model.AttributeDefinition, error) {
+	loadingScreen, err := newLoadingScreen(
+		fmt.Sprintf("Loading %s columns...", definition.Label()))
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []model.AttributeDefinition
+	err = tb.ui.RunCancellable(loadingScreen, func(ctx context.Context) error {
+		var err error
+		attributes, err = tb.metadataService.AttributesContext(ctx, definition.LogicalName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// newRecordService creates an entity service for the rows of a table,
+// selecting the primary id and the displayed columns, and searching the
+// displayed text columns.
+func (tb *tableBrowser) newRecordService(
+	definition model.EntityDefinition,
+	displayAttributes []model.AttributeDefinition) service.EntityService[*model.DynamicRecord] {
+
+	selects := []string{definition.PrimaryIdAttribute}
+	searchFields := []string{}
+	for _, a := range displayAttributes {
+		if a.LogicalName != definition.PrimaryIdAttribute {
+			selects = append(selects, a.LogicalName)
+		}
+		if a.IsText() {
+			searchFields = append(searchFields, a.LogicalName)
+		}
+	}
+
+	return service.NewEntityService[*model.DynamicRecord](service.EntityServiceOptions{
+		DataverseService: tb.dataverseService,
+		BaseUrl:          tb.baseURL,
+		PageLimit:        tb.pageLimit,
+		ResourcePath:     definition.EntitySetName,
+		SelectsFields:    selects,
+		SearchFields:     searchFields,
+		NewEntity: func() view.Entity {
+			return model.NewDynamicRecordFor(definition)
+		},
+		IncludeAnnotations: []string{service.AllAnnotations},
+	})
+}
+
+// selectDisplayAttributes chooses the columns displayed for a table: the
+// primary name column followed by required and then recommended text and
+// choice columns, up to maxDynamicRecordColumns. If the table has no primary name or text
+// columns, the primary id column is displayed.
+func selectDisplayAttributes(
+	definition model.EntityDefinition,
+	attributes []model.AttributeDefinition) []model.AttributeDefinition {
+
+	display := make([]model.AttributeDefinition, 0, maxDynamicRecordColumns)
+	var primaryId *model.AttributeDefinition
+	candidates := []model.AttributeDefinition{}
+
+	for i, a := range attributes {
+		switch {
+		case a.LogicalName == definition.PrimaryNameAttribute:
+			display = append(display, a)
+		case a.LogicalName == definition.PrimaryIdAttribute:
+			primaryId = &attributes[i]
+		case (a.IsText() || a.IsChoice()) && (a.IsRequired() || a.RequiredLevel.Value == model.RequiredLevelRecommended):
+			candidates = append(candidates, a)
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b model.AttributeDefinition) int {
+		if a.IsRequired() != b.IsRequired() {
+			if a.IsRequired() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Label(), b.Label())
+	})
+
+	for _, c := range candidates {
+		if len(display) == maxDynamicRecordColumns {
+			break
+		}
+		display = append(display, c)
+	}
+
+	if len(display) == 0 && primaryId != nil {
+		display = append(display, *primaryId)
+	}
+	return display
+}
+
+// notifyNoTablesFound displays a message when no tables match the current
+// search term.
+// Returns an error if the notification screen cannot be displayed.
+func (tb *tableBrowser) notifyNoTablesFound() error {
+	is, err := newInfoScreen("No tables found")
+	if err != nil {
+		return err
+	}
+
+	_, err = tb.ui.NavigateTo(is)
+	return err
+}
+
+// setSearchTerm prompts the user to enter a search term for filtering tables.
+// An empty search term clears the filter.
+// Returns an error if the input screen cannot be displayed.
+func (tb *tableBrowser) setSearchTerm() error {
+	msg := "Enter a search term (or leave blank to unset)"
+	inputScreen, err := newStringInputScreen("Set search term: Tables", msg, "SearchTerm", tb.searchTerm, false)
+	if err != nil {
+		return err
+	}
+
+	inputScreenOutputs, err := tb.ui.NavigateTo(inputScreen)
+	if err != nil {
+		return err
+	}
+
+	tb.searchTerm = inputScreenOutputs.UserInput()
+	return nil
+}
+
+// displayErrorScreen shows an error message to the user.
+// Returns the original error if displaying the error screen fails,
+// otherwise returns nil to indicate the error was displayed successfully.
+func (tb *tableBrowser) displayErrorScreen(originalError error) error {
+	es, err := newErrorScreen(errorScreenMessage(originalError))
+	if err != nil {
+		return originalError
+	}
+	_, err = tb.ui.NavigateTo(es)
+	if err != nil {
+		return originalError
+	}
+	return nil
+}
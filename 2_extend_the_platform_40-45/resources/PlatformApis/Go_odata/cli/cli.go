@@ -0,0 +1,167 @@
+// Package cli implements the command line subcommands, which run without the
+// terminal user interface so they can be scripted or scheduled.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/app"
+	authMode "github.com/turnerbenjamin/go_odata/constants/authmode"
+	"github.com/turnerbenjamin/go_odata/msal"
+	"github.com/turnerbenjamin/go_odata/secrets"
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// Names of the --auth flag values.
+const (
+	authFlagApp    = "app"
+	authFlagUser   = "user"
+	authFlagDevice = "device"
+)
+
+// clientSecretPrompt is displayed when the client secret's source is
+// "prompt".
+const clientSecretPrompt = "Client secret: "
+
+// ErrUnknownCommand is returned when Run is given an unknown subcommand.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// command is a subcommand run with the arguments following its name.
+type command struct {
+	description string
+	run         func(ctx context.Context, config app.AppConfig, args []string, stdout io.Writer) error
+}
+
+// commands lists the available subcommands by name.
+var commands = map[string]command{
+	"accounts": {
+		description: "List, get, create, update or delete accounts",
+		run:         accountsCommand.run,
+	},
+	"contacts": {
+		description: "List, get, create, update or delete contacts",
+		run:         contactsCommand.run,
+	},
+	"sync": {
+		description: "Mirror tables into a local SQLite database",
+		run:         runSync,
+	},
+}
+
+// IsCommand returns true if name is the name of a subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run runs the subcommand named by the first argument with the remaining
+// arguments. The subcommand is cancelled if the process is interrupted.
+// Returns ErrUnknownCommand, with a list of the available subcommands, if the
+// subcommand does not exist.
+func Run(config app.AppConfig, args []string) error {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		return fmt.Errorf("%w\n\n%s", ErrUnknownCommand, usage())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return commands[args[0]].run(ctx, config, args[1:], os.Stdout)
+}
+
+// usage lists the available subcommands.
+func usage() string {
+	var sb strings.Builder
+	sb.WriteString("Commands:\n")
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(&sb, "  %-10s %s\n", name, commands[name].description)
+	}
+	return sb.String()
+}
+
+// parseFlags parses args with flags, allowing flags to follow positional
+// arguments, e.g. "get <guid> --output table".
+// Returns the positional arguments, or an error wrapping ErrUsage if a flag
+// is invalid. flag.ErrHelp is returned unwrapped if help was requested.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// connect authenticates with the mode given by an --auth flag, or the
+// environment's configured mode, and returns a Dataverse service and the root
+// URL of the API. For device code authentication, the sign in instructions
+// are written to stderr. Reading secrets and waiting for sign in stop if ctx
+// is cancelled.
+// Returns an error wrapping ErrAuthentication if the connection fails.
+func connect(ctx context.Context, config app.AppConfig, auth string) (service.DataverseService, *url.URL, error) {
+	mode, err := authenticationMode(config, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseURL, err := url.Parse(config.APIBaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataverseService, err := app.NewDataverseService(ctx, config, mode, app.Prompts{
+		Secret: secrets.NewTerminalPrompt(clientSecretPrompt),
+		DeviceCode: func(_ context.Context, code msal.DeviceCode, wait func(context.Context) error) error {
+			fmt.Fprintln(os.Stderr, code.Message)
+			return wait(ctx)
+		},
+		BrowserSignIn: func(_ context.Context, wait func(context.Context) error) error {
+			fmt.Fprintln(os.Stderr, "Please authenticate in the browser...")
+			return wait(ctx)
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrAuthentication, err)
+	}
+	return dataverseService, baseURL, nil
+}
+
+// authenticationMode returns the authentication mode given by an --auth
+// flag, or if the flag is not set, the mode configured for the environment,
+// defaulting to application authentication.
+func authenticationMode(config app.AppConfig, flagValue string) (authMode.AuthenticationMode, error) {
+	switch strings.ToLower(flagValue) {
+	case "":
+		if config.AuthMode != "" {
+			return config.AuthMode, nil
+		}
+		return authMode.Application, nil
+	case authFlagApp:
+		return authMode.Application, nil
+	case authFlagUser:
+		return authMode.User, nil
+	case authFlagDevice:
+		return authMode.DeviceCode, nil
+	default:
+		return authMode.Invalid, fmt.Errorf("%w: --auth must be %s, %s or %s",
+			ErrUsage, authFlagApp, authFlagUser, authFlagDevice)
+	}
+}
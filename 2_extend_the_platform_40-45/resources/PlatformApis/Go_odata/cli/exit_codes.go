@@ -0,0 +1,55 @@
+// Package cli implements the command line subcommands, which run without the
+// terminal user interface so they can be scripted or scheduled.
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// Exit codes returned by ExitCode, so that scripts can react to the cause of
+// a failure.
+const (
+	ExitOK          = 0   // The command succeeded
+	ExitError       = 1   // The command failed for any other reason
+	ExitUsage       = 2   // The command or its flags or arguments were invalid
+	ExitAuth        = 3   // Authentication or connecting to Dataverse failed
+	ExitNotFound    = 4   // The record does not exist
+	ExitConflict    = 5   // The record changed or duplicates another record
+	ExitForbidden   = 6   // The user lacks the required privileges
+	ExitThrottled   = 7   // Service protection limits were exceeded
+	ExitInterrupted = 130 // The command was interrupted
+)
+
+// ErrUsage is wrapped by errors caused by an invalid command line.
+var ErrUsage = errors.New("invalid usage")
+
+// ErrAuthentication is wrapped by errors caused by a failure to authenticate
+// or connect to Dataverse.
+var ErrAuthentication = errors.New("unable to connect to Dataverse")
+
+// ExitCode returns the exit code describing err, or ExitOK if err is nil.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrUsage), errors.Is(err, ErrUnknownCommand):
+		return ExitUsage
+	case errors.Is(err, ErrAuthentication):
+		return ExitAuth
+	case service.IsNotFound(err):
+		return ExitNotFound
+	case service.IsConcurrencyConflict(err), service.IsDuplicate(err), service.IsAlreadyExists(err):
+		return ExitConflict
+	case service.IsPrivilegeDenied(err):
+		return ExitForbidden
+	case service.IsThrottled(err):
+		return ExitThrottled
+	default:
+		return ExitError
+	}
+}
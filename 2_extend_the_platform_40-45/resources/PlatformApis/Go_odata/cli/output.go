@@ -0,0 +1,83 @@
+// Package cli implements the command line subcommands, which run without the
+// terminal user interface so they can be scripted or scheduled.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/turnerbenjamin/go_odata/model"
+)
+
+// Names of the --output flag values.
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// recordWriter writes records to the command's output in the format chosen
+// with the --output flag.
+type recordWriter struct {
+	w       io.Writer
+	format  string
+	columns []string
+}
+
+// newRecordWriter creates a recordWriter writing the given columns of each
+// record in format, which must be "json" or "table".
+// Returns an error wrapping ErrUsage if the format is invalid.
+func newRecordWriter(w io.Writer, format string, columns []string) (*recordWriter, error) {
+	format = strings.ToLower(format)
+	if format != outputJSON && format != outputTable {
+		return nil, fmt.Errorf("%w: --output must be %s or %s",
+			ErrUsage, outputJSON, outputTable)
+	}
+	return &recordWriter{w: w, format: format, columns: columns}, nil
+}
+
+// writeList writes records as a JSON array or a table with a header row.
+func (rw *recordWriter) writeList(records []*model.DynamicRecord) error {
+	if rw.format == outputJSON {
+		values := make([]map[string]any, len(records))
+		for i, r := range records {
+			values[i] = r.Values()
+		}
+		return rw.writeJSON(values)
+	}
+	return rw.writeTable(records)
+}
+
+// writeRecord writes a single record as a JSON object or a table with a
+// header row.
+func (rw *recordWriter) writeRecord(record *model.DynamicRecord) error {
+	if rw.format == outputJSON {
+		return rw.writeJSON(record.Values())
+	}
+	return rw.writeTable([]*model.DynamicRecord{record})
+}
+
+// writeJSON writes value as indented JSON.
+func (rw *recordWriter) writeJSON(value any) error {
+	encoder := json.NewEncoder(rw.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// writeTable writes the records' formatted values in aligned columns headed
+// by their logical names.
+func (rw *recordWriter) writeTable(records []*model.DynamicRecord) error {
+	tw := tabwriter.NewWriter(rw.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(rw.columns, "\t"))
+
+	cells := make([]string, len(rw.columns))
+	for _, r := range records {
+		for i, c := range rw.columns {
+			cells[i] = strings.ReplaceAll(r.FormattedString(c), "\t", " ")
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,323 @@
+// Package cli implements the command line subcommands, which run without the
+// terminal user interface so they can be scripted or scheduled.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/app"
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
+	"github.com/turnerbenjamin/go_odata/model"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// Values used by the record subcommands.
+const (
+	// maxListPageSize is the largest page requested when listing records
+	maxListPageSize = 5000
+
+	// dataFilePrefix marks a --data value as a file path, or "-" for stdin
+	dataFilePrefix = "@"
+	dataStdin      = "-"
+)
+
+// Verbs of the record subcommands.
+const (
+	verbList   = "list"
+	verbGet    = "get"
+	verbCreate = "create"
+	verbUpdate = "update"
+	verbDelete = "delete"
+)
+
+// recordsCommand describes a table managed by a record subcommand such as
+// "accounts".
+type recordsCommand struct {
+	// definition identifies the table and its primary columns
+	definition model.EntityDefinition
+
+	// selects lists the columns retrieved when --select is not given
+	selects []string
+}
+
+// accountsCommand manages accounts, selecting the columns shown in the
+// interactive application by default.
+var accountsCommand = recordsCommand{
+	definition: model.EntityDefinition{
+		LogicalName:          logicalNames.TableAccount,
+		EntitySetName:        logicalNames.TableAccountResource,
+		PrimaryIdAttribute:   logicalNames.ColumnAccountId,
+		PrimaryNameAttribute: logicalNames.ColumnAccountName,
+	},
+	selects: []string{
+		logicalNames.ColumnAccountName,
+		logicalNames.ColumnAccountCity,
+		logicalNames.ColumnAccountIndustry,
+		logicalNames.ColumnAccountStatus,
+	},
+}
+
+// contactsCommand manages contacts, selecting the columns shown in the
+// interactive application by default.
+var contactsCommand = recordsCommand{
+	definition: model.EntityDefinition{
+		LogicalName:          logicalNames.TableContactSingular,
+		EntitySetName:        logicalNames.TableContactResource,
+		PrimaryIdAttribute:   logicalNames.ColumnContactId,
+		PrimaryNameAttribute: logicalNames.ColumnContactFullName,
+	},
+	selects: []string{
+		logicalNames.ColumnContactFirstName,
+		logicalNames.ColumnContactLastName,
+		logicalNames.ColumnContactEmail,
+		logicalNames.ColumnContactParentCustomer,
+	},
+}
+
+// recordsOptions holds the flags and arguments of a record subcommand.
+type recordsOptions struct {
+	verb    string
+	guid    string
+	filter  string
+	selects []string
+	top     int
+	data    string
+	output  string
+	auth    string
+}
+
+// run parses the verb, flags and arguments of a record subcommand, e.g.
+// "list --top 10" or "get <guid>", and runs it.
+func (c recordsCommand) run(ctx context.Context, config app.AppConfig, args []string, stdout io.Writer) error {
+	options, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+
+	writer, err := newRecordWriter(stdout, options.output, options.selects)
+	if err != nil {
+		return err
+	}
+
+	dataverseService, baseURL, err := connect(ctx, config, options.auth)
+	if err != nil {
+		return err
+	}
+
+	pageLimit := maxListPageSize
+	if options.top > 0 && options.top < pageLimit {
+		pageLimit = options.top
+	}
+
+	entityService := service.NewEntityService[*model.DynamicRecord](service.EntityServiceOptions{
+		DataverseService: dataverseService,
+		BaseUrl:          baseURL,
+		PageLimit:        pageLimit,
+		ResourcePath:     c.definition.EntitySetName,
+		SelectsFields:    options.selects,
+		NewEntity: func() view.Entity {
+			return model.NewDynamicRecordFor(c.definition)
+		},
+		IncludeAnnotations: []string{service.AllAnnotations},
+	})
+
+	switch options.verb {
+	case verbList:
+		return c.list(ctx, entityService, options, writer)
+	case verbGet:
+		record, err := entityService.GetContext(ctx, options.guid)
+		if err != nil {
+			return err
+		}
+		return writer.writeRecord(record)
+	case verbCreate:
+		record, err := c.recordFromData(options.data)
+		if err != nil {
+			return err
+		}
+		created, err := entityService.CreateContext(ctx, record)
+		if err != nil {
+			return err
+		}
+		return writer.writeRecord(created)
+	case verbUpdate:
+		return c.update(ctx, entityService, options)
+	case verbDelete:
+		return entityService.DeleteContext(ctx, options.guid)
+	}
+	return nil
+}
+
+// parse reads the verb, flags and arguments of a record subcommand.
+// Returns an error wrapping ErrUsage if they are invalid.
+func (c recordsCommand) parse(args []string) (*recordsOptions, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: expected %s, %s, %s, %s or %s",
+			ErrUsage, verbList, verbGet, verbCreate, verbUpdate, verbDelete)
+	}
+
+	options := &recordsOptions{verb: args[0]}
+	var selects string
+	flags := flag.NewFlagSet(c.definition.EntitySetName+" "+options.verb, flag.ContinueOnError)
+	flags.StringVar(&options.output, "output", outputJSON, "output format: json or table")
+	flags.StringVar(&options.auth, "auth", "", "authentication mode: app, user or device (default: the profile's mode, or app)")
+
+	var wantsGuid bool
+	switch options.verb {
+	case verbList:
+		flags.StringVar(&options.filter, "filter", "", "OData $filter expression")
+		flags.StringVar(&selects, "select", "", "comma-separated columns to retrieve")
+		flags.IntVar(&options.top, "top", 0, "maximum number of records to list")
+	case verbGet:
+		flags.StringVar(&selects, "select", "", "comma-separated columns to retrieve")
+		wantsGuid = true
+	case verbCreate:
+		flags.StringVar(&options.data, "data", "", "JSON record, or @file.json, or @- for stdin")
+		flags.StringVar(&selects, "select", "", "comma-separated columns to output")
+	case verbUpdate:
+		flags.StringVar(&options.data, "data", "", "JSON changes, or @file.json, or @- for stdin")
+		wantsGuid = true
+	case verbDelete:
+		wantsGuid = true
+	default:
+		return nil, fmt.Errorf("%w: unknown verb %q", ErrUsage, options.verb)
+	}
+
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case wantsGuid && len(positional) != 1:
+		return nil, fmt.Errorf("%w: %s expects a single record id", ErrUsage, options.verb)
+	case !wantsGuid && len(positional) != 0:
+		return nil, fmt.Errorf("%w: unexpected arguments %s", ErrUsage, strings.Join(positional, " "))
+	case options.top < 0:
+		return nil, fmt.Errorf("%w: --top must not be negative", ErrUsage)
+	case (options.verb == verbCreate || options.verb == verbUpdate) && options.data == "":
+		return nil, fmt.Errorf("%w: %s requires --data", ErrUsage, options.verb)
+	}
+	if wantsGuid {
+		options.guid = positional[0]
+	}
+
+	options.selects = c.selectedColumns(selects)
+	return options, nil
+}
+
+// selectedColumns returns the primary id column followed by the columns in
+// the comma-separated value of --select, or the default columns if it is
+// empty.
+func (c recordsCommand) selectedColumns(value string) []string {
+	columns := c.selects
+	if value != "" {
+		columns = strings.Split(value, ",")
+	}
+
+	selects := []string{c.definition.PrimaryIdAttribute}
+	for _, column := range columns {
+		column = strings.TrimSpace(column)
+		if column != "" && column != c.definition.PrimaryIdAttribute {
+			selects = append(selects, column)
+		}
+	}
+	return selects
+}
+
+// list writes the records matching the --filter flag, following every page
+// until --top records have been retrieved.
+func (c recordsCommand) list(
+	ctx context.Context,
+	entityService service.EntityService[*model.DynamicRecord],
+	options *recordsOptions,
+	writer *recordWriter) error {
+
+	var filter requestBuilder.Filter
+	if options.filter != "" {
+		filter = requestBuilder.Raw(options.filter)
+	}
+
+	page, err := entityService.ListContext(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	records := append([]*model.DynamicRecord{}, page.Data()...)
+	for page.HasNext() && (options.top == 0 || len(records) < options.top) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err = page.Next()
+		if err != nil {
+			return err
+		}
+		records = append(records, page.Data()...)
+	}
+
+	if options.top > 0 && len(records) > options.top {
+		records = records[:options.top]
+	}
+	return writer.writeList(records)
+}
+
+// update applies the changes in --data to an existing record. The record is
+// retrieved first, so that a missing record is reported rather than created
+// and the update fails if the record changes in the meantime.
+func (c recordsCommand) update(
+	ctx context.Context,
+	entityService service.EntityService[*model.DynamicRecord],
+	options *recordsOptions) error {
+
+	changes, err := c.recordFromData(options.data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := entityService.GetContext(ctx, options.guid); err != nil {
+		return err
+	}
+	return entityService.UpdateContext(ctx, options.guid, changes)
+}
+
+// recordFromData creates a record holding the columns of the JSON object
+// given by a --data flag: either the JSON itself, a path prefixed with "@",
+// or "@-" to read from stdin. Properties are sent as given, so lookups may be
+// set with "nav@odata.bind" properties.
+func (c recordsCommand) recordFromData(data string) (*model.DynamicRecord, error) {
+	content := []byte(data)
+	if path, ok := strings.CutPrefix(data, dataFilePrefix); ok {
+		var err error
+		if path == dataStdin {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var properties map[string]any
+	if err := decoder.Decode(&properties); err != nil {
+		return nil, fmt.Errorf("%w: --data must be a JSON object: %w", ErrUsage, err)
+	}
+
+	record := model.NewDynamicRecordFor(c.definition)
+	for property, value := range properties {
+		record.Set(property, value)
+	}
+	return record, nil
+}
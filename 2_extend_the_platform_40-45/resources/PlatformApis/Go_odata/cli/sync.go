@@ -0,0 +1,137 @@
+// Package cli implements the command line subcommands, which run without the
+// terminal user interface so they can be scripted or scheduled.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/app"
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
+	"github.com/turnerbenjamin/go_odata/mirror"
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// defaultMirrorPath is the database used when --db is not given.
+const defaultMirrorPath = "dataverse_mirror.db"
+
+// defaultMirrorTables lists the tables mirrored when no --table flags are
+// given.
+var defaultMirrorTables = []mirror.TableOptions{
+	{
+		LogicalName: logicalNames.TableAccount,
+		Columns: []string{
+			logicalNames.ColumnAccountName,
+			logicalNames.ColumnAccountCity,
+			logicalNames.ColumnAccountIndustry,
+			logicalNames.ColumnAccountStatus,
+			logicalNames.ColumnAccountPrimaryContact,
+		},
+	},
+	{
+		LogicalName: logicalNames.TableContactSingular,
+		Columns: []string{
+			logicalNames.ColumnContactFirstName,
+			logicalNames.ColumnContactLastName,
+			logicalNames.ColumnContactEmail,
+			logicalNames.ColumnContactParentCustomerLookup,
+		},
+	},
+}
+
+// tableFlags implements flag.Value for repeated --table flags of the form
+// "logicalname:column,column".
+type tableFlags []mirror.TableOptions
+
+// String returns the tables in the form they are given on the command line.
+func (f *tableFlags) String() string {
+	specs := make([]string, len(*f))
+	for i, t := range *f {
+		specs[i] = t.LogicalName + ":" + strings.Join(t.Columns, ",")
+	}
+	return strings.Join(specs, " ")
+}
+
+// Set adds a table given as "logicalname:column,column".
+func (f *tableFlags) Set(value string) error {
+	logicalName, columns, _ := strings.Cut(value, ":")
+	logicalName = strings.TrimSpace(logicalName)
+	if logicalName == "" {
+		return fmt.Errorf("missing table name in %q", value)
+	}
+
+	table := mirror.TableOptions{LogicalName: logicalName}
+	for _, c := range strings.Split(columns, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			table.Columns = append(table.Columns, c)
+		}
+	}
+	*f = append(*f, table)
+	return nil
+}
+
+// runSync mirrors tables into a local SQLite database, applying the changes
+// since the previous sync of each table.
+func runSync(ctx context.Context, config app.AppConfig, args []string, stdout io.Writer) error {
+	var tables tableFlags
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dbPath := flags.String("db", defaultMirrorPath, "path of the SQLite database")
+	auth := flags.String("auth", "", "authentication mode: app, user or device (default: the profile's mode, or app)")
+	full := flags.Bool("full", false, "copy every row, ignoring the previous sync")
+	flags.Var(&tables, "table", "table to mirror as logicalname:column,column (repeatable)")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("%w: unexpected arguments %s", ErrUsage, strings.Join(positional, " "))
+	}
+	if len(tables) == 0 {
+		tables = defaultMirrorTables
+	}
+
+	dataverseService, baseURL, err := connect(ctx, config, *auth)
+	if err != nil {
+		return err
+	}
+
+	db, err := mirror.Open(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := mirror.NewMirror(mirror.Options{
+		DB:               db,
+		DataverseService: dataverseService,
+		MetadataService: service.NewMetadataService(service.MetadataServiceOptions{
+			DataverseService: dataverseService,
+			BaseUrl:          baseURL,
+		}),
+		BaseUrl: baseURL,
+		Tables:  tables,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *full {
+		if err := m.ResetContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	results, err := m.SyncContext(ctx)
+	for _, r := range results {
+		kind := "changes"
+		if r.IsFull {
+			kind = "full copy"
+		}
+		fmt.Fprintf(stdout, "%s: %d upserted, %d deleted (%s)\n",
+			r.Table, r.Upserted, r.Deleted, kind)
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// Package config loads the settings used to connect to Dataverse
+// environments from a profiles file, environment variables and command line
+// flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Locations of the profiles file.
+const (
+	configDirName    = "go_odata"
+	profilesFileName = "profiles.yaml"
+)
+
+// Environment variables holding settings. They override the profiles file
+// and are overridden by command line flags.
+const (
+	EnvConfigPath         = "GO_ODATA_CONFIG"
+	EnvProfile            = "GO_ODATA_PROFILE"
+	EnvEnvironmentURL     = "ENVIRONMENT_URL"
+	EnvTenantID           = "TENANT_ID"
+	EnvClientID           = "CLIENT_ID"
+	EnvAuthority          = "AUTHORITY"
+	EnvAuthMode           = "AUTH_MODE"
+	EnvPageSize           = "PAGE_SIZE"
+	EnvAPIVersion         = "API_VERSION"
+	EnvAPIPath            = "API_PATH"
+	EnvClientSecret       = "CLIENT_SECRET"
+	EnvClientSecretSource = "CLIENT_SECRET_SOURCE"
+
+	EnvClientCertificatePath           = "CLIENT_CERTIFICATE_PATH"
+	EnvClientCertificatePasswordSource = "CLIENT_CERTIFICATE_PASSWORD_SOURCE"
+	EnvSendX5C                         = "CLIENT_SEND_X5C"
+	EnvClientAssertionFile             = "CLIENT_ASSERTION_FILE"
+
+	// EnvFederatedTokenFile is set by workload identity platforms, e.g.
+	// Azure Kubernetes Service, to the path of a federated token. It is used
+	// when CLIENT_ASSERTION_FILE is not set
+	EnvFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+)
+
+// ErrUnknownProfile is returned when the selected profile is not in the
+// profiles file.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// File is the content of a profiles file, e.g.
+//
+//	default: dev
+//	profiles:
+//	  dev:
+//	    environment_url: https://yourorg-dev.crm.dynamics.com/
+//	    tenant_id: your-tenant-id
+//	    client_id: your-application-id
+//	    auth_mode: user
+//	    page_size: 10
+//	    api_version: "9.2"
+type File struct {
+	// Default is the name of the profile used when none is selected. If
+	// empty and there are several profiles, the user chooses one
+	Default string `yaml:"default,omitempty"`
+
+	// Profiles holds the profiles keyed by name
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// DefaultPath returns the path of the profiles file: the value of
+// GO_ODATA_CONFIG if set, otherwise go_odata/profiles.yaml in the user's
+// configuration directory.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName, profilesFileName), nil
+}
+
+// Load reads the profiles file at path. A missing file is treated as an
+// empty file, so that settings can be given by environment variables and
+// flags alone.
+func Load(path string) (*File, error) {
+	file := &File{Profiles: map[string]Profile{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]Profile{}
+	}
+	for name, p := range file.Profiles {
+		file.Profiles[name] = p.withName(name)
+	}
+	return file, nil
+}
+
+// FromEnv returns a profile holding the settings set by environment
+// variables, read with getenv, e.g. os.Getenv.
+// Returns an error if PAGE_SIZE is not a number or CLIENT_SEND_X5C is not a
+// boolean.
+func FromEnv(getenv func(string) string) (Profile, error) {
+	p := Profile{
+		EnvironmentURL:                  getenv(EnvEnvironmentURL),
+		TenantID:                        getenv(EnvTenantID),
+		ClientID:                        getenv(EnvClientID),
+		Authority:                       getenv(EnvAuthority),
+		AuthMode:                        getenv(EnvAuthMode),
+		APIVersion:                      getenv(EnvAPIVersion),
+		APIPath:                         getenv(EnvAPIPath),
+		ClientSecretSource:              getenv(EnvClientSecretSource),
+		ClientCertificatePath:           getenv(EnvClientCertificatePath),
+		ClientCertificatePasswordSource: getenv(EnvClientCertificatePasswordSource),
+		ClientAssertionFile:             getenv(EnvClientAssertionFile),
+	}
+
+	// A client secret in the environment is a configured credential, so the
+	// federated token is not used in its place
+	if getenv(EnvClientSecret) == "" {
+		p.FederatedTokenFile = getenv(EnvFederatedTokenFile)
+	}
+
+	if sendX5C := getenv(EnvSendX5C); sendX5C != "" {
+		b, err := strconv.ParseBool(sendX5C)
+		if err != nil {
+			return Profile{}, fmt.Errorf("invalid %s: %w", EnvSendX5C, err)
+		}
+		p.SendX5C = &b
+	}
+
+	if pageSize := getenv(EnvPageSize); pageSize != "" {
+		n, err := strconv.Atoi(pageSize)
+		if err != nil {
+			return Profile{}, fmt.Errorf("invalid %s: %w", EnvPageSize, err)
+		}
+		p.PageSize = n
+	}
+	return p, nil
+}
+
+// Resolve returns the profiles the user may connect to, with each setting
+// taken from the last of overrides that sets it, then the profile in the
+// file, then its default value. Pass the environment variable settings
+// followed by the flag settings, so that flags take precedence over
+// environment variables, which take precedence over the file. The federated
+// token file from the environment is only used by profiles that configure
+// no other credential.
+//
+// If name is set, only that profile is returned; otherwise the file's
+// default profile, or every profile sorted by name so that the user can
+// choose one. If the file has no profiles, a single profile is built from
+// the overrides alone. Overrides only apply to a selected profile: when
+// every profile is returned they are ignored, as they would otherwise make
+// each profile connect to the same environment.
+//
+// Returns ErrUnknownProfile if the named or default profile does not exist,
+// or an error describing the first invalid profile.
+func (f *File) Resolve(name string, overrides ...Profile) ([]Profile, error) {
+	if name == "" {
+		name = f.Default
+	}
+
+	var profiles []Profile
+	switch {
+	case name != "":
+		p, ok := f.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+		}
+		profiles = []Profile{p}
+	case len(f.Profiles) > 1:
+		for _, name := range slices.Sorted(maps.Keys(f.Profiles)) {
+			profiles = append(profiles, f.Profiles[name])
+		}
+		overrides = nil
+	case len(f.Profiles) == 1:
+		for _, p := range f.Profiles {
+			profiles = []Profile{p}
+		}
+	default:
+		profiles = []Profile{{}}
+	}
+
+	for i, p := range profiles {
+		p = p.Merge(overrides...).withDefaults().withFederatedToken()
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		profiles[i] = p
+	}
+	return profiles, nil
+}
@@ -0,0 +1,270 @@
+// Package config loads the settings used to connect to Dataverse
+// environments from a profiles file, environment variables and command line
+// flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	authMode "github.com/turnerbenjamin/go_odata/constants/authmode"
+)
+
+// Default values of optional settings.
+const (
+	DefaultAuthority  = "https://login.microsoftonline.com/"
+	DefaultAPIVersion = "9.2"
+	DefaultPageSize   = 5
+)
+
+// apiPathFormat formats the path of the Web API for an API version.
+const apiPathFormat = "api/data/v%s/"
+
+// Names of the auth mode setting values.
+const (
+	authModeApp    = "app"
+	authModeUser   = "user"
+	authModeDevice = "device"
+)
+
+// ErrInvalidAuthMode is returned when an auth mode is not "app", "user" or
+// "device".
+var ErrInvalidAuthMode = errors.New(`auth mode must be "app", "user" or "device"`)
+
+// ErrIncompleteProfile is returned when a profile is missing a required
+// setting.
+var ErrIncompleteProfile = errors.New("profile is missing required settings")
+
+// ErrConflictingCredentials is returned when a profile sets both a client
+// certificate and a client assertion file.
+var ErrConflictingCredentials = errors.New("client_certificate_path and client_assertion_file cannot both be set")
+
+// Profile holds the settings used to connect to a Dataverse environment.
+// Empty fields are unset, so that profiles can be layered with Merge.
+type Profile struct {
+	// Name identifies the profile in the profiles file
+	Name string `yaml:"-"`
+
+	// EnvironmentURL is the URL of the environment, e.g.
+	// "https://yourorg.crm.dynamics.com/"
+	EnvironmentURL string `yaml:"environment_url"`
+
+	// TenantID is the EntraID tenant ID
+	TenantID string `yaml:"tenant_id"`
+
+	// ClientID is the EntraID application (client) ID
+	ClientID string `yaml:"client_id"`
+
+	// Authority is the EntraID authority URL, without the tenant ID
+	Authority string `yaml:"authority,omitempty"`
+
+	// AuthMode is "app", "user" or "device". If empty, the user is asked to choose
+	AuthMode string `yaml:"auth_mode,omitempty"`
+
+	// PageSize is the number of records displayed per page
+	PageSize int `yaml:"page_size,omitempty"`
+
+	// APIVersion is the version of the Web API, e.g. "9.2"
+	APIVersion string `yaml:"api_version,omitempty"`
+
+	// APIPath is the path of the Web API, e.g. "api/data/v9.2/". If set, it
+	// is used in place of APIVersion
+	APIPath string `yaml:"api_path,omitempty"`
+
+	// ClientSecretSource identifies where the client secret is read from,
+	// e.g. "keyring:go_odata/dev" or "file:/run/secrets/client_secret".
+	// See secrets.NewProvider for the supported sources
+	ClientSecretSource string `yaml:"client_secret_source,omitempty"`
+
+	// ClientCertificatePath is the path of a PEM or PFX (.pfx, .p12) file
+	// holding the certificate and private key used in place of the client
+	// secret
+	ClientCertificatePath string `yaml:"client_certificate_path,omitempty"`
+
+	// ClientCertificatePasswordSource identifies where the password of an
+	// encrypted certificate file is read from, in the format of
+	// ClientSecretSource
+	ClientCertificatePasswordSource string `yaml:"client_certificate_password_source,omitempty"`
+
+	// SendX5C sends the certificate chain with token requests, as required
+	// for subject name/issuer authentication. It is nil if unset, so that an
+	// override can turn it off
+	SendX5C *bool `yaml:"send_x5c,omitempty"`
+
+	// ClientAssertionFile is the path of a file holding a signed client
+	// assertion, e.g. a federated workload identity token. The file is read
+	// each time a token is requested, so that it may be rotated
+	ClientAssertionFile string `yaml:"client_assertion_file,omitempty"`
+
+	// FederatedTokenFile is the path of the workload identity token set by
+	// the platform in AZURE_FEDERATED_TOKEN_FILE. It is only used as the
+	// client assertion file if no other credential is configured
+	FederatedTokenFile string `yaml:"-"`
+}
+
+// Merge returns a copy of p with each setting replaced by the value in the
+// last override that sets it. The name of p is kept.
+func (p Profile) Merge(overrides ...Profile) Profile {
+	merged := p
+	for _, o := range overrides {
+		mergeString(&merged.EnvironmentURL, o.EnvironmentURL)
+		mergeString(&merged.TenantID, o.TenantID)
+		mergeString(&merged.ClientID, o.ClientID)
+		mergeString(&merged.Authority, o.Authority)
+		mergeString(&merged.AuthMode, o.AuthMode)
+		mergeString(&merged.APIVersion, o.APIVersion)
+		mergeString(&merged.APIPath, o.APIPath)
+		mergeString(&merged.ClientSecretSource, o.ClientSecretSource)
+		mergeString(&merged.ClientCertificatePath, o.ClientCertificatePath)
+		mergeString(&merged.ClientCertificatePasswordSource, o.ClientCertificatePasswordSource)
+		mergeString(&merged.ClientAssertionFile, o.ClientAssertionFile)
+		mergeString(&merged.FederatedTokenFile, o.FederatedTokenFile)
+		if o.SendX5C != nil {
+			merged.SendX5C = o.SendX5C
+		}
+		if o.PageSize > 0 {
+			merged.PageSize = o.PageSize
+		}
+	}
+	return merged
+}
+
+// IsEmpty returns true if p sets no settings.
+func (p Profile) IsEmpty() bool {
+	return p == Profile{Name: p.Name}
+}
+
+// mergeString sets *dst to value if value is not empty.
+func mergeString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// withDefaults returns a copy of p with the default values of any unset
+// optional settings.
+func (p Profile) withDefaults() Profile {
+	return Profile{
+		Authority:  DefaultAuthority,
+		APIVersion: DefaultAPIVersion,
+		PageSize:   DefaultPageSize,
+	}.Merge(p).withName(p.Name)
+}
+
+// withFederatedToken returns a copy of p that uses the federated token file as
+// its client assertion file if p configures no other credential.
+func (p Profile) withFederatedToken() Profile {
+	if p.ClientSecretSource == "" && p.ClientCertificatePath == "" && p.ClientAssertionFile == "" {
+		p.ClientAssertionFile = p.FederatedTokenFile
+	}
+	return p
+}
+
+// SendsX5C returns true if SendX5C is set to true.
+func (p Profile) SendsX5C() bool {
+	return p.SendX5C != nil && *p.SendX5C
+}
+
+// withName returns a copy of p with the given name.
+func (p Profile) withName(name string) Profile {
+	p.Name = name
+	return p
+}
+
+// Validate checks that the profile's required settings are set and that its
+// URLs and auth mode are valid.
+// Returns an error wrapping ErrIncompleteProfile listing any missing
+// settings, or describing the first invalid setting.
+func (p Profile) Validate() error {
+	where := ""
+	if label := p.Label(); label != "" {
+		where = " in " + label
+	}
+
+	missing := []string{}
+	if p.EnvironmentURL == "" {
+		missing = append(missing, "environment_url")
+	}
+	if p.TenantID == "" {
+		missing = append(missing, "tenant_id")
+	}
+	if p.ClientID == "" {
+		missing = append(missing, "client_id")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w%s: %s", ErrIncompleteProfile, where, strings.Join(missing, ", "))
+	}
+
+	if _, err := url.Parse(p.EnvironmentURL); err != nil {
+		return fmt.Errorf("invalid environment_url%s: %w", where, err)
+	}
+	if _, err := url.Parse(p.Authority); err != nil {
+		return fmt.Errorf("invalid authority%s: %w", where, err)
+	}
+	if p.AuthMode != "" {
+		if _, err := ParseAuthMode(p.AuthMode); err != nil {
+			return fmt.Errorf("invalid auth_mode%s: %w", where, err)
+		}
+	}
+	if p.ClientCertificatePath != "" && p.ClientAssertionFile != "" {
+		return fmt.Errorf("%w%s", ErrConflictingCredentials, where)
+	}
+	return nil
+}
+
+// Label returns the profile's name followed by its environment URL, for
+// display when choosing an environment.
+func (p Profile) Label() string {
+	switch {
+	case p.Name == "":
+		return p.EnvironmentURL
+	case p.EnvironmentURL == "":
+		return p.Name
+	default:
+		return fmt.Sprintf("%s (%s)", p.Name, p.EnvironmentURL)
+	}
+}
+
+// APIBaseURL returns the root URL of the Web API, combining the environment
+// URL with the API path or version.
+func (p Profile) APIBaseURL() (string, error) {
+	apiPath := p.APIPath
+	if apiPath == "" {
+		apiPath = fmt.Sprintf(apiPathFormat, p.APIVersion)
+	}
+	return url.JoinPath(p.EnvironmentURL, apiPath)
+}
+
+// AuthorityURL returns the tenant-specific authority URL, combining the
+// authority with the tenant ID.
+func (p Profile) AuthorityURL() (string, error) {
+	return url.JoinPath(p.Authority, p.TenantID)
+}
+
+// AuthenticationMode returns the profile's auth mode, or authmode.Invalid if
+// it is not set.
+func (p Profile) AuthenticationMode() authMode.AuthenticationMode {
+	mode, err := ParseAuthMode(p.AuthMode)
+	if err != nil {
+		return authMode.Invalid
+	}
+	return mode
+}
+
+// ParseAuthMode converts "app", "user" or "device", ignoring case, to an
+// authentication mode.
+// Returns ErrInvalidAuthMode for any other value.
+func ParseAuthMode(value string) (authMode.AuthenticationMode, error) {
+	switch strings.ToLower(value) {
+	case authModeApp:
+		return authMode.Application, nil
+	case authModeUser:
+		return authMode.User, nil
+	case authModeDevice:
+		return authMode.DeviceCode, nil
+	default:
+		return authMode.Invalid, ErrInvalidAuthMode
+	}
+}
@@ -15,6 +15,7 @@ const (
 	ClearScreen     = "\033[2J" // Clear entire screen
 	ClearScrollback = "\033[3J" // Clear scrollback buffer
 	ClearToEnd      = "\033[J"  // Clear from cursor position to end of screen
+	ClearToLineEnd  = "\033[K"  // Clear from cursor position to end of line
 
 	// Combined operations
 	ClearAll  = "\033[H\033[2J\033[3J" // Clear screen and scrollback buffer
@@ -16,6 +16,11 @@ const (
 	// User represents interactive authentication with user delegation
 	User AuthenticationMode = "User"
 
+	// DeviceCode represents user delegated authentication in which the user
+	// signs in on another device with a code, for sessions without a local
+	// browser such as SSH sessions
+	DeviceCode AuthenticationMode = "Device Code"
+
 	// Invalid represents an invalid authentication mode selection
 	Invalid AuthenticationMode = "Invalid"
 )
@@ -0,0 +1,17 @@
+// Package conflictoption provides constants for the choices offered when an
+// update or delete fails because the record was changed by another user.
+package conflictoption
+
+// ConflictOption represents a selectable option for resolving a concurrency
+// conflict. It's implemented as a string type for type safety when working
+// with user choices.
+type ConflictOption string
+
+// Constants representing conflict resolution choices.
+const (
+	Reload       ConflictOption = "Reload and edit again"    // Discard changes and reload
+	Overwrite    ConflictOption = "Overwrite their changes"  // Save changes regardless
+	ReloadDelete ConflictOption = "Reload and confirm again" // Review the latest values before deleting
+	DeleteAnyway ConflictOption = "Delete anyway"            // Delete regardless of their changes
+	Cancel       ConflictOption = "Cancel"                   // Discard changes
+)
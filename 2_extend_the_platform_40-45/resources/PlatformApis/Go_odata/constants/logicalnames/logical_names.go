@@ -5,15 +5,29 @@ package logicalnames
 // Constants representing entity and field logical names in Microsoft Dataverse.
 // These are used when constructing OData queries and processing API responses.
 const (
-	TableAccount           = "account"
-	TableAccountResource   = "accounts"
-	TableContactSingular   = "contact"
-	TableContactResource   = "contacts"
-	ColumnAccountId        = "accountid"
-	ColumnAccountName      = "name"
-	ColumnAccountCity      = "address1_city"
-	ColumnContactId        = "contactid"
-	ColumnContactFirstName = "firstname"
-	ColumnContactLastName  = "lastname"
-	ColumnContactEmail     = "emailaddress1"
+	TableAccount                      = "account"
+	TableAccountResource              = "accounts"
+	TableContactSingular              = "contact"
+	TableContactResource              = "contacts"
+	ColumnAccountId                   = "accountid"
+	ColumnAccountName                 = "name"
+	ColumnAccountCity                 = "address1_city"
+	ColumnAccountPrimaryContact       = "primarycontactid"
+	ColumnAccountIndustry             = "industrycode"
+	ColumnAccountStatus               = "statecode"
+	ColumnContactId                   = "contactid"
+	ColumnContactFirstName            = "firstname"
+	ColumnContactLastName             = "lastname"
+	ColumnContactFullName             = "fullname"
+	ColumnContactEmail                = "emailaddress1"
+	ColumnContactParentCustomer       = "_parentcustomerid_value"
+	ColumnContactParentCustomerLookup = "parentcustomerid"
+	NavigationContactParentAccount    = "parentcustomerid_account"
+	RelationshipAccountContacts       = "contact_customer_accounts"
+	TableSystemUserResource           = "systemusers"
+	ColumnSystemUserId                = "systemuserid"
+	ColumnSystemUserFullName          = "fullname"
+	ColumnSystemUserDomainName        = "domainname"
+	ColumnSystemUserObjectId          = "azureactivedirectoryobjectid"
+	ColumnSystemUserIsDisabled        = "isdisabled"
 )
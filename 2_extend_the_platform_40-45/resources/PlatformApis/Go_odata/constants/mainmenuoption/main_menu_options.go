@@ -9,8 +9,12 @@ type MainMenuOption string
 
 // Menu option constants define the available choices in the main menu.
 const (
-	Accounts MainMenuOption = "Accounts" // Account entity list
-	Contacts MainMenuOption = "Contacts" // Contact entity list
-	Exit     MainMenuOption = "Exit"     // Quit application
-	Invalid  MainMenuOption = "Invalid"  // Invalid selection
+	Accounts          MainMenuOption = "Accounts"               // Account entity list
+	Contacts          MainMenuOption = "Contacts"               // Contact entity list
+	Tables            MainMenuOption = "Browse tables"          // Any table, from metadata
+	Impersonate       MainMenuOption = "Impersonate user"       // Act on behalf of a user
+	StopImpersonating MainMenuOption = "Stop impersonating"     // Act as the signed in identity
+	SignOut           MainMenuOption = "Sign out / clear cache" // Forget cached tokens and quit
+	Exit              MainMenuOption = "Exit"                   // Quit application
+	Invalid           MainMenuOption = "Invalid"                // Invalid selection
 )
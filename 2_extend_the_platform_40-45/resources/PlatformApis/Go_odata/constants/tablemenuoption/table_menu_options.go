@@ -14,5 +14,7 @@ const (
 	Create TableMenuOption = "Create" // Create new entity
 	Update TableMenuOption = "Update" // Update selected entity
 	Delete TableMenuOption = "Delete" // Delete selected entity
+	Open   TableMenuOption = "Open"   // Open selected table
+	Pick   TableMenuOption = "Pick"   // Choose selected entity
 	Back   TableMenuOption = "Back"   // Return to previous menu
 )
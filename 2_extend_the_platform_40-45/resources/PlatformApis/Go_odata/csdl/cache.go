@@ -0,0 +1,207 @@
+// Package csdl parses the OData Common Schema Definition Language (CSDL)
+// document returned by the Web API's $metadata endpoint, and provides a
+// queryable model of the entity types, properties, navigation properties,
+// actions and functions it describes.
+package csdl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+	"github.com/turnerbenjamin/go_odata/service"
+)
+
+// Constants used when fetching and caching the $metadata document.
+const (
+	metadataPath           = "$metadata"
+	headerAccept           = "Accept"
+	headerETag             = "ETag"
+	headerLastModified     = "Last-Modified"
+	headerIfNoneMatch      = "If-None-Match"
+	headerIfModifiedSince  = "If-Modified-Since"
+	contentTypeXML         = "application/xml"
+	cacheDirName           = "go_odata/csdl"
+	cacheDocumentExtension = ".xml"
+	cacheInfoExtension     = ".json"
+	defaultMaxAge          = 24 * time.Hour
+	cacheFilePermissions   = 0o600
+	cacheDirPermissions    = 0o700
+	cacheKeyLength         = 16
+)
+
+// LoaderOptions configures how the $metadata document is fetched and cached.
+type LoaderOptions struct {
+	// DataverseService sends the $metadata request with authentication
+	DataverseService service.DataverseService
+
+	// BaseUrl is the root URL of the API. It also identifies the environment
+	// in the cache.
+	BaseUrl *url.URL
+
+	// CacheDir is the directory holding cached documents. If empty, a
+	// directory within os.UserCacheDir() is used.
+	CacheDir string
+
+	// MaxAge is how long a cached document is used without checking for
+	// changes. If zero, a default of 24 hours is used. Once a document is
+	// older than MaxAge it is revalidated with a conditional request.
+	MaxAge time.Duration
+}
+
+// cacheInfo records the validators of a cached document.
+type cacheInfo struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// Load returns the parsed $metadata document for the environment.
+//
+// A cached copy is used if it is younger than MaxAge. Otherwise the document
+// is requested, conditionally if the cached copy has an ETag or Last-Modified
+// validator, and the cache is updated. If the request fails, a stale cached
+// copy is used when available.
+func Load(ctx context.Context, options LoaderOptions) (*Metadata, error) {
+	documentPath, infoPath, err := cachePaths(options)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, info := readCache(documentPath, infoPath)
+	if cached != nil && info.isFresh(options) {
+		return Parse(bytes.NewReader(cached))
+	}
+
+	document, err := fetch(ctx, options, cached, &info)
+	if err != nil {
+		if cached == nil || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return Parse(bytes.NewReader(cached))
+	}
+
+	metadata, err := Parse(bytes.NewReader(document))
+	if err != nil {
+		return nil, err
+	}
+
+	writeCache(documentPath, infoPath, document, info)
+	return metadata, nil
+}
+
+// isFresh reports whether a document fetched as recorded by info is younger
+// than the MaxAge of options, and so is used without revalidation.
+func (info cacheInfo) isFresh(options LoaderOptions) bool {
+	maxAge := options.MaxAge
+	if maxAge == 0 {
+		maxAge = defaultMaxAge
+	}
+	return time.Since(info.FetchedAt) < maxAge
+}
+
+// fetch requests the $metadata document, revalidating cached if it is not
+// nil. info is updated with the validators of the returned document.
+func fetch(ctx context.Context, options LoaderOptions, cached []byte, info *cacheInfo) ([]byte, error) {
+	metadataUrl := *options.BaseUrl
+	metadataUrl.Path = path.Join(metadataUrl.Path, metadataPath)
+
+	rb := requestBuilder.NewRequestBuilder(http.MethodGet, metadataUrl.String(), nil).
+		AddHeader(headerAccept, contentTypeXML)
+	if cached != nil {
+		if info.ETag != "" {
+			rb.AddHeader(headerIfNoneMatch, info.ETag)
+		}
+		if info.LastModified != "" {
+			rb.AddHeader(headerIfModifiedSince, info.LastModified)
+		}
+	}
+	req, err := rb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := options.DataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve $metadata: %w", err)
+	}
+
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		info.FetchedAt = time.Now()
+		return cached, nil
+	}
+	if !res.IsSuccessful {
+		return nil, fmt.Errorf("failed to retrieve $metadata: %w", service.NewDataverseError(res))
+	}
+
+	*info = cacheInfo{
+		URL:          options.BaseUrl.String(),
+		ETag:         res.Header.Get(headerETag),
+		LastModified: res.Header.Get(headerLastModified),
+		FetchedAt:    time.Now(),
+	}
+	return res.Body, nil
+}
+
+// cachePaths returns the paths of the cached document and its validators for
+// the environment. Files are named after a hash of the base URL.
+func cachePaths(options LoaderOptions) (string, string, error) {
+	dir := options.CacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", "", fmt.Errorf("unable to locate cache directory: %w", err)
+		}
+		dir = filepath.Join(userCacheDir, cacheDirName)
+	}
+
+	sum := sha256.Sum256([]byte(options.BaseUrl.String()))
+	name := hex.EncodeToString(sum[:])[:cacheKeyLength]
+	return filepath.Join(dir, name+cacheDocumentExtension),
+		filepath.Join(dir, name+cacheInfoExtension),
+		nil
+}
+
+// readCache returns the cached document and its validators, or nil if there
+// is no usable cached copy.
+func readCache(documentPath, infoPath string) ([]byte, cacheInfo) {
+	var info cacheInfo
+	infoBytes, err := os.ReadFile(infoPath)
+	if err != nil || json.Unmarshal(infoBytes, &info) != nil {
+		return nil, cacheInfo{}
+	}
+
+	document, err := os.ReadFile(documentPath)
+	if err != nil {
+		return nil, cacheInfo{}
+	}
+	return document, info
+}
+
+// writeCache stores the document and its validators. The cache is an
+// optimisation, so failures to write it are ignored.
+func writeCache(documentPath, infoPath string, document []byte, info cacheInfo) {
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(documentPath), cacheDirPermissions); err != nil {
+		return
+	}
+	if err := os.WriteFile(documentPath, document, cacheFilePermissions); err != nil {
+		return
+	}
+	_ = os.WriteFile(infoPath, infoBytes, cacheFilePermissions)
+}
@@ -0,0 +1,316 @@
+// Package csdl parses the OData Common Schema Definition Language (CSDL)
+// document returned by the Web API's $metadata endpoint, and provides a
+// queryable model of the entity types, properties, navigation properties,
+// actions and functions it describes.
+package csdl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// collectionTypePrefix and collectionTypeSuffix wrap the element type of a
+// collection-valued type, e.g. "Collection(mscrm.contact)".
+const (
+	collectionTypePrefix = "Collection("
+	collectionTypeSuffix = ")"
+)
+
+// ErrUnknownEntitySet is returned when validating properties of an entity set
+// that is not defined in the metadata.
+var ErrUnknownEntitySet = errors.New("unknown entity set")
+
+// ErrUnknownProperty is returned when validating a property that is not
+// defined on the entity type.
+var ErrUnknownProperty = errors.New("unknown property")
+
+// Metadata is the parsed content of a $metadata document.
+type Metadata struct {
+	// Schemas contains each schema in the document. Dataverse returns a
+	// single schema with the namespace "Microsoft.Dynamics.CRM".
+	Schemas []Schema
+
+	// entityTypes indexes entity types by qualified name and by alias
+	// qualified name
+	entityTypes map[string]*EntityType
+
+	// entitySets indexes entity sets by name
+	entitySets map[string]*EntitySet
+}
+
+// Schema groups the types and operations defined in a namespace.
+type Schema struct {
+	// Namespace is the schema namespace, e.g. "Microsoft.Dynamics.CRM"
+	Namespace string
+	// Alias is the short name used in place of the namespace, e.g. "mscrm"
+	Alias string
+	// EntityTypes contains the entity types defined in the schema
+	EntityTypes []EntityType
+	// Actions contains the actions defined in the schema
+	Actions []Operation
+	// Functions contains the functions defined in the schema
+	Functions []Operation
+	// EntitySets contains the entity sets exposed by the schema's entity
+	// container
+	EntitySets []EntitySet
+}
+
+// EntityType describes a structured type with a key, such as a Dataverse
+// table.
+type EntityType struct {
+	// Name is the unqualified name of the type, e.g. "account"
+	Name string
+	// Namespace is the namespace of the schema defining the type
+	Namespace string
+	// BaseType is the qualified name of the type this type derives from, or
+	// an empty string
+	BaseType string
+	// Abstract is true if the type cannot be instantiated
+	Abstract bool
+	// Key contains the names of the properties forming the type's key
+	Key []string
+	// Properties contains the structural properties declared on the type,
+	// excluding those inherited from its base type
+	Properties []Property
+	// NavigationProperties contains the navigation properties declared on
+	// the type, excluding those inherited from its base type
+	NavigationProperties []NavigationProperty
+}
+
+// Property describes a structural property of an entity type.
+type Property struct {
+	// Name is the property name, e.g. "address1_city"
+	Name string
+	// Type is the EDM type of the property, e.g. "Edm.String"
+	Type string
+	// Nullable is false if the property must have a value
+	Nullable bool
+}
+
+// NavigationProperty describes a relationship from an entity type to another
+// entity type.
+type NavigationProperty struct {
+	// Name is the property name, e.g. "parentcustomerid_account"
+	Name string
+	// Type is the qualified name of the related entity type. For collection
+	// valued properties, this is the element type.
+	Type string
+	// IsCollection is true if the property refers to many entities
+	IsCollection bool
+	// Partner is the name of the corresponding navigation property on the
+	// related type, or an empty string
+	Partner string
+	// ReferentialConstraints maps properties of this type to properties of
+	// the related type, e.g. "_parentcustomerid_value" to "accountid"
+	ReferentialConstraints []ReferentialConstraint
+}
+
+// ReferentialConstraint maps a property of the dependent type to the
+// referenced property of the principal type.
+type ReferentialConstraint struct {
+	// Property is the dependent property
+	Property string
+	// ReferencedProperty is the principal property
+	ReferencedProperty string
+}
+
+// Operation describes an action or function.
+type Operation struct {
+	// Name is the unqualified name of the operation, e.g. "WhoAmI"
+	Name string
+	// IsFunction is true for functions and false for actions
+	IsFunction bool
+	// IsBound is true if the operation is invoked on an entity or collection,
+	// which is passed as the first parameter
+	IsBound bool
+	// Parameters contains the operation's parameters in order
+	Parameters []Parameter
+	// ReturnType is the EDM type returned by the operation, or an empty string
+	// if it returns nothing
+	ReturnType string
+}
+
+// Parameter describes a parameter of an action or function.
+type Parameter struct {
+	// Name is the parameter name
+	Name string
+	// Type is the EDM type of the parameter
+	Type string
+	// Nullable is false if the parameter must have a value
+	Nullable bool
+}
+
+// EntitySet describes a collection of entities exposed by the service, which
+// corresponds to a Web API resource such as "accounts".
+type EntitySet struct {
+	// Name is the name of the entity set, e.g. "accounts"
+	Name string
+	// EntityType is the qualified name of the set's entity type
+	EntityType string
+}
+
+// newMetadata creates Metadata from parsed schemas and builds its indexes.
+func newMetadata(schemas []Schema) *Metadata {
+	m := &Metadata{
+		Schemas:     schemas,
+		entityTypes: make(map[string]*EntityType),
+		entitySets:  make(map[string]*EntitySet),
+	}
+
+	for si := range m.Schemas {
+		s := &m.Schemas[si]
+		for ti := range s.EntityTypes {
+			t := &s.EntityTypes[ti]
+			m.entityTypes[s.Namespace+"."+t.Name] = t
+			if s.Alias != "" {
+				m.entityTypes[s.Alias+"."+t.Name] = t
+			}
+		}
+		for ei := range s.EntitySets {
+			m.entitySets[s.EntitySets[ei].Name] = &s.EntitySets[ei]
+		}
+	}
+	return m
+}
+
+// EntityType returns the entity type with the given name. The name may be
+// qualified with the schema namespace or alias, or unqualified if it is
+// unique across schemas.
+func (m *Metadata) EntityType(name string) (*EntityType, bool) {
+	if t, ok := m.entityTypes[name]; ok {
+		return t, true
+	}
+	for _, s := range m.Schemas {
+		if t, ok := m.entityTypes[s.Namespace+"."+name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// EntitySet returns the entity set with the given name, e.g. "accounts".
+func (m *Metadata) EntitySet(name string) (*EntitySet, bool) {
+	es, ok := m.entitySets[name]
+	return es, ok
+}
+
+// EntityTypeForSet returns the entity type of the entity set with the given
+// name.
+func (m *Metadata) EntityTypeForSet(entitySetName string) (*EntityType, bool) {
+	es, ok := m.EntitySet(entitySetName)
+	if !ok {
+		return nil, false
+	}
+	return m.EntityType(es.EntityType)
+}
+
+// Property returns the structural property with the given name declared on
+// the entity type or inherited from its base types.
+func (m *Metadata) Property(t *EntityType, name string) (*Property, bool) {
+	for current := t; current != nil; current = m.baseType(current) {
+		for i := range current.Properties {
+			if current.Properties[i].Name == name {
+				return &current.Properties[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// NavigationProperty returns the navigation property with the given name
+// declared on the entity type or inherited from its base types.
+func (m *Metadata) NavigationProperty(t *EntityType, name string) (*NavigationProperty, bool) {
+	for current := t; current != nil; current = m.baseType(current) {
+		for i := range current.NavigationProperties {
+			if current.NavigationProperties[i].Name == name {
+				return &current.NavigationProperties[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Actions returns every action defined in the metadata.
+func (m *Metadata) Actions() []Operation {
+	actions := []Operation{}
+	for _, s := range m.Schemas {
+		actions = append(actions, s.Actions...)
+	}
+	return actions
+}
+
+// Functions returns every function defined in the metadata.
+func (m *Metadata) Functions() []Operation {
+	functions := []Operation{}
+	for _, s := range m.Schemas {
+		functions = append(functions, s.Functions...)
+	}
+	return functions
+}
+
+// ValidateProperties checks that each property is defined on the entity type
+// of the named entity set. Properties may be paths through single-valued
+// navigation properties, e.g. "primarycontactid/fullname".
+//
+// Returns ErrUnknownEntitySet if the entity set does not exist, or an error
+// wrapping ErrUnknownProperty for each property that does not exist.
+func (m *Metadata) ValidateProperties(entitySetName string, properties ...string) error {
+	t, ok := m.EntityTypeForSet(entitySetName)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEntitySet, entitySetName)
+	}
+
+	errs := []error{}
+	for _, p := range properties {
+		if !m.hasPropertyPath(t, p) {
+			errs = append(errs, fmt.Errorf("%w: %s/%s", ErrUnknownProperty, entitySetName, p))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasPropertyPath returns true if path resolves to a property of t, following
+// navigation properties for all but the last segment.
+func (m *Metadata) hasPropertyPath(t *EntityType, path string) bool {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if i == len(segments)-1 {
+			if _, ok := m.Property(t, segment); ok {
+				return true
+			}
+			_, ok := m.NavigationProperty(t, segment)
+			return ok
+		}
+
+		np, ok := m.NavigationProperty(t, segment)
+		if !ok || np.IsCollection {
+			return false
+		}
+		if t, ok = m.EntityType(np.Type); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// baseType returns the base type of t, or nil if it has none.
+func (m *Metadata) baseType(t *EntityType) *EntityType {
+	if t.BaseType == "" {
+		return nil
+	}
+	base, ok := m.EntityType(t.BaseType)
+	if !ok {
+		return nil
+	}
+	return base
+}
+
+// elementType returns the element type of a collection type, and whether the
+// type was a collection.
+func elementType(typeName string) (string, bool) {
+	if strings.HasPrefix(typeName, collectionTypePrefix) && strings.HasSuffix(typeName, collectionTypeSuffix) {
+		return strings.TrimSuffix(strings.TrimPrefix(typeName, collectionTypePrefix), collectionTypeSuffix), true
+	}
+	return typeName, false
+}
@@ -0,0 +1,188 @@
+// Package csdl parses the OData Common Schema Definition Language (CSDL)
+// document returned by the Web API's $metadata endpoint, and provides a
+// queryable model of the entity types, properties, navigation properties,
+// actions and functions it describes.
+package csdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// edmx mirrors the root element of a CSDL XML document. Elements are matched
+// by local name, so the edmx and edm namespace prefixes are not required.
+type edmx struct {
+	XMLName      xml.Name    `xml:"Edmx"`
+	DataServices []xmlSchema `xml:"DataServices>Schema"`
+}
+
+// xmlSchema mirrors a Schema element.
+type xmlSchema struct {
+	Namespace       string          `xml:"Namespace,attr"`
+	Alias           string          `xml:"Alias,attr"`
+	EntityTypes     []xmlEntityType `xml:"EntityType"`
+	Actions         []xmlOperation  `xml:"Action"`
+	Functions       []xmlOperation  `xml:"Function"`
+	EntityContainer []xmlEntitySet  `xml:"EntityContainer>EntitySet"`
+}
+
+// xmlEntityType mirrors an EntityType element.
+type xmlEntityType struct {
+	Name                 string                  `xml:"Name,attr"`
+	BaseType             string                  `xml:"BaseType,attr"`
+	Abstract             bool                    `xml:"Abstract,attr"`
+	Key                  []xmlPropertyRef        `xml:"Key>PropertyRef"`
+	Properties           []xmlProperty           `xml:"Property"`
+	NavigationProperties []xmlNavigationProperty `xml:"NavigationProperty"`
+}
+
+// xmlPropertyRef mirrors a PropertyRef element within a Key.
+type xmlPropertyRef struct {
+	Name string `xml:"Name,attr"`
+}
+
+// xmlProperty mirrors a Property or Parameter element. Nullable is a string
+// because an absent attribute means true.
+type xmlProperty struct {
+	Name     string `xml:"Name,attr"`
+	Type     string `xml:"Type,attr"`
+	Nullable string `xml:"Nullable,attr"`
+}
+
+// xmlNavigationProperty mirrors a NavigationProperty element.
+type xmlNavigationProperty struct {
+	Name                   string                     `xml:"Name,attr"`
+	Type                   string                     `xml:"Type,attr"`
+	Partner                string                     `xml:"Partner,attr"`
+	ReferentialConstraints []xmlReferentialConstraint `xml:"ReferentialConstraint"`
+}
+
+// xmlReferentialConstraint mirrors a ReferentialConstraint element.
+type xmlReferentialConstraint struct {
+	Property           string `xml:"Property,attr"`
+	ReferencedProperty string `xml:"ReferencedProperty,attr"`
+}
+
+// xmlOperation mirrors an Action or Function element.
+type xmlOperation struct {
+	Name       string        `xml:"Name,attr"`
+	IsBound    bool          `xml:"IsBound,attr"`
+	Parameters []xmlProperty `xml:"Parameter"`
+	ReturnType *xmlProperty  `xml:"ReturnType"`
+}
+
+// xmlEntitySet mirrors an EntitySet element within an EntityContainer.
+type xmlEntitySet struct {
+	Name       string `xml:"Name,attr"`
+	EntityType string `xml:"EntityType,attr"`
+}
+
+// Parse reads a CSDL XML document, as returned by the $metadata endpoint.
+// Returns an error if the document is not valid CSDL XML.
+func Parse(r io.Reader) (*Metadata, error) {
+	var doc edmx
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse $metadata document: %w", err)
+	}
+
+	schemas := make([]Schema, len(doc.DataServices))
+	for i, s := range doc.DataServices {
+		schemas[i] = s.toSchema()
+	}
+	return newMetadata(schemas), nil
+}
+
+// toSchema converts the XML schema into its public representation.
+func (s xmlSchema) toSchema() Schema {
+	schema := Schema{
+		Namespace:   s.Namespace,
+		Alias:       s.Alias,
+		EntityTypes: make([]EntityType, len(s.EntityTypes)),
+		Actions:     make([]Operation, len(s.Actions)),
+		Functions:   make([]Operation, len(s.Functions)),
+		EntitySets:  make([]EntitySet, len(s.EntityContainer)),
+	}
+
+	for i, t := range s.EntityTypes {
+		schema.EntityTypes[i] = t.toEntityType(s.Namespace)
+	}
+	for i, a := range s.Actions {
+		schema.Actions[i] = a.toOperation(false)
+	}
+	for i, f := range s.Functions {
+		schema.Functions[i] = f.toOperation(true)
+	}
+	for i, es := range s.EntityContainer {
+		schema.EntitySets[i] = EntitySet(es)
+	}
+	return schema
+}
+
+// toEntityType converts the XML entity type into its public representation.
+func (t xmlEntityType) toEntityType(namespace string) EntityType {
+	entityType := EntityType{
+		Name:                 t.Name,
+		Namespace:            namespace,
+		BaseType:             t.BaseType,
+		Abstract:             t.Abstract,
+		Key:                  make([]string, len(t.Key)),
+		Properties:           make([]Property, len(t.Properties)),
+		NavigationProperties: make([]NavigationProperty, len(t.NavigationProperties)),
+	}
+
+	for i, k := range t.Key {
+		entityType.Key[i] = k.Name
+	}
+	for i, p := range t.Properties {
+		entityType.Properties[i] = p.toProperty()
+	}
+	for i, np := range t.NavigationProperties {
+		entityType.NavigationProperties[i] = np.toNavigationProperty()
+	}
+	return entityType
+}
+
+// toProperty converts the XML property into its public representation.
+func (p xmlProperty) toProperty() Property {
+	return Property{
+		Name:     p.Name,
+		Type:     p.Type,
+		Nullable: p.Nullable != "false",
+	}
+}
+
+// toNavigationProperty converts the XML navigation property into its public
+// representation.
+func (np xmlNavigationProperty) toNavigationProperty() NavigationProperty {
+	typeName, isCollection := elementType(np.Type)
+	navigationProperty := NavigationProperty{
+		Name:                   np.Name,
+		Type:                   typeName,
+		IsCollection:           isCollection,
+		Partner:                np.Partner,
+		ReferentialConstraints: make([]ReferentialConstraint, len(np.ReferentialConstraints)),
+	}
+	for i, rc := range np.ReferentialConstraints {
+		navigationProperty.ReferentialConstraints[i] = ReferentialConstraint(rc)
+	}
+	return navigationProperty
+}
+
+// toOperation converts the XML action or function into its public
+// representation.
+func (o xmlOperation) toOperation(isFunction bool) Operation {
+	operation := Operation{
+		Name:       o.Name,
+		IsFunction: isFunction,
+		IsBound:    o.IsBound,
+		Parameters: make([]Parameter, len(o.Parameters)),
+	}
+	for i, p := range o.Parameters {
+		operation.Parameters[i] = Parameter(p.toProperty())
+	}
+	if o.ReturnType != nil {
+		operation.ReturnType = o.ReturnType.Type
+	}
+	return operation
+}
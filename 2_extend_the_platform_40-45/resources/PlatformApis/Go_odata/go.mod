@@ -2,15 +2,37 @@ module github.com/turnerbenjamin/go_odata
 
 go 1.24.1
 
-require github.com/AzureAD/microsoft-authentication-library-for-go v1.4.2
+require (
+	github.com/AzureAD/microsoft-authentication-library-for-go v1.4.2
+	github.com/gofrs/flock v0.12.1
+	github.com/zalando/go-keyring v0.2.6
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/sqlite v1.38.2
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
+
+require (
+	al.essio.dev/pkg/shellescape v1.5.1 // indirect
+	github.com/danieljoos/wincred v1.2.2 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/godbus/dbus/v5 v5.1.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+)
 
 require (
 	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203
 	github.com/golang-jwt/jwt/v5 v5.0.0 // indirect
-	github.com/google/uuid v1.3.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/joho/godotenv v1.5.1
 	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8 // indirect
-	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/term v0.31.0
+	golang.org/x/sys v0.34.0 // indirect
+	golang.org/x/term v0.32.0
 )
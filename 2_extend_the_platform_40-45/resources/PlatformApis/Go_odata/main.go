@@ -5,83 +5,230 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
-	"net/url"
 	"os"
+	"strconv"
 
 	goDotEnv "github.com/joho/godotenv"
 	"github.com/turnerbenjamin/go_odata/app"
+	"github.com/turnerbenjamin/go_odata/cli"
+	"github.com/turnerbenjamin/go_odata/config"
+	"github.com/turnerbenjamin/go_odata/secrets"
+	"github.com/turnerbenjamin/go_odata/tokencache"
 )
 
-// Constants used throughout the application.
-const (
-	// unableToParseUrlMsgFmt is the error message format for URL parsing
-	// failures.
-	unableToParseUrlMsgFmt = "unable to parse url (%s)"
-	// maxPageLimit defines the maximum number of records to retrieve per page.
-	maxPageLimit = 5
-)
+// globalFlags holds the command line flags accepted before any subcommand.
+// Flags take precedence over environment variables, which take precedence
+// over the profiles file.
+type globalFlags struct {
+	configPath string
+	profile    string
+	settings   config.Profile
+}
 
 // main initializes and runs the application.
-// It loads configuration from environment variables, sets up URL paths for
-// the API and authentication endpoints, and initializes the application with
-// these settings.
+// It resolves the environment settings from flags, environment variables and
+// the profiles file, then runs either a command line subcommand or the
+// interactive application with these settings.
 func main() {
 
-	// Load environment variables from .env file
+	// Load environment variables from a .env file, if there is one
 	err := goDotEnv.Load()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("unable to load .env file: %s", err)
+	}
+
+	flags := parseGlobalFlags()
+	configs, err := loadAppConfigs(flags)
+
+	// Run a command line subcommand, e.g. "accounts list", if one is given,
+	// exiting with a code describing any failure
+	if flag.NArg() > 0 {
+		// An invalid or incomplete configuration is a usage error
+		if err != nil {
+			err = fmt.Errorf("%w: %w", cli.ErrUsage, err)
+		}
+		if err == nil && len(configs) > 1 {
+			err = fmt.Errorf("%w: select an environment with --profile or %s",
+				cli.ErrUsage, config.EnvProfile)
+		}
+		if err == nil {
+			err = cli.Run(configs[0], flag.Args())
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(cli.ExitCode(err))
+	}
+
 	if err != nil {
-		log.Fatal("unable to load .env file")
+		log.Fatal(err)
 	}
 
-	// Extract required configuration from environment variables
-	clientID := os.Getenv("CLIENT_ID")
-	tenantID := os.Getenv("TENANT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
-	apiPath := os.Getenv("API_PATH")
+	// Initialize the application
+	a, err := app.NewApp(configs...)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Parse the environment URL (Dataverse instance)
-	environmentURL, err := url.Parse(os.Getenv("ENVIRONMENT_URL"))
+	// Run the application
+	err = a.Run()
 	if err != nil {
-		log.Fatalf(unableToParseUrlMsgFmt, os.Getenv("ENVIRONMENT_URL"))
+		log.Fatalf("The application has experienced a fatal error: %s", err.Error())
 	}
+}
 
-	// Create API base URL by combining environment URL with API path
-	apiBaseURL := *environmentURL
-	apiBaseURL.Path = apiPath
+// parseGlobalFlags parses the flags preceding any subcommand.
+func parseGlobalFlags() globalFlags {
+	var f globalFlags
+	flag.StringVar(&f.configPath, "config", "", "path of the profiles file")
+	flag.StringVar(&f.profile, "profile", "", "name of the profile to use")
+	flag.StringVar(&f.settings.EnvironmentURL, "environment-url", "", "URL of the Dataverse environment")
+	flag.StringVar(&f.settings.TenantID, "tenant-id", "", "EntraID tenant ID")
+	flag.StringVar(&f.settings.ClientID, "client-id", "", "EntraID application (client) ID")
+	flag.StringVar(&f.settings.Authority, "authority", "", "EntraID authority URL")
+	flag.StringVar(&f.settings.AuthMode, "auth-mode", "", "authentication mode: app, user or device")
+	flag.IntVar(&f.settings.PageSize, "page-size", 0, "number of records per page")
+	flag.StringVar(&f.settings.APIVersion, "api-version", "", "Web API version, e.g. 9.2")
+	flag.StringVar(&f.settings.ClientSecretSource, "client-secret-source", "",
+		"source of the client secret: env:NAME, file:PATH, command:CMD, keyring:SERVICE/USER or prompt")
+	flag.StringVar(&f.settings.ClientCertificatePath, "client-certificate", "",
+		"PEM or PFX certificate file used in place of the client secret")
+	flag.StringVar(&f.settings.ClientCertificatePasswordSource, "client-certificate-password-source", "",
+		"source of the certificate file's password, in the format of -client-secret-source")
+	flag.BoolFunc("send-x5c",
+		"send the certificate chain for subject name/issuer authentication",
+		func(value string) error {
+			sendX5C, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			f.settings.SendX5C = &sendX5C
+			return nil
+		})
+	flag.StringVar(&f.settings.ClientAssertionFile, "client-assertion-file", "",
+		"file holding a signed client assertion, e.g. a federated workload identity token")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(),
+			"Usage: %s [flags] [command]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	return f
+}
+
+// loadAppConfigs resolves the environments the application may connect to.
+// Each setting is taken from the flags, then environment variables, then the
+// selected profile in the profiles file. If no profile is selected and the
+// file has several, a configuration is returned for each so that the user
+// can choose one, without the settings from flags and environment variables.
+func loadAppConfigs(flags globalFlags) ([]app.AppConfig, error) {
+	path := flags.configPath
+	if path == "" {
+		var err error
+		path, err = config.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// Parse the authority URL (Entra Id endpoint)
-	authorityURL, err := url.Parse(os.Getenv("AUTHORITY"))
+	file, err := config.Load(path)
 	if err != nil {
-		log.Fatalf(unableToParseUrlMsgFmt, os.Getenv("AUTHORITY_URL"))
+		return nil, err
 	}
 
-	// Append tenant ID to authority path for tenant-specific authentication
-	authorityURL.Path, err = url.JoinPath(authorityURL.Path, tenantID)
+	env, err := config.FromEnv(os.Getenv)
 	if err != nil {
-		log.Fatal(err.Error())
+		return nil, err
 	}
 
-	// Create application configuration
-	c := app.AppConfig{
-		ClientID:     clientID,
-		TenantID:     tenantID,
-		ClientSecret: clientSecret,
-		ResourceURL:  environmentURL.String(),
-		APIBaseURL:   apiBaseURL.String(),
-		Authority:    authorityURL.String(),
-		PageLimit:    maxPageLimit,
+	profileName := flags.profile
+	if profileName == "" {
+		profileName = os.Getenv(config.EnvProfile)
 	}
 
-	// Initialize the application
-	a, err := app.NewApp(c)
+	profiles, err := file.Resolve(profileName, env, flags.settings)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if len(profiles) > 1 && (!env.IsEmpty() || !flags.settings.IsEmpty()) {
+		log.Printf("warning: settings from environment variables and flags are "+
+			"ignored until a profile is selected with --profile or %s", config.EnvProfile)
 	}
 
-	// Run the application
-	err = a.Run()
+	configs := make([]app.AppConfig, len(profiles))
+	for i, p := range profiles {
+		configs[i], err = newAppConfig(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// newAppConfig creates the application configuration for a resolved
+// profile. If the profile does not set the client secret's source, the
+// secret is read from the CLIENT_SECRET environment variable if it is set,
+// or the user is prompted for it.
+func newAppConfig(p config.Profile) (app.AppConfig, error) {
+	apiBaseURL, err := p.APIBaseURL()
 	if err != nil {
-		log.Fatalf("The application has experienced a fatal error: %s", err.Error())
+		return app.AppConfig{}, err
+	}
+
+	authorityURL, err := p.AuthorityURL()
+	if err != nil {
+		return app.AppConfig{}, err
+	}
+
+	c := app.AppConfig{
+		Name:                      p.Name,
+		ClientID:                  p.ClientID,
+		TenantID:                  p.TenantID,
+		ClientSecret:              clientSecretSource(p),
+		CertificatePath:           p.ClientCertificatePath,
+		CertificatePasswordSource: p.ClientCertificatePasswordSource,
+		SendX5C:                   p.SendsX5C(),
+		ClientAssertionFile:       p.ClientAssertionFile,
+		ResourceURL:               p.EnvironmentURL,
+		APIBaseURL:                apiBaseURL,
+		Authority:                 authorityURL,
+		PageLimit:                 p.PageSize,
+		TokenCachePath:            tokenCachePath(p),
+	}
+	if p.AuthMode != "" {
+		c.AuthMode = p.AuthenticationMode()
+	}
+	return c, nil
+}
+
+// tokenCachePath returns the path of the profile's token cache file in the
+// user's configuration directory, or an empty string, so that tokens are kept
+// in memory only, if the directory is unknown. Each profile has its own file,
+// so that signing out of one environment keeps the tokens of the others.
+func tokenCachePath(p config.Profile) string {
+	path, err := tokencache.ProfilePath(p.Name, p.TenantID, p.ClientID, p.EnvironmentURL)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// clientSecretSource returns the source of the client secret for a profile.
+func clientSecretSource(p config.Profile) string {
+	switch {
+	case p.ClientSecretSource != "":
+		return p.ClientSecretSource
+	case os.Getenv(config.EnvClientSecret) != "":
+		return secrets.SourceEnv + ":" + config.EnvClientSecret
+	default:
+		return secrets.SourcePrompt
 	}
 }
@@ -0,0 +1,369 @@
+// Package mirror keeps a local SQLite copy of Dataverse tables, applying the
+// changes reported by change tracking so that each sync only transfers the
+// rows created, updated or deleted since the previous one.
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/turnerbenjamin/go_odata/model"
+	"github.com/turnerbenjamin/go_odata/service"
+	"github.com/turnerbenjamin/go_odata/view"
+
+	// Registers the "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+// Values used when opening the database and requesting changes.
+const (
+	driverName = "sqlite"
+
+	// defaultPageLimit is the number of rows requested per page when
+	// Options.PageLimit is not set
+	defaultPageLimit = 5000
+
+	// openPragmas makes concurrent readers, such as reporting tools, wait
+	// for a sync to commit rather than fail
+	openPragmas = "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+)
+
+// ErrNoTables is returned when a Mirror is created without any tables.
+var ErrNoTables = errors.New("no tables to mirror")
+
+// TableOptions identifies a table to mirror and the columns to copy.
+type TableOptions struct {
+	// LogicalName is the table's logical name, e.g. "account". It is also
+	// the name of the local table
+	LogicalName string
+
+	// Columns lists the logical names of the columns to copy. The primary
+	// id column is always copied. Lookup columns may be given by their
+	// logical name or value property, e.g. "parentcustomerid" or
+	// "_parentcustomerid_value", and hold the referenced row's ID
+	Columns []string
+}
+
+// Options contains configuration parameters for creating a Mirror.
+type Options struct {
+	// DB is the SQLite database holding the local copy, e.g. from Open
+	DB *sql.DB
+
+	// DataverseService handles the actual HTTP communication with the API
+	DataverseService service.DataverseService
+
+	// MetadataService provides the table and column definitions used to
+	// create the local schema
+	MetadataService service.MetadataService
+
+	// BaseUrl is the root URL of the API
+	BaseUrl *url.URL
+
+	// PageLimit is the maximum number of rows retrieved per request. If
+	// zero, 5000 is used
+	PageLimit int
+
+	// Tables lists the tables to mirror
+	Tables []TableOptions
+}
+
+// SyncResult summarises the changes applied to a local table by a sync.
+type SyncResult struct {
+	// Table is the logical name of the table
+	Table string
+
+	// Upserted is the number of rows inserted or updated
+	Upserted int
+
+	// Deleted is the number of rows removed
+	Deleted int
+
+	// IsFull is true if every row was copied, because the table had not
+	// been synced, its columns changed or its delta link had expired
+	IsFull bool
+
+	// SyncedAt is the time the changes were committed
+	SyncedAt time.Time
+}
+
+// Mirror copies Dataverse tables into a local SQLite database.
+type Mirror interface {
+	// Sync brings each local table up to date with Dataverse. The first sync
+	// of a table, or a sync after its columns change or its delta link
+	// expires, copies every row; later syncs apply only the changes since
+	// the previous one. The
+	// changes to a table and its new watermark are committed together, so
+	// an interrupted sync can safely be repeated.
+	// Returns a result for each table synced and an error joining the
+	// failures of any other tables.
+	Sync() ([]SyncResult, error)
+
+	// SyncContext brings each local table up to date like Sync, abandoning
+	// the sync if ctx is cancelled.
+	SyncContext(ctx context.Context) ([]SyncResult, error)
+
+	// Reset removes the watermarks of the mirrored tables, so that the next
+	// sync copies every row.
+	Reset() error
+
+	// ResetContext removes the watermarks like Reset.
+	ResetContext(ctx context.Context) error
+
+	// Watermarks returns the watermark of each table that has been synced.
+	Watermarks() ([]Watermark, error)
+
+	// WatermarksContext returns the watermarks like Watermarks.
+	WatermarksContext(ctx context.Context) ([]Watermark, error)
+}
+
+// mirror implements the Mirror interface.
+type mirror struct {
+	db               *sql.DB
+	dataverseService service.DataverseService
+	metadataService  service.MetadataService
+	baseUrl          *url.URL
+	pageLimit        int
+	tables           []TableOptions
+}
+
+// Open opens the SQLite database at path, creating it if it does not exist.
+func Open(path string) (*sql.DB, error) {
+	return sql.Open(driverName, path+openPragmas)
+}
+
+// NewMirror creates a Mirror with the provided options.
+// Returns ErrNoTables if no tables are given.
+func NewMirror(options Options) (Mirror, error) {
+	if len(options.Tables) == 0 {
+		return nil, ErrNoTables
+	}
+
+	pageLimit := options.PageLimit
+	if pageLimit <= 0 {
+		pageLimit = defaultPageLimit
+	}
+
+	return &mirror{
+		db:               options.DB,
+		dataverseService: options.DataverseService,
+		metadataService:  options.MetadataService,
+		baseUrl:          options.BaseUrl,
+		pageLimit:        pageLimit,
+		tables:           options.Tables,
+	}, nil
+}
+
+// Sync brings each local table up to date with Dataverse.
+func (m *mirror) Sync() ([]SyncResult, error) {
+	return m.SyncContext(context.Background())
+}
+
+// SyncContext brings each local table up to date with Dataverse, abandoning
+// the sync if ctx is cancelled.
+func (m *mirror) SyncContext(ctx context.Context) ([]SyncResult, error) {
+	if err := createWatermarkTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+
+	results := make([]SyncResult, 0, len(m.tables))
+	errs := []error{}
+	for _, table := range m.tables {
+		result, err := m.syncTable(ctx, table)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync %s: %w", table.LogicalName, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// syncTable brings a single local table up to date.
+func (m *mirror) syncTable(ctx context.Context, table TableOptions) (*SyncResult, error) {
+	definition, err := m.metadataService.EntityDefinitionContext(ctx, table.LogicalName)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := m.metadataService.AttributesContext(ctx, table.LogicalName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := resolveColumns(*definition, attributes, table.Columns)
+	if err != nil {
+		return nil, err
+	}
+	typesChanged, err := createTable(ctx, m.db, definition.LogicalName, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	watermark, err := readWatermark(ctx, m.db, definition.LogicalName)
+	if err != nil {
+		return nil, err
+	}
+
+	deltaLink := ""
+	if watermark != nil && watermark.Columns == columnsKey(columns) && !typesChanged {
+		deltaLink = watermark.DeltaLink
+	}
+
+	records := m.newRecordService(*definition, columns)
+	result, err := applyChanges(ctx, m.db, records, definition.LogicalName, columns, deltaLink)
+	if deltaLink != "" && errors.Is(err, service.ErrDeltaLinkExpired) {
+		if err := deleteWatermarks(ctx, m.db, []string{definition.LogicalName}); err != nil {
+			return nil, err
+		}
+		return applyChanges(ctx, m.db, records, definition.LogicalName, columns, "")
+	}
+	return result, err
+}
+
+// newRecordService creates an entity service selecting the mirrored columns
+// of a table.
+func (m *mirror) newRecordService(definition model.EntityDefinition, columns []column) service.EntityService[*model.DynamicRecord] {
+	selects := make([]string, len(columns))
+	for i, c := range columns {
+		selects[i] = c.property
+	}
+
+	return service.NewEntityService[*model.DynamicRecord](service.EntityServiceOptions{
+		DataverseService: m.dataverseService,
+		BaseUrl:          m.baseUrl,
+		PageLimit:        m.pageLimit,
+		ResourcePath:     definition.EntitySetName,
+		SelectsFields:    selects,
+		NewEntity: func() view.Entity {
+			return model.NewDynamicRecordFor(definition)
+		},
+	})
+}
+
+// applyChanges retrieves the changes to a local table since deltaLink, or
+// every row if deltaLink is empty, and writes them and the new watermark in a
+// single transaction. Each page of changes is written as it is retrieved, so
+// a full copy of a large table is not held in memory.
+//
+// Rows are upserted by primary key and deletions of rows that do not exist
+// are ignored, so applying the same changes twice has no further effect. When
+// every row is copied, rows that no longer exist are removed.
+func applyChanges(
+	ctx context.Context,
+	db *sql.DB,
+	records service.EntityService[*model.DynamicRecord],
+	tableName string,
+	columns []column,
+	deltaLink string) (*SyncResult, error) {
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	isFull := deltaLink == ""
+	if isFull {
+		if _, err := tx.ExecContext(ctx, deleteAllStatement(tableName)); err != nil {
+			return nil, err
+		}
+	}
+
+	upsert, err := tx.PrepareContext(ctx, upsertStatement(tableName, columns))
+	if err != nil {
+		return nil, err
+	}
+	defer upsert.Close()
+
+	remove, err := tx.PrepareContext(ctx, deleteStatement(tableName, columns[0]))
+	if err != nil {
+		return nil, err
+	}
+	defer remove.Close()
+
+	result := &SyncResult{
+		Table:  tableName,
+		IsFull: isFull,
+	}
+	newDeltaLink, err := records.ChangesSincePagesContext(ctx, deltaLink,
+		func(page *service.DeltaResult[*model.DynamicRecord]) error {
+			for _, record := range page.Upserted {
+				values := make([]any, len(columns))
+				for i, c := range columns {
+					values[i] = c.value(record)
+				}
+				if _, err := upsert.ExecContext(ctx, values...); err != nil {
+					return fmt.Errorf("failed to upsert %s: %w", record.ID(), err)
+				}
+			}
+
+			for _, deleted := range page.Deleted {
+				if _, err := remove.ExecContext(ctx, deleted.ID); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", deleted.ID, err)
+				}
+			}
+
+			result.Upserted += len(page.Upserted)
+			result.Deleted += len(page.Deleted)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	result.SyncedAt = time.Now().UTC()
+	err = writeWatermark(ctx, tx, Watermark{
+		Table:     tableName,
+		Columns:   columnsKey(columns),
+		DeltaLink: newDeltaLink,
+		SyncedAt:  result.SyncedAt,
+		Upserted:  result.Upserted,
+		Deleted:   result.Deleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Reset removes the watermarks of the mirrored tables.
+func (m *mirror) Reset() error {
+	return m.ResetContext(context.Background())
+}
+
+// ResetContext removes the watermarks of the mirrored tables, abandoning the
+// operation if ctx is cancelled.
+func (m *mirror) ResetContext(ctx context.Context) error {
+	if err := createWatermarkTable(ctx, m.db); err != nil {
+		return err
+	}
+	tableNames := make([]string, len(m.tables))
+	for i, table := range m.tables {
+		tableNames[i] = table.LogicalName
+	}
+	return deleteWatermarks(ctx, m.db, tableNames)
+}
+
+// Watermarks returns the watermark of each table that has been synced.
+func (m *mirror) Watermarks() ([]Watermark, error) {
+	return m.WatermarksContext(context.Background())
+}
+
+// WatermarksContext returns the watermark of each table that has been
+// synced, abandoning the query if ctx is cancelled.
+func (m *mirror) WatermarksContext(ctx context.Context) ([]Watermark, error) {
+	if err := createWatermarkTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	return readWatermarks(ctx, m.db)
+}
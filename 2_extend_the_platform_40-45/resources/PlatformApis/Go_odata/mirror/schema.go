@@ -0,0 +1,280 @@
+// Package mirror keeps a local SQLite copy of Dataverse tables, applying the
+// changes reported by change tracking so that each sync only transfers the
+// rows created, updated or deleted since the previous one.
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/model"
+)
+
+// SQLite column types, chosen from the Dataverse column type.
+const (
+	sqlTypeText    = "TEXT"
+	sqlTypeInteger = "INTEGER"
+	sqlTypeReal    = "REAL"
+)
+
+// ErrUnknownColumn is returned when a mirrored column does not exist or
+// cannot be read.
+var ErrUnknownColumn = errors.New("unknown column")
+
+// column describes a mirrored column.
+type column struct {
+	// name is the column's logical name, also used as the local column name
+	name string
+
+	// property is the name of the property holding the column's value in
+	// Web API responses, which differs from name for lookup columns
+	property string
+
+	// sqlType is one of the sqlType constants
+	sqlType string
+}
+
+// resolveColumns returns the mirrored columns of a table: its primary id
+// column followed by the requested columns in the order given.
+// Returns an error wrapping ErrUnknownColumn listing every requested column
+// that is not a readable column of the table.
+func resolveColumns(
+	definition model.EntityDefinition,
+	attributes []model.AttributeDefinition,
+	logicalNames []string) ([]column, error) {
+
+	byName := make(map[string]model.AttributeDefinition, len(attributes))
+	for _, a := range attributes {
+		byName[a.LogicalName] = a
+	}
+
+	columns := []column{{
+		name:     definition.PrimaryIdAttribute,
+		property: definition.PrimaryIdAttribute,
+		sqlType:  sqlTypeText,
+	}}
+
+	unknown := []string{}
+	for _, logicalName := range logicalNames {
+		logicalName = lookupColumnName(logicalName)
+		attribute, ok := byName[logicalName]
+		if !ok {
+			unknown = append(unknown, logicalName)
+			continue
+		}
+		if slices.ContainsFunc(columns, func(c column) bool { return c.name == logicalName }) {
+			continue
+		}
+		columns = append(columns, newColumn(attribute))
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w in %s: %s",
+			ErrUnknownColumn, definition.LogicalName, strings.Join(unknown, ", "))
+	}
+	return columns, nil
+}
+
+// lookupColumnName returns the logical name of a lookup column given its
+// value property, e.g. "parentcustomerid" for "_parentcustomerid_value". Other
+// names are returned unchanged.
+func lookupColumnName(name string) string {
+	if trimmed, ok := strings.CutPrefix(name, "_"); ok {
+		if trimmed, ok := strings.CutSuffix(trimmed, "_value"); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// newColumn creates a mirrored column from its definition.
+func newColumn(attribute model.AttributeDefinition) column {
+	c := column{
+		name:     attribute.LogicalName,
+		property: attribute.LogicalName,
+		sqlType:  sqlTypeText,
+	}
+
+	switch {
+	case attribute.IsLookup():
+		c.property = model.LookupValueProperty(attribute.LogicalName)
+	case attribute.IsMultiSelectChoice():
+		// Multi-select choices are held as comma-separated values
+	case attribute.IsChoice(),
+		attribute.AttributeType == model.AttributeTypeInteger,
+		attribute.AttributeType == model.AttributeTypeBigInt,
+		attribute.AttributeType == model.AttributeTypeBoolean:
+		c.sqlType = sqlTypeInteger
+	case attribute.AttributeType == model.AttributeTypeDecimal,
+		attribute.AttributeType == model.AttributeTypeMoney:
+		// Decimal and money values are held as text so that no precision
+		// is lost converting them to floating point
+	case attribute.AttributeType == model.AttributeTypeDouble:
+		c.sqlType = sqlTypeReal
+	}
+	return c
+}
+
+// value returns the column's value in record, converted to a value that can
+// be stored in a column of its SQLite type. Missing values are stored as
+// NULL and booleans as 0 or 1.
+func (c column) value(record *model.DynamicRecord) any {
+	value, ok := record.Get(c.property)
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case json.Number:
+		switch c.sqlType {
+		case sqlTypeInteger:
+			if i, err := v.Int64(); err == nil {
+				return i
+			}
+		case sqlTypeReal:
+			if f, err := v.Float64(); err == nil {
+				return f
+			}
+		}
+		return v.String()
+	case string:
+		return v
+	default:
+		return record.GetString(c.property)
+	}
+}
+
+// columnsKey returns a string identifying the mirrored columns and their
+// order, stored with the watermark to detect changes to the columns.
+func columnsKey(columns []column) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return strings.Join(names, ",")
+}
+
+// createTable creates the local table if it does not exist, with the first
+// column as its primary key, and adds any mirrored columns it is missing.
+// Columns created with a different type by an earlier version, such as money
+// columns held as REAL, are dropped and added again with the new type.
+// Returns true if any column was recreated, in which case every row must be
+// copied again.
+func createTable(ctx context.Context, db *sql.DB, tableName string, columns []column) (bool, error) {
+	definitions := make([]string, len(columns))
+	for i, c := range columns {
+		definitions[i] = quoteIdentifier(c.name) + " " + c.sqlType
+		if i == 0 {
+			definitions[i] += " PRIMARY KEY NOT NULL"
+		}
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)",
+		quoteIdentifier(tableName), strings.Join(definitions, ", "))
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return false, err
+	}
+
+	existing, err := tableColumns(ctx, db, tableName)
+	if err != nil {
+		return false, err
+	}
+
+	recreated := false
+	for i, c := range columns {
+		sqlType, ok := existing[c.name]
+		if ok && (i == 0 || strings.EqualFold(sqlType, c.sqlType)) {
+			continue
+		}
+
+		if ok {
+			statement := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+				quoteIdentifier(tableName), quoteIdentifier(c.name))
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return false, err
+			}
+			recreated = true
+		}
+
+		statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+			quoteIdentifier(tableName), quoteIdentifier(c.name), c.sqlType)
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return false, err
+		}
+	}
+	return recreated, nil
+}
+
+// tableColumns returns the declared type of each column of a local table,
+// keyed by column name.
+func tableColumns(ctx context.Context, db *sql.DB, tableName string) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?)", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		var name, sqlType string
+		if err := rows.Scan(&name, &sqlType); err != nil {
+			return nil, err
+		}
+		types[name] = sqlType
+	}
+	return types, rows.Err()
+}
+
+// upsertStatement returns a statement inserting a row with a parameter for
+// each column, or updating the existing row with the same primary key.
+func upsertStatement(tableName string, columns []column) string {
+	names := make([]string, len(columns))
+	params := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for i, c := range columns {
+		names[i] = quoteIdentifier(c.name)
+		params[i] = "?"
+		if i > 0 {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", names[i], names[i]))
+		}
+	}
+
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT(%s) DO ",
+		quoteIdentifier(tableName),
+		strings.Join(names, ", "),
+		strings.Join(params, ", "),
+		names[0])
+	if len(updates) == 0 {
+		return statement + "NOTHING"
+	}
+	return statement + "UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// deleteStatement returns a statement deleting the row whose primary key is
+// given as a parameter.
+func deleteStatement(tableName string, primaryKey column) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE %s = ?",
+		quoteIdentifier(tableName), quoteIdentifier(primaryKey.name))
+}
+
+// deleteAllStatement returns a statement deleting every row of a table.
+func deleteAllStatement(tableName string) string {
+	return "DELETE FROM " + quoteIdentifier(tableName)
+}
+
+// quoteIdentifier quotes a table or column name for use in a statement.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,154 @@
+// Package mirror keeps a local SQLite copy of Dataverse tables, applying the
+// changes reported by change tracking so that each sync only transfers the
+// rows created, updated or deleted since the previous one.
+package mirror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Statements managing the watermark table. Its name begins with an
+// underscore, which Dataverse logical names cannot, so that it never clashes
+// with a mirrored table.
+const (
+	createWatermarkTableStatement = `CREATE TABLE IF NOT EXISTS "_sync_watermarks" (
+	"table_name" TEXT PRIMARY KEY NOT NULL,
+	"columns" TEXT NOT NULL,
+	"delta_link" TEXT NOT NULL,
+	"synced_at" TEXT NOT NULL,
+	"upserted" INTEGER NOT NULL,
+	"deleted" INTEGER NOT NULL
+)`
+	selectWatermarksStatement = `SELECT "table_name", "columns", "delta_link", "synced_at", "upserted", "deleted"
+FROM "_sync_watermarks"`
+	selectWatermarkStatement = selectWatermarksStatement + ` WHERE "table_name" = ?`
+	upsertWatermarkStatement = `INSERT INTO "_sync_watermarks"
+("table_name", "columns", "delta_link", "synced_at", "upserted", "deleted")
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT("table_name") DO UPDATE SET
+"columns" = excluded."columns",
+"delta_link" = excluded."delta_link",
+"synced_at" = excluded."synced_at",
+"upserted" = excluded."upserted",
+"deleted" = excluded."deleted"`
+	deleteWatermarkStatement = `DELETE FROM "_sync_watermarks" WHERE "table_name" = ?`
+)
+
+// Watermark records how far a local table has been synced.
+type Watermark struct {
+	// Table is the logical name of the table
+	Table string
+
+	// Columns lists the mirrored columns, separated by commas, when the
+	// table was synced. If the columns change, the next sync copies every
+	// row
+	Columns string
+
+	// DeltaLink is used by the next sync to retrieve the changes made since
+	// this one
+	DeltaLink string
+
+	// SyncedAt is the time the sync was committed
+	SyncedAt time.Time
+
+	// Upserted is the number of rows inserted or updated by the sync
+	Upserted int
+
+	// Deleted is the number of rows removed by the sync
+	Deleted int
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// createWatermarkTable creates the watermark table if it does not exist.
+func createWatermarkTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, createWatermarkTableStatement)
+	return err
+}
+
+// readWatermark returns the watermark of a table, or nil if the table has not
+// been synced.
+func readWatermark(ctx context.Context, db *sql.DB, tableName string) (*Watermark, error) {
+	watermark, err := scanWatermark(db.QueryRowContext(ctx, selectWatermarkStatement, tableName))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return watermark, err
+}
+
+// readWatermarks returns the watermark of every table that has been synced.
+func readWatermarks(ctx context.Context, db *sql.DB) ([]Watermark, error) {
+	rows, err := db.QueryContext(ctx, selectWatermarksStatement+` ORDER BY "table_name"`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watermarks := []Watermark{}
+	for rows.Next() {
+		watermark, err := scanWatermark(rows)
+		if err != nil {
+			return nil, err
+		}
+		watermarks = append(watermarks, *watermark)
+	}
+	return watermarks, rows.Err()
+}
+
+// scanWatermark reads a watermark from the result of a select statement.
+func scanWatermark(row scanner) (*Watermark, error) {
+	var watermark Watermark
+	var syncedAt string
+	err := row.Scan(
+		&watermark.Table,
+		&watermark.Columns,
+		&watermark.DeltaLink,
+		&syncedAt,
+		&watermark.Upserted,
+		&watermark.Deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	watermark.SyncedAt, err = time.Parse(time.RFC3339Nano, syncedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &watermark, nil
+}
+
+// writeWatermark saves the watermark of a table within tx, so that it is
+// committed together with the changes it describes.
+func writeWatermark(ctx context.Context, tx *sql.Tx, watermark Watermark) error {
+	_, err := tx.ExecContext(ctx, upsertWatermarkStatement,
+		watermark.Table,
+		watermark.Columns,
+		watermark.DeltaLink,
+		watermark.SyncedAt.Format(time.RFC3339Nano),
+		watermark.Upserted,
+		watermark.Deleted)
+	return err
+}
+
+// deleteWatermarks removes the watermarks of the given tables.
+func deleteWatermarks(ctx context.Context, db *sql.DB, tableNames []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, tableName := range tableNames {
+		_, err := tx.ExecContext(ctx, deleteWatermarkStatement, tableName)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
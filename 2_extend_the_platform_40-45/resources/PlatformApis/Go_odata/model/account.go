@@ -3,6 +3,9 @@
 package model
 
 import (
+	"encoding/json"
+
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
 	"github.com/turnerbenjamin/go_odata/view"
 )
 
@@ -18,14 +21,66 @@ type Account struct {
 
 	// City is the city of the account's primary address
 	City string `json:"address1_city,omitempty"`
+
+	// Industry is the account's industry, a choice column
+	Industry *Choice `json:"industrycode,omitempty"`
+
+	// Status is the account's status, either active or inactive
+	Status *Choice `json:"statecode,omitempty"`
+
+	// PrimaryContact is the account's primary contact. It is only populated
+	// when the primarycontactid navigation property is expanded, and is never
+	// sent to the API.
+	PrimaryContact *Contact `json:"primarycontactid,omitempty"`
+}
+
+// MarshalJSON serialises the account's own columns, omitting expanded
+// navigation properties so that they are not sent when the account is
+// created or updated.
+func (a *Account) MarshalJSON() ([]byte, error) {
+	// account has the same fields as Account but no methods, which prevents
+	// MarshalJSON from recursing.
+	type account Account
+	columns := account(*a)
+	columns.PrimaryContact = nil
+	return json.Marshal(columns)
+}
+
+// UnmarshalJSON decodes a retrieved account, reading the labels of its choice
+// columns from their formatted value annotations.
+func (a *Account) UnmarshalJSON(data []byte) error {
+	// account has the same fields as Account but no methods, which prevents
+	// UnmarshalJSON from recursing.
+	type account Account
+	var columns account
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return err
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return err
+	}
+	if columns.Industry != nil {
+		columns.Industry.Label = FormattedValue(properties, logicalNames.ColumnAccountIndustry)
+	}
+	if columns.Status != nil {
+		columns.Status.Label = FormattedValue(properties, logicalNames.ColumnAccountStatus)
+	}
+
+	*a = Account(columns)
+	return nil
 }
 
 // AccountListColumns returns a slice of ListColumn configurations
 // for displaying Account entities in a formatted list.
 //
 // The returned columns include:
-// - Name: The account's name
-// - City: The account's primary address city
+// - Name: The account's name (sortable)
+// - City: The account's primary address city (sortable)
+// - Industry: The label of the account's industry (sortable)
+// - Status: The label of the account's status (sortable)
+// - Primary contact: The name of the account's primary contact, if expanded
 //
 // Returns:
 //   - A slice of ListColumn objects for Account entities
@@ -34,15 +89,44 @@ func AccountListColumns() ([]view.ListColumn[*Account], error) {
 	getName := func(a *Account) string {
 		return a.Name
 	}
-	nameColumn, err := view.NewListColumn("Name", getName)
+	nameColumn, err := view.NewSortableListColumn(
+		"Name", logicalNames.ColumnAccountName, getName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cityCol, err := view.NewSortableListColumn(
+		"City", logicalNames.ColumnAccountCity, func(a *Account) string {
+			return a.City
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	industryCol, err := view.NewSortableListColumn(
+		"Industry", logicalNames.ColumnAccountIndustry, func(a *Account) string {
+			return a.Industry.String()
+		})
+	if err != nil {
+		return nil, err
+	}
 
+	statusCol, err := view.NewSortableListColumn(
+		"Status", logicalNames.ColumnAccountStatus, func(a *Account) string {
+			return a.Status.String()
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	cityCol, err := view.NewListColumn("City", func(a *Account) string {
-		return a.City
-	})
+	primaryContactCol, err := view.NewListColumn(
+		"Primary contact", func(a *Account) string {
+			if a.PrimaryContact == nil {
+				return ""
+			}
+			return a.PrimaryContact.Label()
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +134,9 @@ func AccountListColumns() ([]view.ListColumn[*Account], error) {
 	return []view.ListColumn[*Account]{
 		nameColumn,
 		cityCol,
+		industryCol,
+		statusCol,
+		primaryContactCol,
 	}, nil
 }
 
@@ -0,0 +1,131 @@
+// Package model provides data structures for working with Dataverse OData
+// API responses.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// multiSelectSeparator separates the values of a multi-select choice column,
+// which the Web API represents as a string, e.g. "1,3".
+const multiSelectSeparator = ","
+
+// Choice is the value of a choice (option set) column, such as industrycode
+// or statecode. It is serialised as its integer value. The label is read from
+// the column's formatted value annotation and is never sent to the API.
+type Choice struct {
+	// Value is the integer stored for the selected option
+	Value int
+
+	// Label is the option's label as displayed to users
+	Label string
+
+	// Cleared marks a choice whose value has been removed. It is serialised
+	// as null, so that an update clears the column
+	Cleared bool
+}
+
+// MarshalJSON serialises the choice as its integer value, or as null if it has
+// been cleared.
+func (c Choice) MarshalJSON() ([]byte, error) {
+	if c.Cleared {
+		return []byte("null"), nil
+	}
+	return json.Marshal(c.Value)
+}
+
+// UnmarshalJSON reads the choice's integer value.
+func (c *Choice) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &c.Value)
+}
+
+// String returns the choice's label, or its value if the label is not known.
+// Returns an empty string if the choice is not set or has been cleared.
+func (c *Choice) String() string {
+	if c == nil || c.Cleared {
+		return ""
+	}
+	if c.Label != "" {
+		return c.Label
+	}
+	return strconv.Itoa(c.Value)
+}
+
+// MultiChoice is the value of a multi-select choice column. It is serialised
+// as the comma separated string of its values used by the Web API.
+type MultiChoice struct {
+	// Values holds the integers stored for the selected options
+	Values []int
+
+	// Label is the labels of the selected options as displayed to users,
+	// e.g. "Email; Phone"
+	Label string
+}
+
+// MarshalJSON serialises the selected values as a comma separated string.
+func (c MultiChoice) MarshalJSON() ([]byte, error) {
+	values := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		values[i] = strconv.Itoa(v)
+	}
+	return json.Marshal(strings.Join(values, multiSelectSeparator))
+}
+
+// UnmarshalJSON reads the selected values from a comma separated string.
+func (c *MultiChoice) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	values, err := ParseMultiChoiceValues(s)
+	if err != nil {
+		return err
+	}
+	c.Values = values
+	return nil
+}
+
+// String returns the labels of the selected options, or their values if the
+// labels are not known.
+func (c *MultiChoice) String() string {
+	if c == nil {
+		return ""
+	}
+	if c.Label != "" {
+		return c.Label
+	}
+	values, _ := json.Marshal(c)
+	return strings.Trim(string(values), `"`)
+}
+
+// ParseMultiChoiceValues parses the comma separated values of a multi-select
+// choice column.
+func ParseMultiChoiceValues(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, multiSelectSeparator)
+	values := make([]int, len(parts))
+	for i, p := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid choice value %q: %w", p, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// FormattedValue returns the formatted value annotation of the given property
+// of a retrieved record, or an empty string if it was not returned.
+// properties holds every property of the record, keyed by name.
+func FormattedValue(properties map[string]json.RawMessage, property string) string {
+	var value string
+	if raw, ok := properties[property+annotationSeparator+AnnotationFormattedValue]; ok {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return value
+}
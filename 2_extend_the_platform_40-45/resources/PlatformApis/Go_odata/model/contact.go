@@ -3,8 +3,10 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
 	"github.com/turnerbenjamin/go_odata/view"
 )
 
@@ -23,14 +25,58 @@ type Contact struct {
 
 	// Email is the contact's primary email address
 	Email string `json:"emailaddress1,omitempty"`
+
+	// ParentCustomer is the account or contact that is the contact's parent
+	// customer, read from the _parentcustomerid_value column. It is only sent
+	// to the API when its EntitySet and NavigationProperty are set, e.g.
+	// "accounts" and "parentcustomerid_account".
+	ParentCustomer *Lookup `json:"-"`
+}
+
+// UnmarshalJSON decodes a retrieved contact, reading the parent customer
+// lookup and its annotations.
+func (c *Contact) UnmarshalJSON(data []byte) error {
+	// contact has the same fields as Contact but no methods, which prevents
+	// UnmarshalJSON from recursing.
+	type contact Contact
+	var columns contact
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return err
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return err
+	}
+	parentCustomer, err := LookupFromProperties(
+		properties, LookupValueProperty(logicalNames.ColumnContactParentCustomerLookup))
+	if err != nil {
+		return err
+	}
+
+	*c = Contact(columns)
+	c.ParentCustomer = parentCustomer
+	return nil
+}
+
+// MarshalJSON serialises the contact's columns, binding the parent customer
+// if it has been set.
+func (c *Contact) MarshalJSON() ([]byte, error) {
+	type contact Contact
+	data, err := json.Marshal(contact(*c))
+	if err != nil {
+		return nil, err
+	}
+	return withLookupBinds(data, c.ParentCustomer)
 }
 
 // ContactListColumns returns a slice of ListColumn configurations for
 // displaying Contact entities in a formatted list.
 //
-// The returned columns include:
+// The returned columns are sortable and include:
 // - Name: A formatted string combining first and last name
 // - Email: The contact's email address
+// - Company: The name of the contact's parent customer
 //
 // Returns:
 //   - A slice of ListColumn objects for Contact entities
@@ -40,14 +86,24 @@ func ContactListColumns() ([]view.ListColumn[*Contact], error) {
 		return fmt.Sprintf("%s %s", c.FirstName, c.LastName)
 	}
 
-	nameColumn, err := view.NewListColumn("Name", getName)
+	nameColumn, err := view.NewSortableListColumn(
+		"Name", logicalNames.ColumnContactFullName, getName)
+	if err != nil {
+		return nil, err
+	}
+
+	emailCol, err := view.NewSortableListColumn(
+		"Email", logicalNames.ColumnContactEmail, func(a *Contact) string {
+			return a.Email
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	emailCol, err := view.NewListColumn("Email", func(a *Contact) string {
-		return a.Email
-	})
+	companyCol, err := view.NewListColumn(
+		"Company", func(c *Contact) string {
+			return c.ParentCustomer.Label()
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +111,7 @@ func ContactListColumns() ([]view.ListColumn[*Contact], error) {
 	return []view.ListColumn[*Contact]{
 		nameColumn,
 		emailCol,
+		companyCol,
 	}, nil
 }
 
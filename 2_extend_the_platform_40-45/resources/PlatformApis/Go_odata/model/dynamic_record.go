@@ -0,0 +1,210 @@
+// Package model provides data structures for working with Dataverse OData
+// API responses.
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// annotationSeparator separates a property name from an annotation name,
+// e.g. "@odata.etag" or "name@OData.Community.Display.V1.FormattedValue".
+const annotationSeparator = "@"
+
+// DynamicRecord represents a row of any Dataverse table. Column values are
+// held in a map keyed by logical name, so the record can be used with tables
+// that have no dedicated model type. It implements the view.Entity interface
+// using the table's primary id and primary name columns.
+//
+// A DynamicRecord tracks which columns have been set since it was created or
+// retrieved, and only those columns are included when it is serialised. This
+// allows a retrieved record to be modified and sent in an update without
+// overwriting columns that were not changed.
+type DynamicRecord struct {
+	// primaryIdAttribute is the logical name of the table's primary key
+	primaryIdAttribute string
+
+	// primaryNameAttribute is the logical name of the table's primary name
+	// column
+	primaryNameAttribute string
+
+	// values holds the column values keyed by logical name
+	values map[string]any
+
+	// changed holds the logical names of columns set since the record was
+	// created or retrieved
+	changed map[string]struct{}
+
+	// formatted holds the formatted values of retrieved columns keyed by
+	// property name, e.g. the label of a choice or the name of the record
+	// referenced by a lookup
+	formatted map[string]string
+}
+
+// NewDynamicRecord creates an empty record for the table with the given
+// primary id and primary name columns.
+func NewDynamicRecord(primaryIdAttribute, primaryNameAttribute string) *DynamicRecord {
+	return &DynamicRecord{
+		primaryIdAttribute:   primaryIdAttribute,
+		primaryNameAttribute: primaryNameAttribute,
+		values:               make(map[string]any),
+		changed:              make(map[string]struct{}),
+		formatted:            make(map[string]string),
+	}
+}
+
+// NewDynamicRecordFor creates an empty record for the table described by
+// definition.
+func NewDynamicRecordFor(definition EntityDefinition) *DynamicRecord {
+	return NewDynamicRecord(definition.PrimaryIdAttribute, definition.PrimaryNameAttribute)
+}
+
+// DynamicRecordListColumns returns a sortable ListColumn for each of the
+// given columns, for displaying DynamicRecord entities in a formatted list.
+//
+// Returns:
+//   - A slice of ListColumn objects for DynamicRecord entities
+//   - An error if column creation fails
+func DynamicRecordListColumns(attributes []AttributeDefinition) ([]view.ListColumn[*DynamicRecord], error) {
+	columns := make([]view.ListColumn[*DynamicRecord], 0, len(attributes))
+	for _, a := range attributes {
+		logicalName := a.LogicalName
+		column, err := view.NewSortableListColumn(
+			a.Label(), logicalName, func(r *DynamicRecord) string {
+				return r.FormattedString(logicalName)
+			})
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Get returns the value of the column with the given logical name and
+// whether the record holds a value for it. Numbers are returned as
+// json.Number.
+func (r *DynamicRecord) Get(logicalName string) (any, bool) {
+	value, ok := r.values[logicalName]
+	return value, ok
+}
+
+// Values returns a copy of the record's column values keyed by logical name.
+// Numbers are returned as json.Number.
+func (r *DynamicRecord) Values() map[string]any {
+	values := make(map[string]any, len(r.values))
+	for logicalName, value := range r.values {
+		values[logicalName] = value
+	}
+	return values
+}
+
+// GetString returns the value of the column with the given logical name
+// formatted as a string, or an empty string if the record holds no value for
+// it.
+func (r *DynamicRecord) GetString(logicalName string) string {
+	switch v := r.values[logicalName].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FormattedString returns the value of the column with the given logical
+// name as displayed to users, such as the label of a choice, a formatted date
+// or amount, or the name of the record referenced by a lookup. If no
+// formatted value was retrieved, the value is returned as by GetString.
+func (r *DynamicRecord) FormattedString(logicalName string) string {
+	if _, changed := r.changed[logicalName]; !changed {
+		if formatted, ok := r.formatted[logicalName]; ok {
+			return formatted
+		}
+		if formatted, ok := r.formatted[LookupValueProperty(logicalName)]; ok {
+			return formatted
+		}
+	}
+	return r.GetString(logicalName)
+}
+
+// Set sets the value of the column with the given logical name and marks it
+// as changed. Setting a column to its current value has no effect, and
+// setting an empty string on a column without a value is ignored.
+func (r *DynamicRecord) Set(logicalName string, value any) {
+	if s, ok := value.(string); ok && s == r.GetString(logicalName) {
+		return
+	}
+	if current, ok := r.values[logicalName]; ok && reflect.DeepEqual(current, value) {
+		return
+	}
+	r.values[logicalName] = value
+	r.changed[logicalName] = struct{}{}
+}
+
+// ID implements the view.Entity interface by returning the value of the
+// primary id column.
+func (r *DynamicRecord) ID() string {
+	return r.GetString(r.primaryIdAttribute)
+}
+
+// Label implements the view.Entity interface by returning the value of the
+// primary name column, or the ID if the table has no primary name.
+func (r *DynamicRecord) Label() string {
+	if r.primaryNameAttribute == "" {
+		return r.ID()
+	}
+	return r.GetString(r.primaryNameAttribute)
+}
+
+// MarshalJSON serialises the columns that have been set since the record was
+// created or retrieved.
+func (r *DynamicRecord) MarshalJSON() ([]byte, error) {
+	changes := make(map[string]any, len(r.changed))
+	for logicalName := range r.changed {
+		changes[logicalName] = r.values[logicalName]
+	}
+	return json.Marshal(changes)
+}
+
+// UnmarshalJSON replaces the record's values with the columns in data and
+// clears the set of changed columns. Formatted value annotations are kept for
+// FormattedString, other annotations are ignored, and numbers are decoded as
+// json.Number to preserve their precision.
+func (r *DynamicRecord) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	r.values = make(map[string]any, len(raw))
+	r.changed = make(map[string]struct{})
+	r.formatted = make(map[string]string)
+	for key, value := range raw {
+		if property, ok := strings.CutSuffix(key, annotationSeparator+AnnotationFormattedValue); ok {
+			if s, ok := value.(string); ok {
+				r.formatted[property] = s
+			}
+			continue
+		}
+		if strings.Contains(key, annotationSeparator) {
+			continue
+		}
+		r.values[key] = value
+	}
+	return nil
+}
@@ -2,6 +2,15 @@
 // API responses.
 package model
 
+import (
+	"encoding/json"
+	"strings"
+)
+
+// annotationPrefix marks instance annotations within an OData error object,
+// e.g. "@Microsoft.PowerApps.CDS.ErrorDetails.OperationStatus".
+const annotationPrefix = "@"
+
 // ErrorResponse represents a standardized error response from the Dataverse
 // OData API.
 // This structure follows the OData v4 error response format, where error
@@ -9,13 +18,58 @@ package model
 type ErrorResponse struct {
 	// Error contains the details of the error returned by the API.
 	// This matches the OData v4 error format specification.
-	Error struct {
-		// Code is the error code returned by the Dataverse API.
-		// This typically represents the type or category of the error.
-		Code string `json:"code"`
-
-		// Message contains a human-readable description of the error.
-		// This provides more detailed information about what went wrong.
-		Message string `json:"message"`
-	} `json:"error"`
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail contains the details of an OData error.
+type ErrorDetail struct {
+	// Code is the error code returned by the Dataverse API.
+	// This typically represents the type or category of the error, e.g.
+	// "0x80040217" when a record does not exist.
+	Code string `json:"code"`
+
+	// Message contains a human-readable description of the error.
+	// This provides more detailed information about what went wrong.
+	Message string `json:"message"`
+
+	// Annotations contains the instance annotations included with the error,
+	// keyed by annotation name without the leading "@", e.g.
+	// "Microsoft.PowerApps.CDS.HelpLink". String values are stored as is;
+	// other values are stored as raw JSON.
+	Annotations map[string]string `json:"-"`
+}
+
+// UnmarshalJSON decodes the error code and message, and collects any
+// instance annotations into Annotations.
+func (d *ErrorDetail) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = ErrorDetail{}
+	for key, value := range raw {
+		switch {
+		case key == "code":
+			if err := json.Unmarshal(value, &d.Code); err != nil {
+				return err
+			}
+		case key == "message":
+			if err := json.Unmarshal(value, &d.Message); err != nil {
+				return err
+			}
+		case strings.HasPrefix(key, annotationPrefix):
+			if d.Annotations == nil {
+				d.Annotations = map[string]string{}
+			}
+			name := strings.TrimPrefix(key, annotationPrefix)
+			var s string
+			if err := json.Unmarshal(value, &s); err == nil {
+				d.Annotations[name] = s
+			} else {
+				d.Annotations[name] = string(value)
+			}
+		}
+	}
+	return nil
 }
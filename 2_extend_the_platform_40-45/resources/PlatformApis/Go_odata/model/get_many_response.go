@@ -11,14 +11,20 @@ package model
 // maintaining the common OData response structure.
 //
 // This structure handles the standard OData collection response format which
-// includes the array of entities in the "value" property and an optional next
-// link for pagination.
+// includes the array of entities in the "value" property, an optional next
+// link for pagination and, when tracking changes, a delta link.
 type GetManyResponse[T any] struct {
 	// Next contains the URL to retrieve the next page of results.
 	// It corresponds to the "@odata.nextLink" property in the OData response.
 	// This field will be empty when there are no more pages to retrieve.
 	Next string `json:"@odata.nextLink"`
 
+	// DeltaLink contains the URL to retrieve the changes made since this
+	// response, when change tracking was requested.
+	// It corresponds to the "@odata.deltaLink" property in the OData
+	// response and is only present on the last page of results.
+	DeltaLink string `json:"@odata.deltaLink,omitempty"`
+
 	// Data contains the collection of entities returned by the API.
 	// It maps to the "value" property in the OData response.
 	Data []T `json:"value"`
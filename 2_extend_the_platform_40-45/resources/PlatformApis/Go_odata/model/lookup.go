@@ -0,0 +1,160 @@
+// Package model provides data structures for working with Dataverse OData
+// API responses.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Instance annotations returned with column values when requested with the
+// Prefer: odata.include-annotations header.
+const (
+	// AnnotationFormattedValue gives the value of a column as displayed to
+	// users, e.g. the primary name of the record referenced by a lookup
+	AnnotationFormattedValue = "OData.Community.Display.V1.FormattedValue"
+
+	// AnnotationLookupLogicalName gives the logical name of the table
+	// referenced by a lookup
+	AnnotationLookupLogicalName = "Microsoft.Dynamics.CRM.lookuplogicalname"
+
+	// AnnotationAssociatedNavigationProperty gives the single-valued
+	// navigation property used to set a lookup, which differs for each
+	// target table of a polymorphic lookup such as parentcustomerid
+	AnnotationAssociatedNavigationProperty = "Microsoft.Dynamics.CRM.associatednavigationproperty"
+)
+
+// annotationODataBind is the suffix of a navigation property name used to
+// set a lookup, e.g. "parentcustomerid_account@odata.bind".
+const annotationODataBind = "@odata.bind"
+
+// Lookup is the value of a lookup column: a reference to a record in another
+// table.
+//
+// When retrieved, lookups are returned as a "_<column>_value" property holding
+// the referenced record's GUID, with annotations describing the record. When
+// created or updated, lookups are set by binding a single-valued navigation
+// property to the record's URL, e.g.
+// {"parentcustomerid_account@odata.bind": "/accounts(guid)"}.
+type Lookup struct {
+	// ID is the GUID of the referenced record
+	ID string
+
+	// LogicalName is the logical name of the referenced record's table,
+	// e.g. "account"
+	LogicalName string
+
+	// Name is the primary name of the referenced record
+	Name string
+
+	// NavigationProperty is the single-valued navigation property that sets
+	// the lookup, e.g. "parentcustomerid_account"
+	NavigationProperty string
+
+	// EntitySet is the entity set of the referenced record's table, e.g.
+	// "accounts". It is never populated when a lookup is retrieved, and a
+	// lookup is only sent to the API when it is set, so a retrieved lookup
+	// is not rewritten when its record is updated.
+	EntitySet string
+
+	// Cleared marks a lookup whose reference has been removed. Its navigation
+	// property is bound to null, so that an update clears the column
+	Cleared bool
+}
+
+// Label returns the primary name of the referenced record, or its GUID if the
+// name is not known.
+// Returns an empty string if the lookup is not set or has been cleared.
+func (l *Lookup) Label() string {
+	if l == nil || l.Cleared {
+		return ""
+	}
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.ID
+}
+
+// bind returns the property and value that set the lookup when a record is
+// created or updated. The value is nil if the lookup has been cleared. ok is
+// false if the lookup is nil or was not set or cleared with a navigation
+// property.
+func (l *Lookup) bind() (property string, value *string, ok bool) {
+	if l == nil || l.NavigationProperty == "" {
+		return "", nil, false
+	}
+	property = l.NavigationProperty + annotationODataBind
+	if l.Cleared {
+		return property, nil, true
+	}
+	if l.ID == "" || l.EntitySet == "" {
+		return "", nil, false
+	}
+	url := fmt.Sprintf("/%s(%s)", l.EntitySet, l.ID)
+	return property, &url, true
+}
+
+// LookupValueProperty returns the name of the property holding the GUID of a
+// retrieved lookup column, e.g. "_parentcustomerid_value" for
+// "parentcustomerid".
+func LookupValueProperty(column string) string {
+	return "_" + column + "_value"
+}
+
+// LookupFromProperties reads the lookup held in the given "_<column>_value"
+// property of a retrieved record, together with its annotations. properties
+// holds every property of the record, keyed by name.
+// Returns nil if the property is missing or null.
+func LookupFromProperties(properties map[string]json.RawMessage, valueProperty string) (*Lookup, error) {
+	var id *string
+	if raw, ok := properties[valueProperty]; ok {
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return nil, fmt.Errorf("invalid lookup %s: %w", valueProperty, err)
+		}
+	}
+	if id == nil || *id == "" {
+		return nil, nil
+	}
+
+	annotation := func(name string) string {
+		var value string
+		if raw, ok := properties[valueProperty+annotationSeparator+name]; ok {
+			_ = json.Unmarshal(raw, &value)
+		}
+		return value
+	}
+
+	return &Lookup{
+		ID:                 *id,
+		LogicalName:        annotation(AnnotationLookupLogicalName),
+		Name:               annotation(AnnotationFormattedValue),
+		NavigationProperty: annotation(AnnotationAssociatedNavigationProperty),
+	}, nil
+}
+
+// withLookupBinds adds the @odata.bind property of each set or cleared lookup
+// to the serialised JSON object data.
+func withLookupBinds(data []byte, lookups ...*Lookup) ([]byte, error) {
+	binds := map[string]*string{}
+	for _, l := range lookups {
+		if property, value, ok := l.bind(); ok {
+			binds[property] = value
+		}
+	}
+	if len(binds) == 0 {
+		return data, nil
+	}
+
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, err
+	}
+	for property, value := range binds {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		properties[property] = raw
+	}
+	return json.Marshal(properties)
+}
@@ -0,0 +1,304 @@
+// Package model provides data structures for working with Dataverse OData
+// API responses.
+package model
+
+import "github.com/turnerbenjamin/go_odata/view"
+
+// Attribute types reported in AttributeDefinition.AttributeType.
+const (
+	AttributeTypeString   = "String"
+	AttributeTypeMemo     = "Memo"
+	AttributeTypeInteger  = "Integer"
+	AttributeTypeBigInt   = "BigInt"
+	AttributeTypeDecimal  = "Decimal"
+	AttributeTypeDouble   = "Double"
+	AttributeTypeMoney    = "Money"
+	AttributeTypeBoolean  = "Boolean"
+	AttributeTypeDateTime = "DateTime"
+	AttributeTypeLookup   = "Lookup"
+	AttributeTypeCustomer = "Customer"
+	AttributeTypeOwner    = "Owner"
+	AttributeTypePicklist = "Picklist"
+	AttributeTypeState    = "State"
+	AttributeTypeStatus   = "Status"
+	AttributeTypeVirtual  = "Virtual"
+)
+
+// AttributeTypeNameMultiSelectPicklist is the AttributeTypeName of a
+// multi-select choice column, whose AttributeType is "Virtual".
+const AttributeTypeNameMultiSelectPicklist = "MultiSelectPicklistType"
+
+// Derived attribute metadata types, used to cast an attribute in a metadata
+// request to retrieve the properties specific to its type.
+const (
+	MetadataTypePicklist            = "Microsoft.Dynamics.CRM.PicklistAttributeMetadata"
+	MetadataTypeMultiSelectPicklist = "Microsoft.Dynamics.CRM.MultiSelectPicklistAttributeMetadata"
+	MetadataTypeState               = "Microsoft.Dynamics.CRM.StateAttributeMetadata"
+	MetadataTypeStatus              = "Microsoft.Dynamics.CRM.StatusAttributeMetadata"
+)
+
+// Required levels reported in RequiredLevel.Value.
+const (
+	RequiredLevelNone                = "None"
+	RequiredLevelSystemRequired      = "SystemRequired"
+	RequiredLevelApplicationRequired = "ApplicationRequired"
+	RequiredLevelRecommended         = "Recommended"
+)
+
+// LocalizedLabel is a label in a single language.
+type LocalizedLabel struct {
+	// Label is the text of the label
+	Label string `json:"Label"`
+
+	// LanguageCode is the LCID of the label's language, e.g. 1033
+	LanguageCode int `json:"LanguageCode"`
+}
+
+// Label is a localisable label, such as the display name of a table or
+// column.
+type Label struct {
+	// UserLocalizedLabel is the label in the calling user's language, or nil
+	// if the label has not been set
+	UserLocalizedLabel *LocalizedLabel `json:"UserLocalizedLabel"`
+
+	// LocalizedLabels contains the label in every provisioned language
+	LocalizedLabels []LocalizedLabel `json:"LocalizedLabels"`
+}
+
+// Text returns the label in the user's language, falling back to the first
+// localised label, or an empty string if the label has not been set.
+func (l Label) Text() string {
+	if l.UserLocalizedLabel != nil {
+		return l.UserLocalizedLabel.Label
+	}
+	if len(l.LocalizedLabels) > 0 {
+		return l.LocalizedLabels[0].Label
+	}
+	return ""
+}
+
+// RequiredLevel describes whether a column must contain a value.
+type RequiredLevel struct {
+	// Value is one of the RequiredLevel constants, e.g. "ApplicationRequired"
+	Value string `json:"Value"`
+}
+
+// AttributeTypeName identifies the type of a column more precisely than
+// AttributeType.
+type AttributeTypeName struct {
+	// Value is the name of the type, e.g. "MultiSelectPicklistType"
+	Value string `json:"Value"`
+}
+
+// OptionMetadata describes one of the options of a choice column.
+type OptionMetadata struct {
+	// Value is the integer stored for the option
+	Value int `json:"Value"`
+
+	// Label is the option's display label
+	Label Label `json:"Label"`
+}
+
+// OptionSetMetadata describes the options of a choice column.
+type OptionSetMetadata struct {
+	// Options contains the available options in display order
+	Options []OptionMetadata `json:"Options"`
+}
+
+// ChoiceAttributeMetadata contains the option sets of a choice column, as
+// returned when an attribute is cast to one of the MetadataType constants.
+// A column uses either a local OptionSet or a GlobalOptionSet shared with
+// other columns.
+type ChoiceAttributeMetadata struct {
+	// LogicalName is the column's logical name
+	LogicalName string `json:"LogicalName"`
+
+	// OptionSet is the column's local option set, if any
+	OptionSet *OptionSetMetadata `json:"OptionSet"`
+
+	// GlobalOptionSet is the global option set used by the column, if any
+	GlobalOptionSet *OptionSetMetadata `json:"GlobalOptionSet"`
+}
+
+// Options returns the options of the column's local option set, or of its
+// global option set if it has no local options.
+func (m ChoiceAttributeMetadata) Options() []OptionMetadata {
+	if m.OptionSet != nil && len(m.OptionSet.Options) > 0 {
+		return m.OptionSet.Options
+	}
+	if m.GlobalOptionSet != nil {
+		return m.GlobalOptionSet.Options
+	}
+	return nil
+}
+
+// EntityDefinition describes a Dataverse table, as returned by the
+// EntityDefinitions metadata endpoint. It implements the view.Entity
+// interface.
+type EntityDefinition struct {
+	// MetadataId is the unique identifier of the table definition
+	MetadataId string `json:"MetadataId"`
+
+	// LogicalName is the table's logical name, e.g. "account"
+	LogicalName string `json:"LogicalName"`
+
+	// EntitySetName is the name of the table's Web API resource, e.g.
+	// "accounts"
+	EntitySetName string `json:"EntitySetName"`
+
+	// PrimaryIdAttribute is the logical name of the table's primary key
+	// column, e.g. "accountid"
+	PrimaryIdAttribute string `json:"PrimaryIdAttribute"`
+
+	// PrimaryNameAttribute is the logical name of the table's primary name
+	// column, e.g. "name". It is empty for tables without a primary name.
+	PrimaryNameAttribute string `json:"PrimaryNameAttribute"`
+
+	// DisplayName is the table's singular display name
+	DisplayName Label `json:"DisplayName"`
+
+	// DisplayCollectionName is the table's plural display name
+	DisplayCollectionName Label `json:"DisplayCollectionName"`
+}
+
+// ID implements the view.Entity interface by returning the table definition's
+// metadata identifier.
+func (d *EntityDefinition) ID() string {
+	return d.MetadataId
+}
+
+// Label implements the view.Entity interface by returning the table's display
+// name, or its logical name if it has no display name.
+func (d *EntityDefinition) Label() string {
+	if text := d.DisplayName.Text(); text != "" {
+		return text
+	}
+	return d.LogicalName
+}
+
+// AttributeDefinition describes a column of a Dataverse table, as returned by
+// the Attributes collection of an EntityDefinition.
+type AttributeDefinition struct {
+	// MetadataId is the unique identifier of the column definition
+	MetadataId string `json:"MetadataId"`
+
+	// LogicalName is the column's logical name, e.g. "address1_city"
+	LogicalName string `json:"LogicalName"`
+
+	// AttributeType is one of the AttributeType constants, e.g. "String"
+	AttributeType string `json:"AttributeType"`
+
+	// AttributeTypeName identifies the column's type more precisely, e.g.
+	// distinguishing multi-select choice columns from other virtual columns
+	AttributeTypeName AttributeTypeName `json:"AttributeTypeName"`
+
+	// DisplayName is the column's display name
+	DisplayName Label `json:"DisplayName"`
+
+	// RequiredLevel describes whether the column must contain a value
+	RequiredLevel RequiredLevel `json:"RequiredLevel"`
+
+	// IsValidForCreate is true if the column can be set when a row is
+	// created
+	IsValidForCreate bool `json:"IsValidForCreate"`
+
+	// IsValidForUpdate is true if the column can be set when a row is
+	// updated
+	IsValidForUpdate bool `json:"IsValidForUpdate"`
+
+	// IsValidForRead is true if the column can be retrieved
+	IsValidForRead bool `json:"IsValidForRead"`
+}
+
+// Label returns the column's display name, or its logical name if it has no
+// display name.
+func (d AttributeDefinition) Label() string {
+	if text := d.DisplayName.Text(); text != "" {
+		return text
+	}
+	return d.LogicalName
+}
+
+// IsRequired returns true if the column must contain a value.
+func (d AttributeDefinition) IsRequired() bool {
+	return d.RequiredLevel.Value == RequiredLevelSystemRequired ||
+		d.RequiredLevel.Value == RequiredLevelApplicationRequired
+}
+
+// IsText returns true if the column holds a single or multiple line string.
+func (d AttributeDefinition) IsText() bool {
+	return d.AttributeType == AttributeTypeString ||
+		d.AttributeType == AttributeTypeMemo
+}
+
+// IsLookup returns true if the column references a row of another table,
+// including customer and owner columns. The referenced row's ID is retrieved
+// through the property returned by LookupValueProperty.
+func (d AttributeDefinition) IsLookup() bool {
+	return d.AttributeType == AttributeTypeLookup ||
+		d.AttributeType == AttributeTypeCustomer ||
+		d.AttributeType == AttributeTypeOwner
+}
+
+// IsMultiSelectChoice returns true if the column holds any number of options
+// from a choice.
+func (d AttributeDefinition) IsMultiSelectChoice() bool {
+	return d.AttributeTypeName.Value == AttributeTypeNameMultiSelectPicklist
+}
+
+// IsChoice returns true if the column holds one or, for multi-select choice
+// columns, more options from a choice, including status columns.
+func (d AttributeDefinition) IsChoice() bool {
+	return d.ChoiceMetadataType() != ""
+}
+
+// ChoiceMetadataType returns the MetadataType constant used to retrieve the
+// options of a choice column, or an empty string if the column is not a
+// choice.
+func (d AttributeDefinition) ChoiceMetadataType() string {
+	switch {
+	case d.IsMultiSelectChoice():
+		return MetadataTypeMultiSelectPicklist
+	case d.AttributeType == AttributeTypePicklist:
+		return MetadataTypePicklist
+	case d.AttributeType == AttributeTypeState:
+		return MetadataTypeState
+	case d.AttributeType == AttributeTypeStatus:
+		return MetadataTypeStatus
+	default:
+		return ""
+	}
+}
+
+// EntityDefinitionListColumns returns a slice of ListColumn configurations
+// for displaying EntityDefinition entities in a formatted list.
+//
+// The returned columns include:
+// - Display name: The table's display name
+// - Logical name: The table's logical name
+//
+// Returns:
+//   - A slice of ListColumn objects for EntityDefinition entities
+//   - An error if column creation fails
+func EntityDefinitionListColumns() ([]view.ListColumn[*EntityDefinition], error) {
+	displayNameCol, err := view.NewListColumn(
+		"Display name", func(d *EntityDefinition) string {
+			return d.Label()
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	logicalNameCol, err := view.NewListColumn(
+		"Logical name", func(d *EntityDefinition) string {
+			return d.LogicalName
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return []view.ListColumn[*EntityDefinition]{
+		displayNameCol,
+		logicalNameCol,
+	}, nil
+}
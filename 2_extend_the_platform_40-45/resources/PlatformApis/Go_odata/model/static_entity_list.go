@@ -0,0 +1,77 @@
+// Package model provides data structures for working with Dataverse OData
+// API responses.
+package model
+
+import "github.com/turnerbenjamin/go_odata/view"
+
+// staticEntityList implements the view.EntityList interface over entities
+// that are already held in memory, dividing them into pages of a fixed size.
+type staticEntityList[T view.Entity] struct {
+	// entities contains every entity in the collection
+	entities []T
+
+	// pageSize is the maximum number of entities on each page
+	pageSize int
+
+	// start is the index of the first entity on the current page
+	start int
+}
+
+// CreateStaticEntityList creates a new EntityList over entities held in
+// memory. It is useful for data that cannot be paged by the API, such as
+// table definitions.
+//
+// Parameters:
+//   - entities: The entities in the collection
+//   - pageSize: The maximum number of entities on each page. A value less
+//     than one places every entity on a single page
+//
+// Returns:
+//   - An EntityList positioned on the first page
+func CreateStaticEntityList[T view.Entity](entities []T, pageSize int) view.EntityList[T] {
+	if pageSize < 1 {
+		pageSize = len(entities)
+	}
+	return &staticEntityList[T]{
+		entities: entities,
+		pageSize: pageSize,
+	}
+}
+
+// Data returns the entities on the current page.
+func (el *staticEntityList[T]) Data() []T {
+	end := min(el.start+el.pageSize, len(el.entities))
+	return el.entities[el.start:end]
+}
+
+// HasNext returns true if there are entities after the current page.
+func (el *staticEntityList[T]) HasNext() bool {
+	return el.start+el.pageSize < len(el.entities)
+}
+
+// Next returns the next page of entities. It never returns an error.
+func (el *staticEntityList[T]) Next() (view.EntityList[T], error) {
+	return &staticEntityList[T]{
+		entities: el.entities,
+		pageSize: el.pageSize,
+		start:    el.start + el.pageSize,
+	}, nil
+}
+
+// HasPrevious returns true if there are entities before the current page.
+func (el *staticEntityList[T]) HasPrevious() bool {
+	return el.start > 0
+}
+
+// Previous returns the previous page of entities, or nil if this is the first
+// page.
+func (el *staticEntityList[T]) Previous() view.EntityList[T] {
+	if !el.HasPrevious() {
+		return nil
+	}
+	return &staticEntityList[T]{
+		entities: el.entities,
+		pageSize: el.pageSize,
+		start:    max(el.start-el.pageSize, 0),
+	}
+}
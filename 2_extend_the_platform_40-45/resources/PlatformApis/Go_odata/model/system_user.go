@@ -0,0 +1,89 @@
+// Package model provides data structures for working with Dataverse OData API
+// responses.
+package model
+
+import (
+	logicalNames "github.com/turnerbenjamin/go_odata/constants/logicalnames"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// SystemUser represents a user of a Dataverse environment, from the
+// systemuser table. It implements the view.Entity interface.
+type SystemUser struct {
+	// Id is the unique identifier for the user
+	Id string `json:"systemuserid,omitempty"`
+
+	// FullName is the user's full name
+	FullName string `json:"fullname"`
+
+	// DomainName is the user's sign in name, e.g. "jane@contoso.com"
+	DomainName string `json:"domainname"`
+
+	// ObjectID is the user's Microsoft Entra ID object ID. It is empty for
+	// users without an Entra ID identity
+	ObjectID string `json:"azureactivedirectoryobjectid,omitempty"`
+
+	// IsDisabled is true if the user cannot sign in
+	IsDisabled bool `json:"isdisabled"`
+}
+
+// SystemUserListColumns returns a slice of ListColumn configurations for
+// displaying SystemUser entities in a formatted list.
+//
+// The returned columns include:
+// - Name: The user's full name (sortable)
+// - Username: The user's sign in name (sortable)
+// - Status: Whether the user is enabled or disabled
+//
+// Returns:
+//   - A slice of ListColumn objects for SystemUser entities
+//   - An error if column creation fails
+func SystemUserListColumns() ([]view.ListColumn[*SystemUser], error) {
+	nameColumn, err := view.NewSortableListColumn(
+		"Name", logicalNames.ColumnSystemUserFullName, func(u *SystemUser) string {
+			return u.FullName
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	usernameColumn, err := view.NewSortableListColumn(
+		"Username", logicalNames.ColumnSystemUserDomainName, func(u *SystemUser) string {
+			return u.DomainName
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	statusColumn, err := view.NewListColumn(
+		"Status", func(u *SystemUser) string {
+			if u.IsDisabled {
+				return "Disabled"
+			}
+			return "Enabled"
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return []view.ListColumn[*SystemUser]{
+		nameColumn,
+		usernameColumn,
+		statusColumn,
+	}, nil
+}
+
+// ID implements the view.Entity interface by returning the user's unique
+// identifier.
+func (u *SystemUser) ID() string {
+	return u.Id
+}
+
+// Label implements the view.Entity interface by returning the user's full
+// name followed by their sign in name.
+func (u *SystemUser) Label() string {
+	if u.DomainName == "" {
+		return u.FullName
+	}
+	return u.FullName + " (" + u.DomainName + ")"
+}
@@ -0,0 +1,189 @@
+// Package msal provides authentication mechanisms for Microsoft identity
+// platform. It contains implementations of the DataverseClient interface for
+// different authentication flows.
+package msal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/public"
+)
+
+// ErrAccountNotFound is returned when an account is not in the token cache.
+var ErrAccountNotFound = errors.New("account not found in the token cache")
+
+// Account identifies a user who has signed in with a delegated client, and
+// whose tokens are held in the client's token cache.
+type Account struct {
+	// ID is the home account ID, unique across tenants
+	ID string
+
+	// Username is the user's sign in name, e.g. "admin@contoso.com"
+	Username string
+
+	// TenantID is the ID of the EntraID tenant the user signed in to
+	TenantID string
+}
+
+// String returns the account's username followed by its tenant ID.
+func (a Account) String() string {
+	return fmt.Sprintf("%s (%s)", a.Username, a.TenantID)
+}
+
+// AccountManager is implemented by delegated clients, which can hold tokens
+// for several accounts, to choose the account that tokens are acquired for.
+// By default, the first cached account is used.
+type AccountManager interface {
+	// Accounts returns the accounts in the token cache.
+	Accounts(ctx context.Context) ([]Account, error)
+
+	// RemoveAccount signs out the account with the given ID, removing its
+	// tokens from the cache.
+	// Returns ErrAccountNotFound if the account is not in the cache.
+	RemoveAccount(ctx context.Context, id string) error
+
+	// UseAccount acquires subsequent tokens for the account with the given
+	// ID, signing in again if its cached tokens have expired.
+	UseAccount(id string)
+
+	// UseNewAccount signs in with an account chosen by the user when the
+	// next token is acquired, rather than using a cached account.
+	UseNewAccount()
+
+	// ActiveAccount returns the account of the last token acquired, and
+	// false if no token has been acquired.
+	ActiveAccount() (Account, bool)
+}
+
+// accountSelection records the account chosen with UseAccount or
+// UseNewAccount. The zero value selects the first cached account.
+type accountSelection struct {
+	// id is the home account ID of the chosen account
+	id string
+	// newAccount is true if the user is to sign in with another account
+	newAccount bool
+}
+
+// Accounts returns the accounts in the client's token cache.
+func (c *delegatedClient) Accounts(ctx context.Context) ([]Account, error) {
+	accounts, err := c.client.Accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Account, len(accounts))
+	for i, account := range accounts {
+		result[i] = newAccount(account)
+	}
+	return result, nil
+}
+
+// RemoveAccount removes the account with the given ID from the client's
+// token cache. If it is the active or chosen account, the first cached
+// account is used for the next token.
+func (c *delegatedClient) RemoveAccount(ctx context.Context, id string) error {
+	account, err := c.findAccount(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = c.client.RemoveAccount(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.selection.id == id {
+		c.selection = accountSelection{}
+	}
+	if c.active != nil && c.active.ID == id {
+		c.active = nil
+	}
+	return nil
+}
+
+// UseAccount chooses the account that subsequent tokens are acquired for.
+func (c *delegatedClient) UseAccount(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.selection = accountSelection{id: id}
+}
+
+// UseNewAccount chooses to sign in with another account when the next token
+// is acquired.
+func (c *delegatedClient) UseNewAccount() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.selection = accountSelection{newAccount: true}
+}
+
+// ActiveAccount returns the account of the last token acquired.
+func (c *delegatedClient) ActiveAccount() (Account, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active == nil {
+		return Account{}, false
+	}
+	return *c.active, true
+}
+
+// selectedAccount returns the cached account to acquire a token for: the
+// chosen account, or the first cached account if none has been chosen.
+// Returns false if the user is to sign in with a new account, or if the
+// account is not cached, e.g. because another process signed it out.
+func (c *delegatedClient) selectedAccount(ctx context.Context) (public.Account, bool) {
+	c.mu.Lock()
+	selection := c.selection
+	c.mu.Unlock()
+
+	if selection.newAccount {
+		return public.Account{}, false
+	}
+	if selection.id != "" {
+		account, err := c.findAccount(ctx, selection.id)
+		return account, err == nil
+	}
+
+	accounts, err := c.client.Accounts(ctx)
+	if err != nil || len(accounts) == 0 {
+		return public.Account{}, false
+	}
+	return accounts[0], true
+}
+
+// findAccount returns the cached account with the given home account ID.
+// Returns ErrAccountNotFound if it is not cached.
+func (c *delegatedClient) findAccount(ctx context.Context, id string) (public.Account, error) {
+	accounts, err := c.client.Accounts(ctx)
+	if err != nil {
+		return public.Account{}, err
+	}
+	for _, account := range accounts {
+		if account.HomeAccountID == id {
+			return account, nil
+		}
+	}
+	return public.Account{}, ErrAccountNotFound
+}
+
+// setActive records the account a token was acquired for. Once signed in,
+// subsequent tokens are acquired for the same account.
+func (c *delegatedClient) setActive(account public.Account) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a := newAccount(account)
+	c.active = &a
+	c.selection = accountSelection{id: account.HomeAccountID}
+}
+
+// newAccount converts an MSAL account.
+func newAccount(account public.Account) Account {
+	return Account{
+		ID:       account.HomeAccountID,
+		Username: account.PreferredUsername,
+		TenantID: account.Realm,
+	}
+}
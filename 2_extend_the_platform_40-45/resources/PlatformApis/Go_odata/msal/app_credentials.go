@@ -0,0 +1,134 @@
+// Package msal provides authentication mechanisms for Microsoft identity
+// platform. It contains implementations of the DataverseClient interface for
+// different authentication flows.
+package msal
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// pfxExtensions lists the file extensions of PKCS#12 certificate files. Other
+// certificate files are read as PEM.
+var pfxExtensions = []string{".pfx", ".p12"}
+
+// ErrNoCertificateKey is returned when a certificate file does not contain a
+// private key.
+var ErrNoCertificateKey = errors.New("certificate file does not contain a private key")
+
+// CertificateOptions configures a certificate credential for application
+// authentication.
+type CertificateOptions struct {
+	// Path is the path of a PEM file, or a PFX (PKCS#12) file with a .pfx or
+	// .p12 extension, containing the certificate and its private key
+	Path string
+
+	// Password provides the password of an encrypted PEM or PFX file. It
+	// may be nil if the file is not encrypted
+	Password SecretProvider
+
+	// SendX5C sends the certificate chain (x5c) with each token request,
+	// which is required for subject name and issuer authentication
+	SendX5C bool
+}
+
+// newAppCredential creates the credential used by an application client:
+// a certificate if c.Certificate is set, otherwise a client assertion if
+// c.ClientAssertion is set, otherwise the client secret. The certificate
+// password or client secret is read with ctx so that a slow source can be
+// cancelled.
+// Returns the credential and any client options it requires.
+func newAppCredential(ctx context.Context, c ClientOptions) (confidential.Credential, []confidential.Option, error) {
+	switch {
+	case c.Certificate != nil:
+		return newCertificateCredential(ctx, *c.Certificate)
+	case c.ClientAssertion != nil:
+		return newAssertionCredential(c.ClientAssertion), nil, nil
+	case c.ClientSecret != nil:
+		secret, err := c.ClientSecret.Secret(ctx)
+		if err != nil {
+			return confidential.Credential{}, nil, fmt.Errorf("unable to read client secret: %w", err)
+		}
+		cred, err := confidential.NewCredFromSecret(secret)
+		return cred, nil, err
+	default:
+		return confidential.Credential{}, nil, ErrNoClientSecret
+	}
+}
+
+// newCertificateCredential creates a credential from a certificate file,
+// reading its password with ctx.
+func newCertificateCredential(ctx context.Context, options CertificateOptions) (confidential.Credential, []confidential.Option, error) {
+	password := ""
+	if options.Password != nil {
+		var err error
+		password, err = options.Password.Secret(ctx)
+		if err != nil {
+			return confidential.Credential{}, nil, fmt.Errorf("unable to read certificate password: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(options.Path)
+	if err != nil {
+		return confidential.Credential{}, nil, err
+	}
+
+	certs, key, err := decodeCertificate(options.Path, data, password)
+	if err != nil {
+		return confidential.Credential{}, nil, fmt.Errorf("unable to read certificate %s: %w", options.Path, err)
+	}
+
+	cred, err := confidential.NewCredFromCert(certs, key)
+	if err != nil {
+		return confidential.Credential{}, nil, err
+	}
+
+	var clientOptions []confidential.Option
+	if options.SendX5C {
+		clientOptions = append(clientOptions, confidential.WithX5C())
+	}
+	return cred, clientOptions, nil
+}
+
+// decodeCertificate decodes the certificate chain and private key in a PFX
+// or PEM file, chosen by the file's extension.
+func decodeCertificate(path string, data []byte, password string) ([]*x509.Certificate, crypto.PrivateKey, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, pfxExt := range pfxExtensions {
+		if ext == pfxExt {
+			key, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+			if err != nil {
+				return nil, nil, err
+			}
+			return append([]*x509.Certificate{cert}, caCerts...), key, nil
+		}
+	}
+
+	certs, key, err := confidential.CertFromPEM(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == nil {
+		return nil, nil, ErrNoCertificateKey
+	}
+	return certs, key, nil
+}
+
+// newAssertionCredential creates a credential that asks provider for a signed
+// client assertion each time a token is requested, so that short-lived
+// assertions such as federated workload identity tokens are always current.
+func newAssertionCredential(provider SecretProvider) confidential.Credential {
+	return confidential.NewCredFromAssertionCallback(
+		func(ctx context.Context, _ confidential.AssertionRequestOptions) (string, error) {
+			return provider.Secret(ctx)
+		})
+}
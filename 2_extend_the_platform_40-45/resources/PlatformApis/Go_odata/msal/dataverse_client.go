@@ -2,19 +2,49 @@
 // through the Microsoft Authentication Library (MSAL).
 package msal
 
+import (
+	"context"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/cache"
+)
+
 // ClientOptions contains the configuration parameters needed to establish
 // authenticated connections to Microsoft Dataverse services.
 //
-// It supports both application (client credentials) and delegated
-// (user interactive) authentication flows depending on which client
+// It supports application (client credentials) and delegated (user
+// interactive or device code) authentication flows depending on which client
 // implementation is used.
 type ClientOptions struct {
 	// ClientID is the application (client) ID registered in Azure AD
 	ClientID string
 
-	// ClientSecret is the client secret for confidential client applications
-	// This is only required for application authentication flows
-	ClientSecret string
+	// ClientSecret provides the client secret for confidential client
+	// applications. It is only required, and only asked for its secret, in
+	// application authentication flows
+	ClientSecret SecretProvider
+
+	// Certificate configures a certificate credential, used in place of
+	// the client secret in application authentication flows
+	Certificate *CertificateOptions
+
+	// ClientAssertion provides a signed client assertion, such as a
+	// federated workload identity token, used in place of the client secret
+	// in application authentication flows. It is asked for an assertion
+	// each time a token is requested
+	ClientAssertion SecretProvider
+
+	// DeviceCodeHandler shows the device code to the user in the device
+	// code flow. It is only required in device code authentication flows
+	DeviceCodeHandler DeviceCodeHandler
+
+	// BrowserSignInHandler tells the user to sign in with the browser in
+	// interactive browser flows. If nil, the browser is opened without a
+	// message
+	BrowserSignInHandler BrowserSignInHandler
+
+	// TokenCache persists acquired tokens between runs. If nil, tokens are
+	// kept in memory only
+	TokenCache TokenCache
 
 	// ResourceURL is the base URL of the Dataverse environment
 	// For example: "https://orgname.crm.dynamics.com/"
@@ -25,6 +55,32 @@ type ClientOptions struct {
 	Authority string
 }
 
+// TokenCache is an external store for the MSAL token cache, so that tokens
+// outlive the process that acquired them.
+type TokenCache interface {
+	cache.ExportReplace
+
+	// Clear deletes every stored token.
+	Clear(ctx context.Context) error
+}
+
+// SecretProvider supplies a secret, such as a client secret, from a source
+// other than plaintext configuration, e.g. an OS keyring, a mounted file, an
+// external command or a prompt.
+type SecretProvider interface {
+	// Secret returns the secret. The operation is abandoned, returning
+	// ctx.Err(), if ctx is cancelled.
+	Secret(ctx context.Context) (string, error)
+}
+
+// SecretFunc adapts a function to the SecretProvider interface.
+type SecretFunc func(ctx context.Context) (string, error)
+
+// Secret calls f.
+func (f SecretFunc) Secret(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
 // DataverseClient defines the interface for authentication providers that
 // can obtain access tokens for Microsoft Dataverse.
 //
@@ -40,4 +96,14 @@ type DataverseClient interface {
 	//   - The access token as a string
 	//   - An error if token acquisition fails
 	AcquireToken() (string, error)
+
+	// AcquireTokenContext obtains an access token like AcquireToken. The
+	// operation is abandoned, returning ctx.Err(), if ctx is cancelled
+	// before a token is acquired.
+	AcquireTokenContext(ctx context.Context) (string, error)
+
+	// SignOut removes the client's signed in accounts and clears its
+	// persistent token cache, if it has one, so that the next token request
+	// signs in again.
+	SignOut(ctx context.Context) error
 }
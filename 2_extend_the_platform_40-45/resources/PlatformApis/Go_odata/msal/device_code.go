@@ -0,0 +1,99 @@
+// Package msal provides authentication mechanisms for Microsoft identity
+// platform. It contains implementations of the DataverseClient interface for
+// different authentication flows.
+package msal
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoDeviceCodeHandler is returned when creating a device code client
+// without a handler to show the device code to the user.
+var ErrNoDeviceCodeHandler = errors.New("a device code handler is required for device code authentication")
+
+// DeviceCode holds the code the user enters on another device to sign in.
+type DeviceCode struct {
+	// UserCode is the code the user enters at the verification URL
+	UserCode string
+
+	// VerificationURL is the URL the user opens in a browser to sign in
+	VerificationURL string
+
+	// Message is the sign in instruction provided by the identity platform
+	Message string
+
+	// ExpiresOn is the time after which the code can no longer be used
+	ExpiresOn time.Time
+}
+
+// DeviceCodeHandler shows a device code to the user, then calls wait, which
+// polls the identity platform until the user has signed in, the code
+// expires or ctx is cancelled. The handler may pass wait a context that is
+// cancelled when the user chooses to stop waiting.
+// Returns the error returned by wait, or any error showing the code.
+type DeviceCodeHandler func(ctx context.Context, code DeviceCode, wait func(ctx context.Context) error) error
+
+// GetDeviceCodeService creates a new DataverseClient that uses delegated
+// authentication with the device code flow. The user signs in with a browser
+// on any device, so no browser is needed on the machine running the client,
+// e.g. in an SSH session. c.DeviceCodeHandler is called to show the code.
+//
+// Parameters:
+//   - c: ClientOptions containing required authentication parameters
+//
+// Returns:
+//   - A DataverseClient implementation
+//   - ErrNoDeviceCodeHandler if c.DeviceCodeHandler is nil, or an error if
+//     client creation fails
+func GetDeviceCodeService(c ClientOptions) (DataverseClient, error) {
+	if c.DeviceCodeHandler == nil {
+		return nil, ErrNoDeviceCodeHandler
+	}
+
+	client, err := newPublicClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delegatedClient{
+		client:            client,
+		resourceURL:       c.ResourceURL,
+		deviceCodeHandler: c.DeviceCodeHandler,
+		tokenCache:        c.TokenCache,
+	}, nil
+}
+
+// acquireTokenByDeviceCode requests a device code, passes it to the client's
+// device code handler, and waits for the user to sign in.
+// Returns the access token as a string or an error if authentication fails
+// or is cancelled.
+func (c *delegatedClient) acquireTokenByDeviceCode(ctx context.Context, scopes []string) (string, error) {
+	deviceCode, err := c.client.AcquireTokenByDeviceCode(ctx, scopes)
+	if err != nil {
+		return "", err
+	}
+
+	code := DeviceCode{
+		UserCode:        deviceCode.Result.UserCode,
+		VerificationURL: deviceCode.Result.VerificationURL,
+		Message:         deviceCode.Result.Message,
+		ExpiresOn:       deviceCode.Result.ExpiresOn,
+	}
+
+	var accessToken string
+	err = c.deviceCodeHandler(ctx, code, func(ctx context.Context) error {
+		result, err := deviceCode.AuthenticationResult(ctx)
+		if err != nil {
+			return err
+		}
+		accessToken = result.AccessToken
+		c.setActive(result.Account)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return accessToken, nil
+}
@@ -5,16 +5,23 @@ package msal
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/confidential"
 )
 
+// ErrNoClientSecret is returned when creating an application client without
+// a client secret, certificate or client assertion.
+var ErrNoClientSecret = errors.New("a client secret, certificate or client assertion is required for application authentication")
+
 // appClient implements the DataverseClient interface using a confidential
 // application client from the MSAL library.
 type appClient struct {
 	client      *confidential.Client
 	resourceURL string
+	// tokenCache persists tokens between runs, or is nil
+	tokenCache TokenCache
 }
 
 // GetAppService creates and returns a DataverseClient implementation using
@@ -24,19 +31,45 @@ type appClient struct {
 // The returned client uses confidential application authentication flow.
 // It acquires tokens using the client credentials grant type.
 //
+// The client authenticates with a certificate if c.Certificate is set,
+// otherwise with a signed client assertion if c.ClientAssertion is set,
+// otherwise with the client secret, which is read when the client is
+// created.
+//
 // Parameters:
 //   - c: ClientOptions containing required authentication parameters
 //
 // Returns:
 //   - A DataverseClient implementation
-//   - An error if client creation fails
+//   - An error if the credential cannot be read or client creation fails
 func GetAppService(c ClientOptions) (DataverseClient, error) {
-	cred, err := confidential.NewCredFromSecret(c.ClientSecret)
+	return GetAppServiceContext(context.Background(), c)
+}
+
+// GetAppServiceContext creates and returns a DataverseClient implementation
+// using the provided ClientOptions, as described by GetAppService. Reading
+// the client secret or certificate password is cancelled if ctx is
+// cancelled, so that a secret provider prompting the user or calling a vault
+// can be stopped.
+//
+// Parameters:
+//   - ctx: The context used to read the client secret or certificate
+//     password
+//   - c: ClientOptions containing required authentication parameters
+//
+// Returns:
+//   - A DataverseClient implementation
+//   - An error if the credential cannot be read or client creation fails
+func GetAppServiceContext(ctx context.Context, c ClientOptions) (DataverseClient, error) {
+	cred, options, err := newAppCredential(ctx, c)
 	if err != nil {
 		return nil, err
 	}
+	if c.TokenCache != nil {
+		options = append(options, confidential.WithCache(c.TokenCache))
+	}
 
-	app, err := confidential.New(c.Authority, c.ClientID, cred)
+	app, err := confidential.New(c.Authority, c.ClientID, cred, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +77,7 @@ func GetAppService(c ClientOptions) (DataverseClient, error) {
 	return &appClient{
 		client:      &app,
 		resourceURL: c.ResourceURL,
+		tokenCache:  c.TokenCache,
 	}, nil
 }
 
@@ -55,6 +89,17 @@ func GetAppService(c ClientOptions) (DataverseClient, error) {
 //   - The access token as a string
 //   - An error if token acquisition fails
 func (c *appClient) AcquireToken() (string, error) {
+	return c.AcquireTokenContext(context.Background())
+}
+
+// AcquireTokenContext obtains an access token for accessing the Dataverse API
+// using the client credentials flow. The request to the identity platform is
+// cancelled if ctx is cancelled.
+//
+// Returns:
+//   - The access token as a string
+//   - An error if token acquisition fails
+func (c *appClient) AcquireTokenContext(ctx context.Context) (string, error) {
 
 	resourceURL := c.resourceURL
 	if len(resourceURL) > 0 && !strings.HasSuffix(resourceURL, "/") {
@@ -62,9 +107,19 @@ func (c *appClient) AcquireToken() (string, error) {
 	}
 	scopes := []string{resourceURL + ".default"}
 
-	result, err := c.client.AcquireTokenByCredential(context.TODO(), scopes)
+	result, err := c.client.AcquireTokenByCredential(ctx, scopes)
 	if err != nil {
 		return "", err
 	}
 	return result.AccessToken, nil
 }
+
+// SignOut clears the client's persistent token cache if it has one. Tokens
+// acquired with client credentials belong to no account, so any held in
+// memory remain usable until the client is discarded.
+func (c *appClient) SignOut(ctx context.Context) error {
+	if c.tokenCache != nil {
+		return c.tokenCache.Clear(ctx)
+	}
+	return nil
+}
@@ -5,8 +5,8 @@ package msal
 
 import (
 	"context"
-	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/public"
 )
@@ -17,30 +17,77 @@ import (
 type delegatedClient struct {
 	client      *public.Client
 	resourceURL string
+	// deviceCodeHandler shows the device code when signing in with the
+	// device code flow. If nil, the interactive browser flow is used
+	deviceCodeHandler DeviceCodeHandler
+	// browserSignInHandler tells the user to sign in with the browser while
+	// waiting for the interactive browser flow, or is nil
+	browserSignInHandler BrowserSignInHandler
+	// tokenCache persists tokens between runs, or is nil
+	tokenCache TokenCache
+
+	// mu guards the account selection
+	mu sync.Mutex
+	// selection is the account chosen with UseAccount or UseNewAccount
+	selection accountSelection
+	// active is the account of the last token acquired, or nil
+	active *Account
 }
 
+// BrowserSignInHandler tells the user to sign in with the browser opened by
+// the interactive flow, then calls wait, which returns once the user has
+// signed in or ctx is cancelled. The handler may pass wait a context that is
+// cancelled when the user chooses to stop waiting.
+// Returns the error returned by wait, or any error showing the message.
+type BrowserSignInHandler func(ctx context.Context, wait func(ctx context.Context) error) error
+
 // GetDelegatedService creates a new DataverseClient that uses delegated
 // authentication. It requires user authentication through a browser. The
 // returned client implements the DataverseClient interface defined in
 // dataverse_service.go.
 func GetDelegatedService(c ClientOptions) (DataverseClient, error) {
-	client, err := public.New(c.ClientID, public.WithAuthority(c.Authority))
+	client, err := newPublicClient(c)
 	if err != nil {
 		return nil, err
 	}
 
 	return &delegatedClient{
-		client:      &client,
-		resourceURL: c.ResourceURL,
+		client:               client,
+		resourceURL:          c.ResourceURL,
+		browserSignInHandler: c.BrowserSignInHandler,
+		tokenCache:           c.TokenCache,
 	}, nil
 }
 
+// newPublicClient creates the MSAL public client used by delegated clients,
+// using c.TokenCache if set.
+func newPublicClient(c ClientOptions) (*public.Client, error) {
+	options := []public.Option{public.WithAuthority(c.Authority)}
+	if c.TokenCache != nil {
+		options = append(options, public.WithCache(c.TokenCache))
+	}
+
+	client, err := public.New(c.ClientID, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
 // AcquireToken obtains an access token for Dataverse API access.
-// It first attempts to acquire a token silently from the cache.
+// It first attempts to acquire a token silently from the cache, for the
+// account chosen with UseAccount, or else the first cached account.
 // If that fails, it initiates an interactive authentication flow that opens a
-// browser for user login.
+// browser for user login, or for device code clients, the device code flow.
 // Returns the access token as a string or an error if authentication fails.
 func (c *delegatedClient) AcquireToken() (string, error) {
+	return c.AcquireTokenContext(context.Background())
+}
+
+// AcquireTokenContext obtains an access token like AcquireToken. Both the
+// silent and interactive flows are abandoned if ctx is cancelled.
+// Returns the access token as a string or an error if authentication fails.
+func (c *delegatedClient) AcquireTokenContext(ctx context.Context) (string, error) {
 
 	resourceURL := c.resourceURL
 	if len(resourceURL) > 0 && !strings.HasSuffix(resourceURL, "/") {
@@ -49,21 +96,82 @@ func (c *delegatedClient) AcquireToken() (string, error) {
 	scopes := []string{resourceURL + "user_impersonation"}
 
 	// Looks for a token in the cache
-	accounts, err := c.client.Accounts(context.TODO())
-	if err == nil && len(accounts) > 0 {
+	account, found := c.selectedAccount(ctx)
+	if found {
 		response, err := c.client.AcquireTokenSilent(
-			context.TODO(), scopes,
-			public.WithSilentAccount(accounts[0]))
+			ctx, scopes,
+			public.WithSilentAccount(account))
 		if err == nil {
+			c.setActive(response.Account)
 			return response.AccessToken, nil
 		}
 	}
+	if c.deviceCodeHandler != nil {
+		return c.acquireTokenByDeviceCode(ctx, scopes)
+	}
+
 	// Opens default browser so client can authenticate
-	fmt.Printf("\nPlease authenticate in the browser...\n")
-	response, err := c.client.AcquireTokenInteractive(context.TODO(), scopes)
+	var options []public.AcquireInteractiveOption
+	if found {
+		options = append(options, public.WithLoginHint(account.PreferredUsername))
+	}
+	return c.acquireTokenInteractive(ctx, scopes, options)
+}
+
+// acquireTokenInteractive opens the default browser for the user to sign in,
+// and waits for them to do so. The client's browser sign in handler, if any,
+// tells the user to sign in while waiting.
+// Returns the access token as a string or an error if authentication fails
+// or is cancelled.
+func (c *delegatedClient) acquireTokenInteractive(
+	ctx context.Context,
+	scopes []string,
+	options []public.AcquireInteractiveOption) (string, error) {
+
+	var accessToken string
+	wait := func(ctx context.Context) error {
+		response, err := c.client.AcquireTokenInteractive(ctx, scopes, options...)
+		if err != nil {
+			return err
+		}
+		accessToken = response.AccessToken
+		c.setActive(response.Account)
+		return nil
+	}
+
+	var err error
+	if c.browserSignInHandler != nil {
+		err = c.browserSignInHandler(ctx, wait)
+	} else {
+		err = wait(ctx)
+	}
 	if err != nil {
 		return "", err
 	}
+	return accessToken, nil
+}
 
-	return response.AccessToken, nil
+// SignOut removes every account from the client's token cache, then clears
+// the persistent token cache if the client has one.
+func (c *delegatedClient) SignOut(ctx context.Context) error {
+	accounts, err := c.client.Accounts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		err = c.client.RemoveAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.selection = accountSelection{}
+	c.active = nil
+	c.mu.Unlock()
+
+	if c.tokenCache != nil {
+		return c.tokenCache.Clear(ctx)
+	}
+	return nil
 }
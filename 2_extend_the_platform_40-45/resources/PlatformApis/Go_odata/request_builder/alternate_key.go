@@ -0,0 +1,70 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrEmptyAlternateKey is returned when an alternate key has no properties.
+var ErrEmptyAlternateKey = errors.New("alternate key must contain at least one property")
+
+// AlternateKey identifies a record by the values of one or more key
+// properties instead of its GUID, e.g. accounts(accountnumber='ABC123').
+//
+// Composite keys are built by chaining And:
+//
+//	key := NewAlternateKey("accountnumber", String("ABC123")).
+//		And("address1_postalcode", String("SW1A 1AA"))
+type AlternateKey struct {
+	properties []keyProperty
+}
+
+// keyProperty is a single property/value pair in an alternate key.
+type keyProperty struct {
+	name  string
+	value Literal
+}
+
+// NewAlternateKey creates an alternate key with a single property.
+func NewAlternateKey(property string, value Literal) AlternateKey {
+	return AlternateKey{}.And(property, value)
+}
+
+// And returns a copy of the key with an additional property, for composite
+// keys.
+func (k AlternateKey) And(property string, value Literal) AlternateKey {
+	properties := make([]keyProperty, len(k.properties), len(k.properties)+1)
+	copy(properties, k.properties)
+	return AlternateKey{
+		properties: append(properties, keyProperty{name: property, value: value}),
+	}
+}
+
+// Segment renders the key as it appears between the parentheses of a
+// resource path, e.g. "accountnumber='ABC123'". Literal values are escaped
+// for use in a URL path.
+// Returns an error if the key is empty or contains an invalid property name
+// or literal.
+func (k AlternateKey) Segment() (string, error) {
+	if len(k.properties) == 0 {
+		return "", ErrEmptyAlternateKey
+	}
+
+	pairs := make([]string, len(k.properties))
+	for i, p := range k.properties {
+		name, err := validatedProperty(p.name)
+		if err != nil {
+			return "", err
+		}
+		value, err := p.value.literal()
+		if err != nil {
+			return "", err
+		}
+		pairs[i] = fmt.Sprintf("%s=%s", name, url.PathEscape(value))
+	}
+	return strings.Join(pairs, ","), nil
+}
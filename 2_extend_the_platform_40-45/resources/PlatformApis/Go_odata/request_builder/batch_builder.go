@@ -0,0 +1,271 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// BatchPath is the path segment, relative to the Web API base URL, that
+// accepts $batch requests.
+const BatchPath = "$batch"
+
+// Header names and values used when building multipart batch bodies.
+const (
+	headerContentType             = "Content-Type"
+	headerContentID               = "Content-ID"
+	headerContentTransferEncoding = "Content-Transfer-Encoding"
+	contentTypeHTTP               = "application/http"
+	contentTypeMultipartMixed     = "multipart/mixed"
+	contentTransferEncodingBinary = "binary"
+	batchBoundaryPrefix           = "batch_"
+	changeSetBoundaryPrefix       = "changeset_"
+)
+
+// ErrEmptyBatch is returned when building a batch that contains no
+// operations.
+var ErrEmptyBatch = errors.New("batch must contain at least one operation")
+
+// ErrEmptyChangeSet is returned when a change set contains no requests.
+var ErrEmptyChangeSet = errors.New("change set must contain at least one request")
+
+// ErrGetInChangeSet is returned when a GET request is added to a change set.
+// Change sets may only contain data modification requests.
+var ErrGetInChangeSet = errors.New("change sets cannot contain GET requests")
+
+// ErrDuplicateContentID is returned when two requests in a change set have the
+// same Content-ID, which would make references to it ambiguous.
+var ErrDuplicateContentID = errors.New("change set contains a duplicate Content-ID")
+
+// BatchBuilder defines an interface for constructing OData $batch requests.
+// It uses the builder pattern to allow for chained method calls.
+//
+// Requests added to a batch are built with a RequestBuilder as usual. Requests
+// in a change set are executed atomically: if one fails, all are rolled back.
+// Each request in a change set is given a Content-ID, numbered from 1 in the
+// order requests are added across the whole batch, unless the request already
+// has a Content-ID header. Numbering continues after the largest numeric
+// Content-ID set by a caller, and Content-IDs must be unique within a change
+// set. Later requests in the same change set can reference
+// the entity created by an earlier one using "$<Content-ID>" as the start of
+// their URL, e.g. "$1/contact_customer_accounts".
+type BatchBuilder interface {
+	// AddRequest adds a request that is executed independently of the other
+	// operations in the batch.
+	// Returns the builder for method chaining.
+	AddRequest(req *http.Request) BatchBuilder
+
+	// AddChangeSet adds a group of requests that succeed or fail together.
+	// Returns the builder for method chaining.
+	AddChangeSet(reqs ...*http.Request) BatchBuilder
+
+	// Build constructs the multipart/mixed $batch request.
+	// Returns an error if the batch is empty or a request cannot be
+	// serialised.
+	Build() (*http.Request, error)
+}
+
+// batchOperation is a single entry in a batch: either a request or a change
+// set.
+type batchOperation struct {
+	request   *http.Request
+	changeSet []*http.Request
+}
+
+// batchBuilder implements the BatchBuilder interface.
+type batchBuilder struct {
+	batchURL   string
+	operations []batchOperation
+	contentID  int
+}
+
+// NewBatchBuilder creates a new BatchBuilder that will post to batchURL, which
+// is usually the Web API base URL joined with BatchPath.
+func NewBatchBuilder(batchURL string) BatchBuilder {
+	return &batchBuilder{
+		batchURL: batchURL,
+	}
+}
+
+// AddRequest adds an independent request to the batch.
+// Returns the builder for method chaining.
+func (bb *batchBuilder) AddRequest(req *http.Request) BatchBuilder {
+	bb.operations = append(bb.operations, batchOperation{request: req})
+	return bb
+}
+
+// AddChangeSet adds an atomic group of requests to the batch.
+// Returns the builder for method chaining.
+func (bb *batchBuilder) AddChangeSet(reqs ...*http.Request) BatchBuilder {
+	bb.operations = append(bb.operations, batchOperation{changeSet: reqs})
+	return bb
+}
+
+// Build serialises every operation into a multipart/mixed body and returns
+// the POST request to send to the $batch endpoint.
+func (bb *batchBuilder) Build() (*http.Request, error) {
+	if len(bb.operations) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary(newBoundary(batchBoundaryPrefix)); err != nil {
+		return nil, err
+	}
+
+	bb.contentID = 0
+	for _, op := range bb.operations {
+		var err error
+		if op.request != nil {
+			err = bb.writeRequestPart(writer, op.request, "")
+		} else {
+			err = bb.writeChangeSetPart(writer, op.changeSet)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, bb.batchURL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch request: %w", err)
+	}
+	req.Header.Set(headerContentType, multipartContentType(writer.Boundary()))
+	return req, nil
+}
+
+// writeChangeSetPart writes a nested multipart/mixed part containing each
+// request in the change set.
+func (bb *batchBuilder) writeChangeSetPart(writer *multipart.Writer, reqs []*http.Request) error {
+	if len(reqs) == 0 {
+		return ErrEmptyChangeSet
+	}
+
+	var body bytes.Buffer
+	changeSetWriter := multipart.NewWriter(&body)
+	if err := changeSetWriter.SetBoundary(newBoundary(changeSetBoundaryPrefix)); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		if req.Method == http.MethodGet {
+			return ErrGetInChangeSet
+		}
+		contentID := bb.assignContentID(req)
+		if used[contentID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateContentID, contentID)
+		}
+		used[contentID] = true
+
+		if err := bb.writeRequestPart(changeSetWriter, req, contentID); err != nil {
+			return err
+		}
+	}
+
+	if err := changeSetWriter.Close(); err != nil {
+		return err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set(headerContentType, multipartContentType(changeSetWriter.Boundary()))
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body.Bytes())
+	return err
+}
+
+// assignContentID returns the Content-ID of a request in a change set: the
+// request's own Content-ID header if set, otherwise the next number. A numeric
+// Content-ID set by the caller advances the numbering past it, so that later
+// requests are not given the same Content-ID.
+func (bb *batchBuilder) assignContentID(req *http.Request) string {
+	contentID := req.Header.Get(headerContentID)
+	if contentID == "" {
+		bb.contentID++
+		return strconv.Itoa(bb.contentID)
+	}
+	if n, err := strconv.Atoi(contentID); err == nil && n > bb.contentID {
+		bb.contentID = n
+	}
+	return contentID
+}
+
+// writeRequestPart writes a single application/http part containing the
+// serialised request. Requests inside change sets are given the Content-ID
+// contentID, which is empty for requests outside change sets.
+func (bb *batchBuilder) writeRequestPart(writer *multipart.Writer, req *http.Request, contentID string) error {
+	header := textproto.MIMEHeader{}
+	header.Set(headerContentType, contentTypeHTTP)
+	header.Set(headerContentTransferEncoding, contentTransferEncodingBinary)
+
+	if contentID != "" {
+		header.Set(headerContentID, contentID)
+	}
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	return writeHTTPRequest(part, req)
+}
+
+// writeHTTPRequest writes the request line, headers and body of req in HTTP/1.1
+// message format.
+func writeHTTPRequest(w io.Writer, req *http.Request) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s HTTP/1.1\r\n", req.Method, req.URL.String())
+	for key, values := range req.Header {
+		if key == textproto.CanonicalMIMEHeaderKey(headerContentID) {
+			continue
+		}
+		for _, value := range values {
+			fmt.Fprintf(&builder, "%s: %s\r\n", key, value)
+		}
+	}
+	builder.WriteString("\r\n")
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return err
+	}
+
+	if req.Body == nil {
+		return nil
+	}
+	defer req.Body.Close()
+	if _, err := io.Copy(w, req.Body); err != nil {
+		return fmt.Errorf("failed to read batch request body: %w", err)
+	}
+	_, err := io.WriteString(w, "\r\n")
+	return err
+}
+
+// multipartContentType returns a multipart/mixed content type with the given
+// boundary.
+func multipartContentType(boundary string) string {
+	return fmt.Sprintf("%s; boundary=%s", contentTypeMultipartMixed, boundary)
+}
+
+// newBoundary returns a random multipart boundary with the given prefix.
+func newBoundary(prefix string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
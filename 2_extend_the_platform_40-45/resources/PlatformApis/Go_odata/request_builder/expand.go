@@ -0,0 +1,132 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OData system query options used in expansions.
+const (
+	queryParamKeyExpand = "$expand"
+	querySelect         = "$select"
+	queryTop            = "$top"
+)
+
+// ErrInvalidNavigationProperty is returned when an expansion references a
+// navigation property name that is not a valid identifier.
+var ErrInvalidNavigationProperty = errors.New("invalid navigation property")
+
+// ErrInvalidTop is returned when an expansion has a negative $top.
+var ErrInvalidTop = errors.New("top must not be negative")
+
+// identifierPattern matches a single OData identifier, such as a navigation
+// property name.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Expand describes a navigation property to include in a response with the
+// $expand query option, together with the query options applied to the
+// related entities.
+//
+// Select applies to both single-valued and collection-valued navigation
+// properties. Filter, OrderBy and Top only apply to collection-valued
+// navigation properties.
+type Expand struct {
+	// NavigationProperty is the name of the navigation property to expand,
+	// e.g. "primarycontactid" or "contact_customer_accounts"
+	NavigationProperty string
+
+	// Select lists the properties of the related entities to return. If
+	// empty, every property is returned.
+	Select []string
+
+	// Filter restricts the related entities returned. If nil, every related
+	// entity is returned.
+	Filter Filter
+
+	// OrderBy sorts the related entities.
+	OrderBy []OrderBy
+
+	// Top limits the number of related entities returned. If zero, no limit
+	// is applied.
+	Top int
+
+	// Expand lists navigation properties of the related entities to expand.
+	Expand []Expand
+}
+
+// expression renders the expansion, e.g.
+// "contact_customer_accounts($select=fullname;$top=5)". Returns an error if
+// any name, filter or sort key is invalid.
+func (e Expand) expression() (string, error) {
+	if !identifierPattern.MatchString(e.NavigationProperty) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNavigationProperty, e.NavigationProperty)
+	}
+
+	options := []string{}
+	if len(e.Select) > 0 {
+		properties := make([]string, len(e.Select))
+		for i, s := range e.Select {
+			property, err := validatedProperty(s)
+			if err != nil {
+				return "", err
+			}
+			properties[i] = property
+		}
+		options = append(options, querySelect+"="+strings.Join(properties, ","))
+	}
+
+	if e.Filter != nil {
+		filter, err := e.Filter.Expression()
+		if err != nil {
+			return "", err
+		}
+		options = append(options, queryParamKeyFilter+"="+filter)
+	}
+
+	if len(e.OrderBy) > 0 {
+		orderBy, err := orderByExpression(e.OrderBy)
+		if err != nil {
+			return "", err
+		}
+		options = append(options, queryParamKeyOrderBy+"="+orderBy)
+	}
+
+	if e.Top < 0 {
+		return "", fmt.Errorf("%w: %d", ErrInvalidTop, e.Top)
+	}
+	if e.Top > 0 {
+		options = append(options, queryTop+"="+strconv.Itoa(e.Top))
+	}
+
+	if len(e.Expand) > 0 {
+		expand, err := expandExpression(e.Expand)
+		if err != nil {
+			return "", err
+		}
+		options = append(options, queryParamKeyExpand+"="+expand)
+	}
+
+	if len(options) == 0 {
+		return e.NavigationProperty, nil
+	}
+	return fmt.Sprintf("%s(%s)", e.NavigationProperty, strings.Join(options, ";")), nil
+}
+
+// expandExpression renders a list of expansions as a comma separated $expand
+// value.
+func expandExpression(expand []Expand) (string, error) {
+	expressions := make([]string, len(expand))
+	for i, e := range expand {
+		expression, err := e.expression()
+		if err != nil {
+			return "", err
+		}
+		expressions[i] = expression
+	}
+	return strings.Join(expressions, ","), nil
+}
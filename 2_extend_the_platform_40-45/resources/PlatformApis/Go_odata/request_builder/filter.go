@@ -0,0 +1,404 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPropertyName is returned when a filter references a property name
+// that is not a valid OData property path.
+var ErrInvalidPropertyName = errors.New("invalid property name")
+
+// ErrInvalidGuid is returned when a GUID literal is not a valid GUID.
+var ErrInvalidGuid = errors.New("invalid guid")
+
+// ErrEmptyFilterGroup is returned when an in filter is built without any
+// values.
+var ErrEmptyFilterGroup = errors.New("filter group must contain at least one value")
+
+// ErrNilFilter is returned when a negation is built without an operand.
+var ErrNilFilter = errors.New("filter operand must not be nil")
+
+var (
+	// propertyPathPattern matches property names and navigation paths such
+	// as "name", "_parentcustomerid_value" or "primarycontactid/fullname".
+	propertyPathPattern = regexp.MustCompile(
+		`^[A-Za-z_][A-Za-z0-9_]*(/[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+	// guidPattern matches a GUID in its canonical 8-4-4-4-12 hex form.
+	guidPattern = regexp.MustCompile(
+		`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Comparison operators supported by OData $filter expressions.
+const (
+	operatorEq = "eq"
+	operatorNe = "ne"
+	operatorGt = "gt"
+	operatorGe = "ge"
+	operatorLt = "lt"
+	operatorLe = "le"
+	operatorIn = "in"
+)
+
+// Filter represents a node in an OData $filter expression tree.
+//
+// Filters are built with the constructor functions in this package (Eq,
+// Contains, And, etc.) and rendered by the RequestBuilder when the request is
+// built. Rendering escapes all literal values and validates property names, so
+// user input can be passed to these constructors safely. The rendered
+// expression is not URL encoded; encoding is applied once when the request URL
+// is built.
+type Filter interface {
+	// Expression renders the filter as an unencoded OData expression.
+	// Returns an error if the filter contains an invalid property name or
+	// literal.
+	Expression() (string, error)
+}
+
+// Literal represents a typed value in an OData expression.
+type Literal interface {
+	// literal renders the value in OData literal syntax.
+	literal() (string, error)
+}
+
+// stringLiteral is a string value, rendered in single quotes.
+type stringLiteral string
+
+// String creates a string literal. Single quotes in the value are escaped by
+// doubling them, as required by the OData URL conventions.
+func String(value string) Literal {
+	return stringLiteral(value)
+}
+
+// literal renders the string in single quotes with embedded quotes doubled.
+func (l stringLiteral) literal() (string, error) {
+	return "'" + strings.ReplaceAll(string(l), "'", "''") + "'", nil
+}
+
+// guidLiteral is a GUID value, rendered without quotes.
+type guidLiteral string
+
+// Guid creates a GUID literal. The value is validated when the filter is
+// rendered.
+func Guid(value string) Literal {
+	return guidLiteral(value)
+}
+
+// literal renders the GUID, returning ErrInvalidGuid if the value is not a
+// valid GUID.
+func (l guidLiteral) literal() (string, error) {
+	if !guidPattern.MatchString(string(l)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidGuid, string(l))
+	}
+	return string(l), nil
+}
+
+// dateTimeLiteral is a point in time, rendered as an Edm.DateTimeOffset.
+type dateTimeLiteral time.Time
+
+// DateTime creates an Edm.DateTimeOffset literal. The value is rendered in
+// UTC using RFC 3339 format.
+func DateTime(value time.Time) Literal {
+	return dateTimeLiteral(value)
+}
+
+// literal renders the time in RFC 3339 format.
+func (l dateTimeLiteral) literal() (string, error) {
+	return time.Time(l).UTC().Format(time.RFC3339), nil
+}
+
+// dateLiteral is a calendar date, rendered as an Edm.Date.
+type dateLiteral time.Time
+
+// Date creates an Edm.Date literal. Only the date component of the value is
+// rendered.
+func Date(value time.Time) Literal {
+	return dateLiteral(value)
+}
+
+// literal renders the date in YYYY-MM-DD format.
+func (l dateLiteral) literal() (string, error) {
+	return time.Time(l).Format(time.DateOnly), nil
+}
+
+// numberLiteral is a numeric value, stored pre-formatted.
+type numberLiteral string
+
+// Int creates an integer literal.
+func Int(value int64) Literal {
+	return numberLiteral(strconv.FormatInt(value, 10))
+}
+
+// Float creates a decimal literal.
+func Float(value float64) Literal {
+	return numberLiteral(strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// literal renders the number.
+func (l numberLiteral) literal() (string, error) {
+	return string(l), nil
+}
+
+// boolLiteral is a boolean value.
+type boolLiteral bool
+
+// Bool creates a boolean literal.
+func Bool(value bool) Literal {
+	return boolLiteral(value)
+}
+
+// literal renders the boolean as true or false.
+func (l boolLiteral) literal() (string, error) {
+	return strconv.FormatBool(bool(l)), nil
+}
+
+// nullLiteral is the OData null value.
+type nullLiteral struct{}
+
+// literal renders null.
+func (nullLiteral) literal() (string, error) {
+	return "null", nil
+}
+
+// comparison is a filter comparing a property with a literal value.
+type comparison struct {
+	property string
+	operator string
+	value    Literal
+}
+
+// Eq creates a filter matching records where property equals value.
+func Eq(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorEq, value: value}
+}
+
+// Ne creates a filter matching records where property does not equal value.
+func Ne(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorNe, value: value}
+}
+
+// Gt creates a filter matching records where property is greater than value.
+func Gt(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorGt, value: value}
+}
+
+// Ge creates a filter matching records where property is greater than or
+// equal to value.
+func Ge(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorGe, value: value}
+}
+
+// Lt creates a filter matching records where property is less than value.
+func Lt(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorLt, value: value}
+}
+
+// Le creates a filter matching records where property is less than or equal
+// to value.
+func Le(property string, value Literal) Filter {
+	return comparison{property: property, operator: operatorLe, value: value}
+}
+
+// IsNull creates a filter matching records where property has no value.
+func IsNull(property string) Filter {
+	return comparison{property: property, operator: operatorEq, value: nullLiteral{}}
+}
+
+// IsNotNull creates a filter matching records where property has a value.
+func IsNotNull(property string) Filter {
+	return comparison{property: property, operator: operatorNe, value: nullLiteral{}}
+}
+
+// Expression renders the comparison, e.g. "name eq 'Contoso'".
+func (c comparison) Expression() (string, error) {
+	property, err := validatedProperty(c.property)
+	if err != nil {
+		return "", err
+	}
+	value, err := c.value.literal()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s", property, c.operator, value), nil
+}
+
+// stringFunction is a filter applying a canonical string function such as
+// contains or startswith to a property.
+type stringFunction struct {
+	name     string
+	property string
+	value    string
+}
+
+// Contains creates a filter matching records where property contains value.
+func Contains(property, value string) Filter {
+	return stringFunction{name: "contains", property: property, value: value}
+}
+
+// StartsWith creates a filter matching records where property starts with
+// value.
+func StartsWith(property, value string) Filter {
+	return stringFunction{name: "startswith", property: property, value: value}
+}
+
+// EndsWith creates a filter matching records where property ends with value.
+func EndsWith(property, value string) Filter {
+	return stringFunction{name: "endswith", property: property, value: value}
+}
+
+// Expression renders the function call, e.g. "contains(name,'Contoso')".
+func (f stringFunction) Expression() (string, error) {
+	property, err := validatedProperty(f.property)
+	if err != nil {
+		return "", err
+	}
+	value, _ := String(f.value).literal()
+	return fmt.Sprintf("%s(%s,%s)", f.name, property, value), nil
+}
+
+// logicalGroup is a filter joining its operands with and/or.
+type logicalGroup struct {
+	operator string
+	operands []Filter
+}
+
+// And creates a filter matching records that satisfy every operand. Nil
+// operands are ignored. And returns nil if no operands remain, and the
+// operand itself if only one remains.
+func And(operands ...Filter) Filter {
+	return newLogicalGroup("and", operands)
+}
+
+// Or creates a filter matching records that satisfy at least one operand.
+// Nil operands are ignored. Or returns nil if no operands remain, and the
+// operand itself if only one remains.
+func Or(operands ...Filter) Filter {
+	return newLogicalGroup("or", operands)
+}
+
+// newLogicalGroup removes nil operands and collapses trivial groups.
+func newLogicalGroup(operator string, operands []Filter) Filter {
+	nonNil := make([]Filter, 0, len(operands))
+	for _, o := range operands {
+		if o != nil {
+			nonNil = append(nonNil, o)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return logicalGroup{operator: operator, operands: nonNil}
+	}
+}
+
+// Expression renders the group with each operand in parentheses, e.g.
+// "(name eq 'a') or (name eq 'b')".
+func (g logicalGroup) Expression() (string, error) {
+	expressions := make([]string, len(g.operands))
+	for i, o := range g.operands {
+		e, err := o.Expression()
+		if err != nil {
+			return "", err
+		}
+		expressions[i] = "(" + e + ")"
+	}
+	return strings.Join(expressions, " "+g.operator+" "), nil
+}
+
+// negation is a filter matching records that do not satisfy its operand.
+type negation struct {
+	operand Filter
+}
+
+// Not creates a filter matching records that do not satisfy operand. If
+// operand is nil, e.g. the result of an empty And, rendering the filter
+// returns ErrNilFilter.
+func Not(operand Filter) Filter {
+	return negation{operand: operand}
+}
+
+// Expression renders the negation, e.g. "not (name eq 'Contoso')".
+// Returns ErrNilFilter if the operand is nil.
+func (n negation) Expression() (string, error) {
+	if n.operand == nil {
+		return "", fmt.Errorf("%w: not", ErrNilFilter)
+	}
+
+	e, err := n.operand.Expression()
+	if err != nil {
+		return "", err
+	}
+	return "not (" + e + ")", nil
+}
+
+// membership is a filter matching records where a property equals one of a
+// set of values.
+type membership struct {
+	property string
+	values   []Literal
+}
+
+// In creates a filter matching records where property equals any of values.
+// It is rendered with the OData in operator, which keeps the URL short for
+// long lists of values.
+func In(property string, values ...Literal) Filter {
+	return membership{property: property, values: values}
+}
+
+// Expression renders the membership test, e.g. "statecode in (0,1)".
+// Returns ErrEmptyFilterGroup if there are no values.
+func (m membership) Expression() (string, error) {
+	property, err := validatedProperty(m.property)
+	if err != nil {
+		return "", err
+	}
+	if len(m.values) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrEmptyFilterGroup, property)
+	}
+
+	literals := make([]string, len(m.values))
+	for i, v := range m.values {
+		literal, err := v.literal()
+		if err != nil {
+			return "", err
+		}
+		literals[i] = literal
+	}
+	return fmt.Sprintf("%s %s (%s)", property, operatorIn, strings.Join(literals, ",")), nil
+}
+
+// rawFilter is an expression rendered exactly as given.
+type rawFilter string
+
+// Raw creates a filter from an OData expression that is rendered exactly as
+// given. Unlike the other constructors, the expression is neither validated
+// nor escaped, so Raw must only be used with trusted input, such as a filter
+// passed on the command line.
+func Raw(expression string) Filter {
+	return rawFilter(expression)
+}
+
+// Expression renders the raw expression.
+func (f rawFilter) Expression() (string, error) {
+	return string(f), nil
+}
+
+// validatedProperty returns the property name if it is a valid OData property
+// path, or ErrInvalidPropertyName otherwise.
+func validatedProperty(property string) (string, error) {
+	if !propertyPathPattern.MatchString(property) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPropertyName, property)
+	}
+	return property, nil
+}
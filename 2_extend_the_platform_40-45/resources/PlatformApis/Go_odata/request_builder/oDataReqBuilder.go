@@ -7,8 +7,12 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
+// queryParamKeyFilter is the OData system query option for filter expressions.
+const queryParamKeyFilter = "$filter"
+
 // RequestBuilder defines an interface for constructing HTTP requests.
 // It uses the builder pattern to allow for chained method calls.
 type RequestBuilder interface {
@@ -20,6 +24,19 @@ type RequestBuilder interface {
 	// Returns the builder for method chaining.
 	AddHeader(key, value string) RequestBuilder
 
+	// AddFilter sets the $filter query option from a filter expression tree.
+	// A nil filter is ignored.
+	// Returns the builder for method chaining.
+	AddFilter(filter Filter) RequestBuilder
+
+	// AddOrderBy appends sort keys to the $orderby query option.
+	// Returns the builder for method chaining.
+	AddOrderBy(orderBy ...OrderBy) RequestBuilder
+
+	// AddExpand appends navigation properties to the $expand query option.
+	// Returns the builder for method chaining.
+	AddExpand(expand ...Expand) RequestBuilder
+
 	// Build constructs and returns the final http.Request object.
 	// Returns an error if the request cannot be created.
 	Build() (*http.Request, error)
@@ -32,6 +49,9 @@ type requestBuilder struct {
 	payload     io.Reader
 	queryParams url.Values
 	headers     http.Header
+	filter      Filter
+	orderBy     []OrderBy
+	expand      []Expand
 }
 
 // NewRequestBuilder creates a new RequestBuilder instance with the specified
@@ -62,10 +82,59 @@ func (rb *requestBuilder) AddHeader(key, value string) RequestBuilder {
 	return rb
 }
 
+// AddFilter sets the filter expression used for the $filter query option.
+// The expression is rendered and validated when the request is built.
+// Returns the builder for method chaining.
+func (rb *requestBuilder) AddFilter(filter Filter) RequestBuilder {
+	rb.filter = filter
+	return rb
+}
+
+// AddOrderBy appends sort keys to the $orderby query option. Keys are applied
+// in the order they are added.
+// Returns the builder for method chaining.
+func (rb *requestBuilder) AddOrderBy(orderBy ...OrderBy) RequestBuilder {
+	rb.orderBy = append(rb.orderBy, orderBy...)
+	return rb
+}
+
+// AddExpand appends navigation properties to the $expand query option.
+// The expansions are rendered and validated when the request is built.
+// Returns the builder for method chaining.
+func (rb *requestBuilder) AddExpand(expand ...Expand) RequestBuilder {
+	rb.expand = append(rb.expand, expand...)
+	return rb
+}
+
 // Build constructs and returns the final http.Request object using the
 // configured parameters, headers, and payload. It returns an error if the
-// request cannot be created.
+// request cannot be created or the filter, sort or expand expressions are
+// invalid.
 func (rb *requestBuilder) Build() (*http.Request, error) {
+	if rb.filter != nil {
+		expression, err := rb.filter.Expression()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build filter: %w", err)
+		}
+		rb.queryParams.Set(queryParamKeyFilter, expression)
+	}
+
+	if len(rb.orderBy) > 0 {
+		expression, err := orderByExpression(rb.orderBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build order by: %w", err)
+		}
+		rb.queryParams.Set(queryParamKeyOrderBy, expression)
+	}
+
+	if len(rb.expand) > 0 {
+		expression, err := expandExpression(rb.expand)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build expand: %w", err)
+		}
+		rb.queryParams.Set(queryParamKeyExpand, expression)
+	}
+
 	url := rb.buildURL()
 
 	req, err := http.NewRequest(rb.httpMethod, url, rb.payload)
@@ -84,9 +153,14 @@ func (rb *requestBuilder) Build() (*http.Request, error) {
 
 // buildURL constructs the complete URL from the path and query parameters.
 // If there are no query parameters, it returns just the path.
+//
+// Query values are encoded exactly once. Spaces are encoded as %20 rather than
+// +, which OData services do not reliably treat as a space; literal plus signs
+// have already been encoded as %2B at this point.
 func (rb *requestBuilder) buildURL() string {
 	if len(rb.queryParams) == 0 {
 		return rb.path
 	}
-	return fmt.Sprintf("%s?%s", rb.path, rb.queryParams.Encode())
+	query := strings.ReplaceAll(rb.queryParams.Encode(), "+", "%20")
+	return fmt.Sprintf("%s?%s", rb.path, query)
 }
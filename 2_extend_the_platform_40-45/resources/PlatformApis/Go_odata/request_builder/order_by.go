@@ -0,0 +1,55 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import "strings"
+
+// queryParamKeyOrderBy is the OData system query option for sort order.
+const queryParamKeyOrderBy = "$orderby"
+
+// OrderBy describes a single sort key in an OData $orderby expression.
+type OrderBy struct {
+	// Property is the logical name of the property to sort by
+	Property string
+
+	// Descending sorts in descending order when true, and ascending order
+	// otherwise
+	Descending bool
+}
+
+// Asc creates an ascending sort key for property.
+func Asc(property string) OrderBy {
+	return OrderBy{Property: property}
+}
+
+// Desc creates a descending sort key for property.
+func Desc(property string) OrderBy {
+	return OrderBy{Property: property, Descending: true}
+}
+
+// expression renders the sort key, e.g. "name desc". Returns an error if the
+// property name is invalid.
+func (o OrderBy) expression() (string, error) {
+	property, err := validatedProperty(o.Property)
+	if err != nil {
+		return "", err
+	}
+	if o.Descending {
+		return property + " desc", nil
+	}
+	return property + " asc", nil
+}
+
+// orderByExpression renders a list of sort keys as a comma separated $orderby
+// value.
+func orderByExpression(orderBy []OrderBy) (string, error) {
+	expressions := make([]string, len(orderBy))
+	for i, o := range orderBy {
+		e, err := o.expression()
+		if err != nil {
+			return "", err
+		}
+		expressions[i] = e
+	}
+	return strings.Join(expressions, ","), nil
+}
@@ -0,0 +1,62 @@
+// Package requestbuilder provides tools for building HTTP requests,
+// specifically designed to work with OData APIs.
+package requestbuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// refSegment is the path segment addressing the reference to an entity,
+// rather than the entity itself.
+const refSegment = "$ref"
+
+// ErrInvalidEntitySet is returned when an entity reference names an entity
+// set that is not a valid identifier.
+var ErrInvalidEntitySet = errors.New("invalid entity set")
+
+// EntityReference identifies a single entity by its entity set and GUID,
+// e.g. the account with a given accountid in the "accounts" entity set.
+type EntityReference struct {
+	// EntitySet is the name of the entity set, e.g. "accounts"
+	EntitySet string
+
+	// ID is the GUID of the entity
+	ID string
+}
+
+// Path returns the path of the entity relative to the Web API base URL, e.g.
+// "accounts(00000000-0000-0000-0000-000000000001)". This is the form used in
+// "@odata.bind" annotations, prefixed with "/".
+// Returns an error if the entity set or GUID is invalid.
+func (r EntityReference) Path() (string, error) {
+	if !identifierPattern.MatchString(r.EntitySet) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEntitySet, r.EntitySet)
+	}
+	id, err := Guid(r.ID).literal()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s)", r.EntitySet, id), nil
+}
+
+// NavigationRefPath returns the path addressing references through a
+// navigation property, relative to the source entity, e.g.
+// "primarycontactid/$ref". If targetID is not empty, the path addresses the
+// reference to that entity within a collection-valued navigation property,
+// e.g. "contact_customer_accounts(guid)/$ref".
+// Returns an error if the navigation property or target GUID is invalid.
+func NavigationRefPath(navigationProperty, targetID string) (string, error) {
+	if !identifierPattern.MatchString(navigationProperty) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNavigationProperty, navigationProperty)
+	}
+	if targetID == "" {
+		return navigationProperty + "/" + refSegment, nil
+	}
+
+	id, err := Guid(targetID).literal()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s)/%s", navigationProperty, id, refSegment), nil
+}
@@ -0,0 +1,109 @@
+// Package secrets provides msal.SecretProvider implementations reading
+// secrets from environment variables, files, external commands, the OS
+// keyring or a prompt, so that secrets need not be stored in plaintext
+// configuration.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+	"github.com/zalando/go-keyring"
+)
+
+// envProvider reads a secret from an environment variable.
+type envProvider struct {
+	name string
+}
+
+// NewEnvProvider creates a SecretProvider reading the environment variable
+// with the given name.
+func NewEnvProvider(name string) msal.SecretProvider {
+	return &envProvider{name: name}
+}
+
+// Secret returns the value of the environment variable.
+func (p *envProvider) Secret(ctx context.Context) (string, error) {
+	return nonEmpty(os.Getenv(p.name), "environment variable "+p.name)
+}
+
+// fileProvider reads a secret from a file.
+type fileProvider struct {
+	path string
+}
+
+// NewFileProvider creates a SecretProvider reading the file at path, such as
+// a secret mounted by a container orchestrator. A trailing line break is
+// removed.
+func NewFileProvider(path string) msal.SecretProvider {
+	return &fileProvider{path: path}
+}
+
+// Secret returns the content of the file.
+func (p *fileProvider) Secret(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	return nonEmpty(string(data), "file "+p.path)
+}
+
+// commandProvider reads a secret from the output of a command.
+type commandProvider struct {
+	name string
+	args []string
+}
+
+// NewCommandProvider creates a SecretProvider running the named command with
+// args and reading the secret from its stdout, e.g. a password manager's
+// command line tool. A trailing line break is removed.
+func NewCommandProvider(name string, args ...string) msal.SecretProvider {
+	return &commandProvider{name: name, args: args}
+}
+
+// Secret runs the command and returns its output. The command is killed if
+// ctx is cancelled.
+func (p *commandProvider) Secret(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", p.name, err, message)
+		}
+		return "", fmt.Errorf("%s failed: %w", p.name, err)
+	}
+	return nonEmpty(stdout.String(), "command "+p.name)
+}
+
+// keyringProvider reads a secret from the OS keyring.
+type keyringProvider struct {
+	service string
+	user    string
+}
+
+// NewKeyringProvider creates a SecretProvider reading the secret stored for
+// service and user in the OS keyring: the Secret Service API (libsecret) on
+// Linux, the Keychain on macOS or the Credential Manager on Windows. On
+// Linux, a secret can be stored with:
+//
+//	secret-tool store --label="go_odata" service SERVICE username USER
+func NewKeyringProvider(service, user string) msal.SecretProvider {
+	return &keyringProvider{service: service, user: user}
+}
+
+// Secret returns the secret stored in the keyring.
+func (p *keyringProvider) Secret(ctx context.Context) (string, error) {
+	secret, err := keyring.Get(p.service, p.user)
+	if err != nil {
+		return "", fmt.Errorf("keyring %s/%s: %w", p.service, p.user, err)
+	}
+	return nonEmpty(secret, "keyring "+p.service+"/"+p.user)
+}
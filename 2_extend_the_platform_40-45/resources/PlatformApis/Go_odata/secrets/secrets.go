@@ -0,0 +1,103 @@
+// Package secrets provides msal.SecretProvider implementations reading
+// secrets from environment variables, files, external commands, the OS
+// keyring or a prompt, so that secrets need not be stored in plaintext
+// configuration.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+)
+
+// Source kinds used in a source specification, e.g. "file:/run/secrets/x".
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceCommand = "command"
+	SourceKeyring = "keyring"
+	SourcePrompt  = "prompt"
+)
+
+// sourceSeparator separates a source kind from its value.
+const sourceSeparator = ":"
+
+// ErrInvalidSource is returned when a source specification is not valid.
+var ErrInvalidSource = errors.New("invalid secret source")
+
+// ErrEmptySecret is returned when a source provides an empty secret.
+var ErrEmptySecret = errors.New("secret is empty")
+
+// NewProvider creates a SecretProvider from a source specification:
+//
+//   - "env:NAME" reads the environment variable NAME
+//   - "file:PATH" reads the file at PATH, e.g. a mounted secret
+//   - "command:NAME ARGS..." runs the command and reads its stdout; the
+//     command is split on spaces and is not run by a shell
+//   - "keyring:SERVICE/USER" reads the OS keyring, using the Secret Service
+//     API (libsecret) on Linux
+//   - "prompt" asks the user with prompt
+//
+// Parameters:
+//   - source: The source specification
+//   - prompt: The provider used for the "prompt" source, which depends on
+//     whether the terminal user interface is running
+//
+// Returns:
+//   - A SecretProvider reading the secret each time it is asked
+//   - An error wrapping ErrInvalidSource if the specification is not valid
+func NewProvider(source string, prompt msal.SecretProvider) (msal.SecretProvider, error) {
+	kind, value, _ := strings.Cut(source, sourceSeparator)
+	value = strings.TrimSpace(value)
+
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case SourceEnv:
+		if value == "" {
+			return nil, fmt.Errorf("%w: env source requires a variable name", ErrInvalidSource)
+		}
+		return NewEnvProvider(value), nil
+	case SourceFile:
+		if value == "" {
+			return nil, fmt.Errorf("%w: file source requires a path", ErrInvalidSource)
+		}
+		return NewFileProvider(value), nil
+	case SourceCommand:
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: command source requires a command", ErrInvalidSource)
+		}
+		return NewCommandProvider(fields[0], fields[1:]...), nil
+	case SourceKeyring:
+		service, user, ok := strings.Cut(value, "/")
+		if !ok || service == "" || user == "" {
+			return nil, fmt.Errorf("%w: keyring source must be keyring:SERVICE/USER", ErrInvalidSource)
+		}
+		return NewKeyringProvider(service, user), nil
+	case SourcePrompt:
+		if prompt == nil {
+			return nil, fmt.Errorf("%w: prompt is not available", ErrInvalidSource)
+		}
+		return prompt, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSource, source)
+	}
+}
+
+// IsPrompt reports whether source is the "prompt" source, which asks the
+// user rather than reading the secret from elsewhere.
+func IsPrompt(source string) bool {
+	kind, _, _ := strings.Cut(source, sourceSeparator)
+	return strings.EqualFold(strings.TrimSpace(kind), SourcePrompt)
+}
+
+// nonEmpty returns secret, or ErrEmptySecret if it is empty once trailing
+// line breaks are removed.
+func nonEmpty(secret, source string) (string, error) {
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s", ErrEmptySecret, source)
+	}
+	return secret, nil
+}
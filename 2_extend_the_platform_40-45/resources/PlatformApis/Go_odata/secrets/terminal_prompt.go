@@ -0,0 +1,48 @@
+// Package secrets provides msal.SecretProvider implementations reading
+// secrets from environment variables, files, external commands, the OS
+// keyring or a prompt, so that secrets need not be stored in plaintext
+// configuration.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+	"golang.org/x/term"
+)
+
+// ErrNoTerminal is returned when prompting for a secret without a terminal.
+var ErrNoTerminal = errors.New("cannot prompt for a secret without a terminal")
+
+// terminalPrompt reads a secret typed at the terminal without echoing it.
+type terminalPrompt struct {
+	label string
+}
+
+// NewTerminalPrompt creates a SecretProvider that writes label to stderr and
+// reads a secret from the terminal without echoing it. It is used by the
+// command line subcommands; the terminal user interface prompts with a
+// masked input screen instead.
+func NewTerminalPrompt(label string) msal.SecretProvider {
+	return &terminalPrompt{label: label}
+}
+
+// Secret prompts for the secret.
+// Returns ErrNoTerminal if stdin is not a terminal, e.g. in a CI job.
+func (p *terminalPrompt) Secret(ctx context.Context) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoTerminal
+	}
+
+	fmt.Fprint(os.Stderr, p.label)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return nonEmpty(string(secret), "prompt")
+}
@@ -0,0 +1,170 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Media types and header names found in $batch responses.
+const (
+	contentTypeMultipartMixed = "multipart/mixed"
+	headerContentID           = "Content-ID"
+	mediaTypeParamBoundary    = "boundary"
+)
+
+// ErrInvalidBatchResponse is returned when a $batch response is not a valid
+// multipart/mixed document.
+var ErrInvalidBatchResponse = errors.New("invalid batch response")
+
+// BatchOperationResponse contains the result of a single operation within a
+// $batch request.
+//
+// When a change set fails, Dataverse rolls back every request in the change
+// set and returns a single response describing the failure in place of the
+// individual responses.
+type BatchOperationResponse struct {
+	// ContentID is the Content-ID of the request within its change set, or an
+	// empty string for requests outside a change set
+	ContentID string
+	// StatusCode contains the HTTP status code of the operation
+	StatusCode int
+	// Header contains the response headers of the operation
+	Header http.Header
+	// Body contains the raw response bytes of the operation
+	Body []byte
+	// IsSuccessful indicates whether the operation succeeded (status code 2xx
+	// or 3xx)
+	IsSuccessful bool
+	// Error describes the failure of an unsuccessful operation, or is nil if
+	// the operation succeeded
+	Error *DataverseError
+}
+
+// ExecuteBatch sends a $batch request and parses the multipart response into
+// one BatchOperationResponse per operation, in the order the operations were
+// added to the batch.
+//
+// Returns an error if the batch request itself fails. Failures of individual
+// operations are reported in the corresponding BatchOperationResponse.
+func (s dataverseService) ExecuteBatch(req *http.Request) ([]BatchOperationResponse, error) {
+	res, err := s.Execute(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !res.IsSuccessful {
+		return nil, fmt.Errorf("batch request failed: %w", NewDataverseError(res))
+	}
+
+	return ParseBatchResponse(res)
+}
+
+// ExecuteBatchContext sends a $batch request like ExecuteBatch, using ctx in
+// place of the request's context.
+func (s dataverseService) ExecuteBatchContext(ctx context.Context, req *http.Request) ([]BatchOperationResponse, error) {
+	return s.ExecuteBatch(req.WithContext(ctx))
+}
+
+// ParseBatchResponse parses a multipart/mixed $batch response into one
+// BatchOperationResponse per operation. Change set responses are flattened in
+// to the returned slice.
+func ParseBatchResponse(res *DataverseResponse) ([]BatchOperationResponse, error) {
+	boundary, err := multipartBoundary(res.Header.Get(headerContentType))
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchParts(bytes.NewReader(res.Body), boundary)
+}
+
+// parseBatchParts reads every part of a multipart body, recursing into nested
+// change set parts.
+func parseBatchParts(body io.Reader, boundary string) ([]BatchOperationResponse, error) {
+	reader := multipart.NewReader(body, boundary)
+	responses := []BatchOperationResponse{}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return responses, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBatchResponse, err)
+		}
+
+		contentType := part.Header.Get(headerContentType)
+		if strings.HasPrefix(contentType, contentTypeMultipartMixed) {
+			nestedBoundary, err := multipartBoundary(contentType)
+			if err != nil {
+				return nil, err
+			}
+			nested, err := parseBatchParts(part, nestedBoundary)
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, nested...)
+			continue
+		}
+
+		response, err := parseBatchPart(part)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *response)
+	}
+}
+
+// parseBatchPart parses an application/http part containing a single HTTP
+// response.
+func parseBatchPart(part *multipart.Part) (*BatchOperationResponse, error) {
+	res, err := http.ReadResponse(bufio.NewReader(part), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBatchResponse, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBatchResponse, err)
+	}
+
+	contentID := part.Header.Get(headerContentID)
+	if contentID == "" {
+		contentID = res.Header.Get(headerContentID)
+	}
+
+	response := &BatchOperationResponse{
+		ContentID:    contentID,
+		StatusCode:   res.StatusCode,
+		Header:       res.Header,
+		Body:         body,
+		IsSuccessful: res.StatusCode >= 200 && res.StatusCode < 400,
+	}
+
+	if !response.IsSuccessful {
+		response.Error = newDataverseErrorFromParts(res.StatusCode, res.Header, body)
+	}
+	return response, nil
+}
+
+// multipartBoundary extracts the boundary parameter from a multipart content
+// type.
+func multipartBoundary(contentType string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidBatchResponse, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params[mediaTypeParamBoundary] == "" {
+		return "", fmt.Errorf("%w: unexpected content type %q", ErrInvalidBatchResponse, contentType)
+	}
+	return params[mediaTypeParamBoundary], nil
+}
@@ -3,6 +3,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,11 +21,40 @@ type DataverseService interface {
 	// otherwise.
 	TestConnection() error
 
+	// TestConnectionContext validates connectivity like TestConnection,
+	// abandoning the attempt if ctx is cancelled.
+	TestConnectionContext(ctx context.Context) error
+
 	// Execute sends the provided HTTP request to Dataverse with proper
-	// authentication.
+	// authentication. The request's context controls cancellation.
 	// Returns a DataverseResponse containing the response data and status
 	// information.
 	Execute(req *http.Request) (*DataverseResponse, error)
+
+	// ExecuteContext sends the provided HTTP request like Execute, using ctx
+	// in place of the request's context.
+	ExecuteContext(ctx context.Context, req *http.Request) (*DataverseResponse, error)
+
+	// ExecuteBatch sends a $batch request built with a BatchBuilder and parses
+	// the multipart response into one result per operation.
+	ExecuteBatch(req *http.Request) ([]BatchOperationResponse, error)
+
+	// ExecuteBatchContext sends a $batch request like ExecuteBatch, using ctx
+	// in place of the request's context.
+	ExecuteBatchContext(ctx context.Context, req *http.Request) ([]BatchOperationResponse, error)
+
+	// Impersonate makes subsequent requests on behalf of caller, sending the
+	// CallerObjectId or MSCRMCallerID header. A request's context can
+	// override this with WithCaller or WithoutCaller.
+	Impersonate(caller Caller)
+
+	// StopImpersonating makes subsequent requests as the authenticated
+	// identity.
+	StopImpersonating()
+
+	// Impersonation returns the caller set with Impersonate, and false if
+	// the service is not impersonating a user.
+	Impersonation() (Caller, bool)
 }
 
 // DataverseServiceOptions contains the configuration options for creating a
@@ -33,32 +63,80 @@ type DataverseServiceOptions struct {
 	// Client is the MSAL client used for authentication with Dataverse.
 	// It handles token acquisition and caching.
 	Client msal.DataverseClient
+
+	// RetryPolicy controls how requests failing with transient errors are
+	// retried by the default middleware. If nil, DefaultRetryPolicy is used.
+	RetryPolicy *RetryPolicy
+
+	// Timeout limits the time taken by each HTTP attempt in the default
+	// middleware. If zero, a default of 30 seconds is used. Use request
+	// contexts to limit the total time taken, including retries.
+	Timeout time.Duration
+
+	// Caller is the user that requests are made on behalf of, until
+	// changed with Impersonate or StopImpersonating. If zero, requests are
+	// made as the authenticated identity.
+	Caller Caller
+
+	// Middleware lists the middleware wrapping every request, outermost
+	// first. If nil, DefaultMiddleware(options) is used. To add interceptors,
+	// such as ones adding correlation IDs or recording metrics, insert them
+	// into the list returned by DefaultMiddleware; without the default
+	// middleware requests are neither authenticated nor retried.
+	Middleware []Middleware
+
+	// Transport sends each attempt of a request once it has passed through
+	// the middleware. If nil, http.DefaultTransport is used. Unlike an
+	// http.Client, a transport does not follow redirects; Dataverse does not
+	// redirect Web API requests, and a redirect response is returned as it
+	// is.
+	Transport http.RoundTripper
 }
 
+// defaultTimeout is the per-attempt HTTP timeout used when
+// DataverseServiceOptions.Timeout is zero.
+const defaultTimeout = 30 * time.Second
+
 // dataverseService is the internal implementation of the DataverseService
 // interface.
 type dataverseService struct {
-	client     msal.DataverseClient
-	httpClient *http.Client
+	client msal.DataverseClient
+	// pipeline sends requests through the middleware to the transport
+	pipeline http.RoundTripper
+	// impersonation holds the session's caller, shared by copies of the
+	// service
+	impersonation *impersonation
 }
 
 // NewDataverseService creates a new DataverseService instance with the provided
 // options.
+//
+// Requests pass through options.Middleware, or DefaultMiddleware(options) if
+// it is nil, before being sent by options.Transport.
 func NewDataverseService(options DataverseServiceOptions) (DataverseService, error) {
-	s := dataverseService{
-		client: options.Client,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	middleware := options.Middleware
+	if middleware == nil {
+		middleware = DefaultMiddleware(options)
 	}
 
-	return &s, nil
+	transport := options.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &dataverseService{
+		client:        options.Client,
+		pipeline:      chainMiddleware(transport, middleware...),
+		impersonation: &impersonation{caller: options.Caller},
+	}, nil
 }
 
 // DataverseResponse encapsulates the HTTP response from a Dataverse API call.
 type DataverseResponse struct {
 	// StatusCode contains the HTTP status code returned by the API
 	StatusCode int
+	// Header contains the response headers returned by the API
+	Header http.Header
 	// Body contains the raw response bytes from the API call
 	Body []byte
 	// IsSuccessful indicates whether the request was successful (status code
@@ -73,38 +151,50 @@ type DataverseResponse struct {
 // Returns nil if successful, or a wrapped error with details if connection
 // fails.
 func (s dataverseService) TestConnection() error {
-	_, err := s.client.AcquireToken()
+	return s.TestConnectionContext(context.Background())
+}
+
+// TestConnectionContext validates connectivity to Dataverse like
+// TestConnection. Token acquisition is abandoned if ctx is cancelled.
+func (s dataverseService) TestConnectionContext(ctx context.Context) error {
+	_, err := s.client.AcquireTokenContext(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to connect: %w", err)
 	}
 	return nil
 }
 
-// Execute sends the provided HTTP request to Dataverse with proper
-// authentication.
-// It automatically acquires an access token (using the cached token if valid)
-// and adds it to the request as a Bearer token. The method also sets
-// appropriate headers for JSON content.
+// Execute sends the provided HTTP request to Dataverse through the
+// service's middleware and reads the response.
+//
+// The default middleware acquires an access token (using the cached token if
+// valid) and adds it to the request as a Bearer token, requests a JSON
+// response unless the request already sets an Accept header, and identifies
+// the caller when impersonating a user. Requests failing with transient
+// errors are retried according to the service's RetryPolicy, with the
+// request body buffered so that it can be sent again on each attempt.
 //
-// The method handles the complete request lifecycle including sending the
-// request, reading the response body, and properly closing resources.
+// Cancelling the request's context abandons token acquisition, the HTTP
+// request and any wait before a retry.
 //
 // Returns a DataverseResponse containing status code, response body, and
 // success indicator, or an error if the request fails at any stage.
 func (s dataverseService) Execute(req *http.Request) (*DataverseResponse, error) {
-
-	accessToken, err := s.client.AcquireToken()
+	res, err := s.pipeline.RoundTrip(s.withSessionCaller(req))
 	if err != nil {
 		return nil, err
 	}
+	return readDataverseResponse(res)
+}
 
-	req.Header.Set(acceptHeader, contentTypeJSON)
-	req.Header.Set(authHeader, bearerTokenPrefix+accessToken)
+// ExecuteContext sends the request like Execute, using ctx in place of the
+// request's context.
+func (s dataverseService) ExecuteContext(ctx context.Context, req *http.Request) (*DataverseResponse, error) {
+	return s.Execute(req.WithContext(ctx))
+}
 
-	res, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+// readDataverseResponse reads and closes the body of res.
+func readDataverseResponse(res *http.Response) (*DataverseResponse, error) {
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
@@ -115,6 +205,21 @@ func (s dataverseService) Execute(req *http.Request) (*DataverseResponse, error)
 	return &DataverseResponse{
 		Body:         body,
 		StatusCode:   res.StatusCode,
+		Header:       res.Header,
 		IsSuccessful: res.StatusCode >= 200 && res.StatusCode < 400,
 	}, nil
 }
+
+// sleepContext waits for the given duration, returning early with ctx.Err()
+// if ctx is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,287 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/model"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+	"github.com/turnerbenjamin/go_odata/view"
+)
+
+// Values used when requesting and reading change tracking responses.
+const (
+	preferTrackChanges         = "odata.track-changes"
+	deletedEntityContextSuffix = "$deletedEntity"
+)
+
+// ErrNoDeltaLink is returned when a change tracking response does not include
+// a delta link, usually because change tracking is not enabled for the table.
+var ErrNoDeltaLink = errors.New("response did not include a delta link; check that change tracking is enabled for the table")
+
+// ErrDeltaLinkExpired is returned when Dataverse rejects a saved delta link
+// because it has expired or is no longer valid, in which case every entity
+// must be retrieved again. Changes does this automatically.
+var ErrDeltaLinkExpired = errors.New("the delta link has expired; a full sync is required")
+
+// Dataverse error code returned when the version stamp of a delta link has
+// expired.
+const errorCodeExpiredVersionStamp = "0x80044352"
+
+// Fragments of the messages returned for expired or invalid delta links,
+// which are not always given a distinct error code.
+var expiredDeltaLinkMessages = []string{
+	"version stamp",
+	"delta token",
+	"deltatoken",
+	"full sync",
+}
+
+// DeletedEntity identifies an entity removed since the previous delta.
+type DeletedEntity struct {
+	// ID is the GUID of the removed entity
+	ID string `json:"id"`
+
+	// Reason is "deleted" if the entity was deleted, or "changed" if it no
+	// longer belongs to the tracked set
+	Reason string `json:"reason"`
+}
+
+// DeltaResult contains the changes to the entities of a table since a
+// previous delta link, as returned by EntityService.Changes.
+type DeltaResult[T view.Entity] struct {
+	// Upserted contains the entities created or updated since the previous
+	// delta, or every entity if IsInitial is true
+	Upserted []T
+
+	// Deleted identifies the entities removed since the previous delta
+	Deleted []DeletedEntity
+
+	// DeltaLink is the URL used to retrieve the changes made after this
+	// result
+	DeltaLink string
+
+	// IsInitial is true if there was no previous delta link, in which case
+	// Upserted contains every entity and Deleted is empty
+	IsInitial bool
+}
+
+// deltaEntry holds the properties that distinguish a deleted entity from an
+// upserted entity in a change tracking response.
+type deltaEntry struct {
+	Context string `json:"@odata.context"`
+	DeletedEntity
+}
+
+// Changes returns the entities created, updated or deleted since the delta
+// link saved in store for this service's table, then saves the new delta
+// link.
+func (s *entityService[T]) Changes(store DeltaTokenStore) (*DeltaResult[T], error) {
+	return s.ChangesContext(context.Background(), store)
+}
+
+// ChangesContext returns the changes since the saved delta link like Changes,
+// abandoning the requests if ctx is cancelled. The delta link is only saved
+// once every page of changes has been retrieved.
+//
+// If the saved delta link has expired, it is deleted and every entity is
+// retrieved again, in which case the result's IsInitial is true.
+func (s *entityService[T]) ChangesContext(ctx context.Context, store DeltaTokenStore) (*DeltaResult[T], error) {
+	key := s.deltaTokenKey()
+	deltaLink, err := store.Load(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delta link: %w", err)
+	}
+
+	result, err := s.ChangesSinceContext(ctx, deltaLink)
+	if errors.Is(err, ErrDeltaLinkExpired) {
+		if err := store.Delete(key); err != nil {
+			return nil, fmt.Errorf("failed to delete expired delta link: %w", err)
+		}
+		result, err = s.ChangesSinceContext(ctx, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Save(key, result.DeltaLink); err != nil {
+		return nil, fmt.Errorf("failed to save delta link: %w", err)
+	}
+	return result, nil
+}
+
+// ChangesSince returns the entities created, updated or deleted since
+// deltaLink.
+func (s *entityService[T]) ChangesSince(deltaLink string) (*DeltaResult[T], error) {
+	return s.ChangesSinceContext(context.Background(), deltaLink)
+}
+
+// ChangesSinceContext returns the changes since deltaLink like ChangesSince,
+// abandoning the requests if ctx is cancelled. If deltaLink is empty, every
+// entity is returned with the selected fields; filters and expanded
+// navigation properties are not supported with change tracking.
+// Every page of changes is retrieved before the result is returned; use
+// ChangesSincePagesContext to handle large tables a page at a time.
+func (s *entityService[T]) ChangesSinceContext(ctx context.Context, deltaLink string) (*DeltaResult[T], error) {
+	result := &DeltaResult[T]{IsInitial: deltaLink == ""}
+
+	newDeltaLink, err := s.ChangesSincePagesContext(ctx, deltaLink, func(page *DeltaResult[T]) error {
+		result.Upserted = append(result.Upserted, page.Upserted...)
+		result.Deleted = append(result.Deleted, page.Deleted...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.DeltaLink = newDeltaLink
+	return result, nil
+}
+
+// ChangesSincePages retrieves the changes since deltaLink a page at a time.
+func (s *entityService[T]) ChangesSincePages(deltaLink string, handlePage func(page *DeltaResult[T]) error) (string, error) {
+	return s.ChangesSincePagesContext(context.Background(), deltaLink, handlePage)
+}
+
+// ChangesSincePagesContext retrieves the changes since deltaLink like
+// ChangesSinceContext, but passes each page to handlePage as it is
+// retrieved rather than collecting every page in memory. Each page's
+// DeltaLink is empty, except for the last page.
+//
+// If handlePage returns an error, no further pages are retrieved and that
+// error is returned. Returns the delta link used to retrieve later changes,
+// ErrDeltaLinkExpired if Dataverse rejects deltaLink, or ErrNoDeltaLink if
+// the last page did not include a delta link.
+func (s *entityService[T]) ChangesSincePagesContext(ctx context.Context, deltaLink string, handlePage func(page *DeltaResult[T]) error) (string, error) {
+	isInitial := deltaLink == ""
+
+	next := deltaLink
+	if isInitial {
+		//e.g. [Organization URI]/api/data/v9.2/accounts?$select=name
+		req, err := requestBuilder.NewRequestBuilder(http.MethodGet, s.resourceUrl.String(), nil).
+			AddQueryParam(queryParamKeySelect, s.selects).
+			Build()
+		if err != nil {
+			return "", err
+		}
+		next = req.URL.String()
+	}
+
+	newDeltaLink := ""
+	for next != "" {
+		page, err := s.getDeltaPage(ctx, next, !isInitial)
+		if err != nil {
+			return "", err
+		}
+
+		result := &DeltaResult[T]{
+			DeltaLink: page.DeltaLink,
+			IsInitial: isInitial,
+		}
+		for _, raw := range page.Data {
+			if err := s.addDeltaEntry(result, raw); err != nil {
+				return "", err
+			}
+		}
+
+		if err := handlePage(result); err != nil {
+			return "", err
+		}
+
+		if page.DeltaLink != "" {
+			newDeltaLink = page.DeltaLink
+		}
+		next = page.Next
+	}
+
+	if newDeltaLink == "" {
+		return "", ErrNoDeltaLink
+	}
+	return newDeltaLink, nil
+}
+
+// getDeltaPage retrieves a single page of a change tracking response. If
+// resuming is true, url continues from a saved delta link, and an error
+// reporting that it has expired is returned as ErrDeltaLinkExpired.
+func (s *entityService[T]) getDeltaPage(ctx context.Context, url string, resuming bool) (*model.GetManyResponse[json.RawMessage], error) {
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, url, nil).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(headerPrefer, s.preferences(
+		preferTrackChanges,
+		fmt.Sprintf(preferMaxPageSizeFormat, s.pageLimit)))
+
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve changes: %w", err)
+	}
+	if !res.IsSuccessful {
+		dvErr := NewDataverseError(res)
+		if resuming && isExpiredDeltaLinkError(dvErr) {
+			return nil, fmt.Errorf("%w: %w", ErrDeltaLinkExpired, dvErr)
+		}
+		return nil, dvErr
+	}
+
+	page := &model.GetManyResponse[json.RawMessage]{}
+	if err := json.Unmarshal(res.Body, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// isExpiredDeltaLinkError returns true if dvErr reports that a delta link has
+// expired or is not a valid delta token.
+func isExpiredDeltaLinkError(dvErr *DataverseError) bool {
+	if dvErr.Code == errorCodeExpiredVersionStamp {
+		return true
+	}
+	if dvErr.StatusCode != http.StatusBadRequest && dvErr.StatusCode != http.StatusGone {
+		return false
+	}
+
+	message := strings.ToLower(dvErr.Message)
+	for _, fragment := range expiredDeltaLinkMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// addDeltaEntry adds a single entry of a change tracking response to result,
+// as either a deleted or an upserted entity.
+func (s *entityService[T]) addDeltaEntry(result *DeltaResult[T], raw json.RawMessage) error {
+	var entry deltaEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(entry.Context, deletedEntityContextSuffix) {
+		s.forgetETag(entry.ID)
+		result.Deleted = append(result.Deleted, entry.DeletedEntity)
+		return nil
+	}
+
+	entity, err := s.decodeEntity(raw)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal changed entity: %w", err)
+	}
+	result.Upserted = append(result.Upserted, entity)
+	return nil
+}
+
+// deltaTokenKey returns the key under which the delta link for this
+// service's table is saved. The resource URL is used so that tables in
+// different environments do not share a key.
+func (s *entityService[T]) deltaTokenKey() string {
+	return s.resourceUrl.String()
+}
@@ -0,0 +1,143 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File permissions used by the file delta token store. Delta links grant no
+// access on their own but are kept private to the user.
+const (
+	deltaTokenDirPerm  = 0o700
+	deltaTokenFilePerm = 0o600
+)
+
+// DeltaTokenStore persists the delta links used to retrieve changes with
+// EntityService.Changes. Keys identify a table in an environment.
+type DeltaTokenStore interface {
+	// Load returns the delta link saved under key, or an empty string if
+	// there is none
+	Load(key string) (string, error)
+
+	// Save stores deltaLink under key, replacing any previous value
+	Save(key, deltaLink string) error
+
+	// Delete removes the delta link saved under key, so that the next call
+	// to Changes retrieves every entity
+	Delete(key string) error
+}
+
+// fileDeltaTokenStore implements DeltaTokenStore using a JSON file mapping
+// keys to delta links.
+type fileDeltaTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileDeltaTokenStore creates a DeltaTokenStore that keeps delta links in
+// the JSON file at path. The file and its directory are created when the
+// first delta link is saved. Writes replace the file atomically, so a failed
+// write leaves the previous delta links intact.
+func NewFileDeltaTokenStore(path string) DeltaTokenStore {
+	return &fileDeltaTokenStore{
+		path: path,
+	}
+}
+
+// Load returns the delta link saved under key.
+func (s *fileDeltaTokenStore) Load(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	return tokens[key], nil
+}
+
+// Save stores deltaLink under key.
+func (s *fileDeltaTokenStore) Save(key, deltaLink string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = deltaLink
+	return s.write(tokens)
+}
+
+// Delete removes the delta link saved under key.
+func (s *fileDeltaTokenStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+	delete(tokens, key)
+	return s.write(tokens)
+}
+
+// read loads every saved delta link. A missing file holds no delta links.
+func (s *fileDeltaTokenStore) read() (map[string]string, error) {
+	tokens := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid delta token file %s: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+// write replaces the file with the given delta links by writing a temporary
+// file in the same directory and renaming it.
+func (s *fileDeltaTokenStore) write(tokens map[string]string) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, deltaTokenDirPerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(deltaTokenFilePerm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -5,8 +5,8 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -22,13 +22,14 @@ import (
 
 const (
 	queryParamKeySelect = "$select"
-	queryParmKeyFilter  = "$filter"
 )
 
 // HTTP header name constants used for API requests
 const (
 	headerContentType = "Content-Type"
 	headerPrefer      = "Prefer"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
 	authHeader        = "Authorization"
 	acceptHeader      = "Accept"
 )
@@ -38,28 +39,186 @@ const (
 	contentTypeJSON            = "application/json"
 	preferReturnRepresentation = "return=representation"
 	preferMaxPageSizeFormat    = "odata.maxpagesize=%d"
+	preferIncludeAnnotations   = "odata.include-annotations=\"%s\""
 	bearerTokenPrefix          = "Bearer "
+	matchAny                   = "*"
 )
 
+// AllAnnotations requests every instance annotation when used in
+// EntityServiceOptions.IncludeAnnotations, including the formatted values of
+// choice, money, date and boolean columns.
+const AllAnnotations = "*"
+
+// UpsertMode controls whether an upsert may create a new record, update an
+// existing record, or do either.
+type UpsertMode int
+
+const (
+	// UpsertCreateOrUpdate creates the record if it does not exist and
+	// updates it otherwise.
+	UpsertCreateOrUpdate UpsertMode = iota
+
+	// UpsertCreateOnly creates the record, failing with ErrAlreadyExists if it
+	// already exists. Sends If-None-Match: *.
+	UpsertCreateOnly
+
+	// UpsertUpdateOnly updates the record, failing with ErrNotFound if it does
+	// not exist. Sends If-Match: *.
+	UpsertUpdateOnly
+)
+
+// UpsertResult contains the outcome of an upsert operation.
+type UpsertResult[T view.Entity] struct {
+	// Entity is the created or updated entity as returned by the API
+	Entity T
+
+	// Created is true if a new record was created, and false if an existing
+	// record was updated
+	Created bool
+}
+
 // EntityService provides a generic interface for CRUD operations on entities
 // that implement the view.Entity interface. The type parameter T represents the
 // entity type.
+//
+// Each operation has a variant with a Context suffix that accepts a
+// context.Context; cancelling the context abandons the request. The variants
+// without a context use context.Background().
 type EntityService[T view.Entity] interface {
-	// List retrieves all entities, optionally filtered by searchTerm
-	List(searchTerm string) (view.EntityList[T], error)
+	// List retrieves all entities, optionally filtered by filter and sorted by
+	// orderBy. A nil filter retrieves every entity. The sort order is
+	// preserved when fetching subsequent pages.
+	List(filter requestBuilder.Filter, orderBy ...requestBuilder.OrderBy) (view.EntityList[T], error)
+
+	// ListContext retrieves entities like List. ctx applies to the first
+	// page only; later pages are fetched when the list is navigated.
+	ListContext(ctx context.Context, filter requestBuilder.Filter, orderBy ...requestBuilder.OrderBy) (view.EntityList[T], error)
+
+	// SearchFilter builds a filter matching entities where any of the
+	// configured search fields contain searchTerm. Returns nil if searchTerm
+	// is empty or no search fields are configured.
+	SearchFilter(searchTerm string) requestBuilder.Filter
 
 	// Get retrieves a specific entity by its GUID
 	Get(guid string) (T, error)
 
+	// GetContext retrieves a specific entity like Get.
+	GetContext(ctx context.Context, guid string) (T, error)
+
 	// Create adds a new entity and returns the created entity with
 	// server-generated fields
 	Create(entityToCreate T) (newEntity T, err error)
 
-	// Update modifies an existing entity identified by GUID
+	// CreateContext adds a new entity like Create.
+	CreateContext(ctx context.Context, entityToCreate T) (newEntity T, err error)
+
+	// Update modifies an existing entity identified by GUID. If the entity's
+	// ETag was captured by Get, List or Create, the update only succeeds if
+	// the entity has not changed since; otherwise ErrConcurrencyConflict is
+	// returned. ETags are kept for the 1000 most recently used entities.
 	Update(guid string, entityToUpdate T) error
 
-	// Delete removes an entity identified by GUID
+	// UpdateContext modifies an existing entity like Update.
+	UpdateContext(ctx context.Context, guid string, entityToUpdate T) error
+
+	// Overwrite modifies an existing entity identified by GUID regardless of
+	// any changes made since it was retrieved.
+	Overwrite(guid string, entityToUpdate T) error
+
+	// OverwriteContext modifies an existing entity like Overwrite.
+	OverwriteContext(ctx context.Context, guid string, entityToUpdate T) error
+
+	// Delete removes an entity identified by GUID. If the entity's ETag was
+	// captured, the delete only succeeds if the entity has not changed since;
+	// otherwise ErrConcurrencyConflict is returned.
 	Delete(guid string) error
+
+	// DeleteContext removes an entity like Delete.
+	DeleteContext(ctx context.Context, guid string) error
+
+	// ForceDelete removes an entity identified by GUID regardless of any
+	// changes made since it was retrieved.
+	ForceDelete(guid string) error
+
+	// ForceDeleteContext removes an entity like ForceDelete.
+	ForceDeleteContext(ctx context.Context, guid string) error
+
+	// NewCreateRequest builds, without sending, the request used by Create.
+	// It can be added to a $batch request.
+	NewCreateRequest(entityToCreate T) (*http.Request, error)
+
+	// NewUpdateRequest builds, without sending, the request used by Update.
+	// It can be added to a $batch request.
+	NewUpdateRequest(guid string, entityToUpdate T) (*http.Request, error)
+
+	// NewDeleteRequest builds, without sending, the request used by Delete.
+	// It can be added to a $batch request.
+	NewDeleteRequest(guid string) (*http.Request, error)
+
+	// Upsert creates or updates the entity identified by an alternate key,
+	// subject to mode, and reports which happened.
+	Upsert(key requestBuilder.AlternateKey, entity T, mode UpsertMode) (UpsertResult[T], error)
+
+	// UpsertContext creates or updates an entity like Upsert.
+	UpsertContext(ctx context.Context, key requestBuilder.AlternateKey, entity T, mode UpsertMode) (UpsertResult[T], error)
+
+	// NewUpsertRequest builds, without sending, the request used by Upsert.
+	// It can be added to a $batch request.
+	NewUpsertRequest(key requestBuilder.AlternateKey, entity T, mode UpsertMode) (*http.Request, error)
+
+	// Associate links the entity identified by GUID to target through the
+	// relationship's navigation property. A single-valued lookup is set,
+	// replacing any existing value; target is added to a collection.
+	Associate(guid string, relationship Relationship, target requestBuilder.EntityReference) error
+
+	// AssociateContext links two entities like Associate.
+	AssociateContext(ctx context.Context, guid string, relationship Relationship, target requestBuilder.EntityReference) error
+
+	// NewAssociateRequest builds, without sending, the request used by
+	// Associate. It can be added to a $batch request.
+	NewAssociateRequest(guid string, relationship Relationship, target requestBuilder.EntityReference) (*http.Request, error)
+
+	// Disassociate removes the link between the entity identified by GUID
+	// and target. A single-valued lookup is cleared, in which case target is
+	// ignored; target is removed from a collection.
+	Disassociate(guid string, relationship Relationship, target requestBuilder.EntityReference) error
+
+	// DisassociateContext removes the link between two entities like
+	// Disassociate.
+	DisassociateContext(ctx context.Context, guid string, relationship Relationship, target requestBuilder.EntityReference) error
+
+	// NewDisassociateRequest builds, without sending, the request used by
+	// Disassociate. It can be added to a $batch request.
+	NewDisassociateRequest(guid string, relationship Relationship, target requestBuilder.EntityReference) (*http.Request, error)
+
+	// Changes returns the entities created, updated or deleted since the
+	// delta link saved in store for this table, and saves the new delta
+	// link. If no delta link has been saved, or the saved delta link has
+	// expired, every entity is returned. The table must have change tracking
+	// enabled.
+	Changes(store DeltaTokenStore) (*DeltaResult[T], error)
+
+	// ChangesContext returns changes like Changes.
+	ChangesContext(ctx context.Context, store DeltaTokenStore) (*DeltaResult[T], error)
+
+	// ChangesSince returns the entities created, updated or deleted since
+	// deltaLink, or every entity if deltaLink is empty, without saving the
+	// new delta link. It allows callers to save the delta link together
+	// with the changes they apply.
+	ChangesSince(deltaLink string) (*DeltaResult[T], error)
+
+	// ChangesSinceContext returns changes like ChangesSince.
+	ChangesSinceContext(ctx context.Context, deltaLink string) (*DeltaResult[T], error)
+
+	// ChangesSincePages retrieves the changes since deltaLink like
+	// ChangesSince, passing each page to handlePage as it is retrieved
+	// instead of holding every change in memory, and returns the new delta
+	// link once every page has been handled. Returns ErrDeltaLinkExpired if
+	// deltaLink is no longer valid.
+	ChangesSincePages(deltaLink string, handlePage func(page *DeltaResult[T]) error) (string, error)
+
+	// ChangesSincePagesContext retrieves changes like ChangesSincePages.
+	ChangesSincePagesContext(ctx context.Context, deltaLink string, handlePage func(page *DeltaResult[T]) error) (string, error)
 }
 
 // EntityServiceOptions contains configuration parameters for creating an
@@ -83,16 +242,68 @@ type EntityServiceOptions struct {
 
 	// SearchFields defines which fields are included in search operations
 	SearchFields []string
+
+	// Expand lists the navigation properties to include when entities are
+	// listed or retrieved. Expanded entities are decoded into the entity
+	// type's fields for the navigation properties.
+	Expand []requestBuilder.Expand
+
+	// NewEntity creates the value into which each entity is decoded. It must
+	// return a value of the service's entity type. If nil, entities are
+	// decoded into the zero value of the entity type, which is suitable for
+	// pointers to structs. It is required for types that need initialising
+	// before decoding, such as model.DynamicRecord.
+	NewEntity func() view.Entity
+
+	// IncludeAnnotations lists the instance annotations returned with
+	// entities, such as model.AnnotationFormattedValue, which are requested
+	// with the Prefer header. If empty, no annotations are requested.
+	IncludeAnnotations []string
+}
+
+// SchemaValidator checks property names against the schema of the
+// environment. It is implemented by *csdl.Metadata.
+type SchemaValidator interface {
+	// ValidateProperties returns an error if the entity set does not exist
+	// or any of the properties are not defined on its entity type.
+	ValidateProperties(entitySetName string, properties ...string) error
+}
+
+// Validate checks that ResourcePath names an entity set and that every field
+// in SelectsFields and SearchFields, and every navigation property in Expand,
+// is one of its properties. This allows misconfigured options to be reported
+// at startup rather than when the first request fails.
+func (o EntityServiceOptions) Validate(validator SchemaValidator) error {
+	fields := make([]string, 0, len(o.SelectsFields)+len(o.SearchFields)+len(o.Expand))
+	fields = append(fields, o.SelectsFields...)
+	fields = append(fields, o.SearchFields...)
+	for _, e := range o.Expand {
+		fields = append(fields, e.NavigationProperty)
+	}
+	return validator.ValidateProperties(o.ResourcePath, fields...)
 }
 
 // entityService implements EntityService for a specific entity type T
 type entityService[T view.Entity] struct {
 	dataverseService DataverseService
 	pageLimit        int
+	baseUrl          *url.URL
 	resourceUrl      *url.URL
 	selects          string
 	zeroValue        T
 	searchFields     []string
+	expand           []requestBuilder.Expand
+	newEntity        func() view.Entity
+	annotations      string
+
+	// etags holds the most recent "@odata.etag" seen for recently used
+	// entities, keyed by GUID, for use in If-Match headers
+	etags *etagCache
+}
+
+// entityTag captures the ETag annotation returned with each entity.
+type entityTag struct {
+	ETag string `json:"@odata.etag"`
 }
 
 // NewEntityService creates a new EntityService implementation for the specified
@@ -106,84 +317,85 @@ func NewEntityService[T view.Entity](options EntityServiceOptions) EntityService
 	return &entityService[T]{
 		dataverseService: options.DataverseService,
 		pageLimit:        options.PageLimit,
+		baseUrl:          options.BaseUrl,
 		resourceUrl:      &resourceUrl,
 		selects:          selectsString,
 		searchFields:     options.SearchFields,
+		expand:           options.Expand,
+		newEntity:        options.NewEntity,
+		annotations:      strings.Join(options.IncludeAnnotations, ","),
+		etags:            newETagCache(maxTrackedETags),
 	}
 }
 
 // Create adds a new entity to the system and returns the created entity with
 // server-generated fields populated.
 func (s *entityService[T]) Create(entity T) (T, error) {
+	return s.CreateContext(context.Background(), entity)
+}
 
-	//e.g. [Organization URI]/api/data/v9.2/accounts
-	path := s.resourceUrl.String()
-	payload, err := json.Marshal(entity)
-	if err != nil {
-		return s.zeroValue, fmt.Errorf("failed to serialise entity %w", err)
-	}
-
-	req, err := requestBuilder.NewRequestBuilder(http.MethodPost, path, bytes.NewReader(payload)).
-		Build()
+// CreateContext adds a new entity like Create, abandoning the request if ctx
+// is cancelled.
+func (s *entityService[T]) CreateContext(ctx context.Context, entity T) (T, error) {
+	req, err := s.NewCreateRequest(entity)
 	if err != nil {
 		return s.zeroValue, err
 	}
 
-	req.Header.Set(headerContentType, contentTypeJSON)
-	req.Header.Set(headerPrefer, preferReturnRepresentation)
-
-	res, err := s.dataverseService.Execute(req)
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
 	if err != nil {
 		return s.zeroValue, fmt.Errorf("failed to create entity: %w", err)
 	}
 
 	if !res.IsSuccessful {
-		errMsg := s.ParseErrorMessage(res.Body)
-		return s.zeroValue, errors.New(errMsg)
+		return s.zeroValue, NewDataverseError(res)
 	}
 
-	var newEntity T
-	if err := json.Unmarshal(res.Body, &newEntity); err != nil {
+	newEntity, err := s.decodeEntity(res.Body)
+	if err != nil {
 		return s.zeroValue, fmt.Errorf("failed to unmarshal created entity: %w", err)
 	}
 	return newEntity, nil
 }
 
-// List retrieves entities, optionally filtered by searchTerm.
+// List retrieves entities, optionally filtered by filter and sorted by orderBy.
 // It returns a paginated collection that handles fetching additional pages as
-// needed.
-func (s *entityService[T]) List(searchTerm string) (view.EntityList[T], error) {
+// needed. Dataverse encodes the query, including the sort order, in the
+// "@odata.nextLink" of each page, so the order is kept across pages.
+func (s *entityService[T]) List(filter requestBuilder.Filter, orderBy ...requestBuilder.OrderBy) (view.EntityList[T], error) {
+	return s.ListContext(context.Background(), filter, orderBy...)
+}
+
+// ListContext retrieves entities like List, abandoning the request for the
+// first page if ctx is cancelled. Subsequent pages are not bound to ctx, as
+// they are fetched on demand after ListContext returns.
+func (s *entityService[T]) ListContext(ctx context.Context, filter requestBuilder.Filter, orderBy ...requestBuilder.OrderBy) (view.EntityList[T], error) {
 
 	//e.g. [Organization URI]/api/data/v9.2/accounts
 	path := s.resourceUrl.String()
-	rb := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
-		AddQueryParam(queryParamKeySelect, s.selects)
-
-	if searchTerm != "" && len(s.searchFields) > 0 {
-		filter := s.buildFilterQuery(searchTerm)
-		rb.AddQueryParam(queryParmKeyFilter, filter)
-	}
-
-	req, err := rb.Build()
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
+		AddQueryParam(queryParamKeySelect, s.selects).
+		AddFilter(filter).
+		AddOrderBy(orderBy...).
+		AddExpand(s.expand...).
+		Build()
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set(headerPrefer, fmt.Sprintf(preferMaxPageSizeFormat, s.pageLimit))
+	req.Header.Set(headerPrefer, s.preferences(fmt.Sprintf(preferMaxPageSizeFormat, s.pageLimit)))
 
-	res, err := s.dataverseService.Execute(req)
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
 	if err != nil {
 
 		return nil, fmt.Errorf("failed to retrieve entities: %w", err)
 	}
 
 	if !res.IsSuccessful {
-		errMsg := s.ParseErrorMessage(res.Body)
-		return nil, errors.New(errMsg)
+		return nil, NewDataverseError(res)
 	}
 
-	gmr := &model.GetManyResponse[T]{}
-	err = json.Unmarshal(res.Body, gmr)
+	gmr, err := s.decodeEntityCollection(res.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -193,109 +405,369 @@ func (s *entityService[T]) List(searchTerm string) (view.EntityList[T], error) {
 
 // Get retrieves a single entity by its unique identifier (GUID).
 func (s *entityService[T]) Get(guid string) (T, error) {
+	return s.GetContext(context.Background(), guid)
+}
+
+// GetContext retrieves a single entity like Get, abandoning the request if ctx
+// is cancelled.
+func (s *entityService[T]) GetContext(ctx context.Context, guid string) (T, error) {
 
 	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
 	path := s.buildUrlWithGuid(guid)
 	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
 		AddQueryParam(queryParamKeySelect, s.selects).
+		AddExpand(s.expand...).
 		Build()
 	if err != nil {
 		return s.zeroValue, err
 	}
 
-	res, err := s.dataverseService.Execute(req)
+	if prefer := s.preferences(); prefer != "" {
+		req.Header.Set(headerPrefer, prefer)
+	}
+
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
 	if err != nil {
 		return s.zeroValue, fmt.Errorf("failed to retrieve entity (%s): %w", guid, err)
 	}
 
 	if !res.IsSuccessful {
-		errMsg := s.ParseErrorMessage(res.Body)
-		return s.zeroValue, errors.New(errMsg)
-	}
-
-	var entity T
-	if err := json.Unmarshal(res.Body, &entity); err != nil {
-		return s.zeroValue, fmt.Errorf("failed to unmarshal retrieved entity: %w", err)
+		return s.zeroValue, NewDataverseError(res)
 	}
 
+	entity, err := s.decodeEntity(res.Body)
 	if err != nil {
-		return s.zeroValue, err
+		return s.zeroValue, fmt.Errorf("failed to unmarshal retrieved entity: %w", err)
 	}
 	return entity, nil
 }
 
 // Update modifies an existing entity identified by GUID with the properties
-// from entityToUpdate.
+// from entityToUpdate. The update is conditional on the entity's captured
+// ETag, if any.
 func (s *entityService[T]) Update(guid string, entityToUpdate T) error {
+	return s.UpdateContext(context.Background(), guid, entityToUpdate)
+}
 
-	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
-	path := s.buildUrlWithGuid(guid)
-	payload, err := json.Marshal(entityToUpdate)
-
+// UpdateContext modifies an existing entity like Update, abandoning the
+// request if ctx is cancelled.
+func (s *entityService[T]) UpdateContext(ctx context.Context, guid string, entityToUpdate T) error {
+	req, err := s.NewUpdateRequest(guid, entityToUpdate)
 	if err != nil {
-		return fmt.Errorf("failed to serialise entity %w", err)
+		return err
 	}
-	req, err := requestBuilder.NewRequestBuilder(http.MethodPatch, path, bytes.NewReader(payload)).
-		Build()
+	return s.executeUpdate(ctx, guid, req)
+}
+
+// Overwrite modifies an existing entity identified by GUID with the properties
+// from entityToUpdate, without checking whether it has changed.
+func (s *entityService[T]) Overwrite(guid string, entityToUpdate T) error {
+	return s.OverwriteContext(context.Background(), guid, entityToUpdate)
+}
+
+// OverwriteContext modifies an existing entity like Overwrite, abandoning the
+// request if ctx is cancelled.
+func (s *entityService[T]) OverwriteContext(ctx context.Context, guid string, entityToUpdate T) error {
+	req, err := s.NewUpdateRequest(guid, entityToUpdate)
 	if err != nil {
 		return err
 	}
+	req.Header.Del(headerIfMatch)
+	return s.executeUpdate(ctx, guid, req)
+}
 
-	req.Header.Set(headerContentType, contentTypeJSON)
-
-	res, err := s.dataverseService.Execute(req)
+// executeUpdate sends an update request. The captured ETag is discarded after
+// a successful update, as the entity's version has changed.
+func (s *entityService[T]) executeUpdate(ctx context.Context, guid string, req *http.Request) error {
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
 	if err != nil {
 		return err
 	}
 
 	if !res.IsSuccessful {
-		errMsg := s.ParseErrorMessage(res.Body)
-		return errors.New(errMsg)
+		return NewDataverseError(res)
 	}
+
+	s.forgetETag(guid)
 	return nil
 }
 
 // Delete removes an entity identified by its GUID.
 func (s *entityService[T]) Delete(guid string) error {
+	return s.DeleteContext(context.Background(), guid)
+}
 
-	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
-	path := s.buildUrlWithGuid(guid)
-	req, err := requestBuilder.NewRequestBuilder(http.MethodDelete, path, nil).
-		Build()
+// DeleteContext removes an entity like Delete, abandoning the request if ctx
+// is cancelled.
+func (s *entityService[T]) DeleteContext(ctx context.Context, guid string) error {
+	req, err := s.NewDeleteRequest(guid)
+	if err != nil {
+		return err
+	}
+	return s.executeDelete(ctx, guid, req)
+}
+
+// ForceDelete removes an entity identified by its GUID, without checking
+// whether it has changed.
+func (s *entityService[T]) ForceDelete(guid string) error {
+	return s.ForceDeleteContext(context.Background(), guid)
+}
+
+// ForceDeleteContext removes an entity like ForceDelete, abandoning the
+// request if ctx is cancelled.
+func (s *entityService[T]) ForceDeleteContext(ctx context.Context, guid string) error {
+	req, err := s.NewDeleteRequest(guid)
 	if err != nil {
 		return err
 	}
+	req.Header.Del(headerIfMatch)
+	return s.executeDelete(ctx, guid, req)
+}
 
-	res, err := s.dataverseService.Execute(req)
+// executeDelete sends a delete request. The captured ETag is discarded after
+// a successful delete.
+func (s *entityService[T]) executeDelete(ctx context.Context, guid string, req *http.Request) error {
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to delete entity (%s): %w", guid, err)
 	}
 
 	if !res.IsSuccessful {
-		errMsg := s.ParseErrorMessage(res.Body)
-		return errors.New(errMsg)
+		return NewDataverseError(res)
 	}
+
+	s.forgetETag(guid)
 	return nil
 }
 
+// NewCreateRequest builds a POST request that creates entity and returns its
+// representation.
+func (s *entityService[T]) NewCreateRequest(entity T) (*http.Request, error) {
+
+	//e.g. [Organization URI]/api/data/v9.2/accounts
+	path := s.resourceUrl.String()
+	payload, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise entity %w", err)
+	}
+
+	return requestBuilder.NewRequestBuilder(http.MethodPost, path, bytes.NewReader(payload)).
+		AddHeader(headerContentType, contentTypeJSON).
+		AddHeader(headerPrefer, s.preferences(preferReturnRepresentation)).
+		Build()
+}
+
+// NewUpdateRequest builds a PATCH request that updates the entity identified
+// by guid with the properties from entityToUpdate.
+func (s *entityService[T]) NewUpdateRequest(guid string, entityToUpdate T) (*http.Request, error) {
+
+	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
+	path := s.buildUrlWithGuid(guid)
+	payload, err := json.Marshal(entityToUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise entity %w", err)
+	}
+
+	rb := requestBuilder.NewRequestBuilder(http.MethodPatch, path, bytes.NewReader(payload)).
+		AddHeader(headerContentType, contentTypeJSON)
+	s.addIfMatchHeader(rb, guid)
+	return rb.Build()
+}
+
+// NewDeleteRequest builds a DELETE request for the entity identified by guid.
+// The request is conditional on the entity's captured ETag, if any.
+func (s *entityService[T]) NewDeleteRequest(guid string) (*http.Request, error) {
+
+	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
+	path := s.buildUrlWithGuid(guid)
+	rb := requestBuilder.NewRequestBuilder(http.MethodDelete, path, nil)
+	s.addIfMatchHeader(rb, guid)
+	return rb.Build()
+}
+
+// addIfMatchHeader makes the request conditional on the captured ETag for
+// guid. No header is added if no ETag has been captured, or it has been
+// discarded because many other entities have been seen since.
+func (s *entityService[T]) addIfMatchHeader(rb requestBuilder.RequestBuilder, guid string) {
+	if etag, ok := s.etags.get(guid); ok {
+		rb.AddHeader(headerIfMatch, etag)
+	}
+}
+
+// storeETag records the ETag for guid. Empty values are ignored.
+func (s *entityService[T]) storeETag(guid, etag string) {
+	if guid == "" || etag == "" {
+		return
+	}
+	s.etags.set(guid, etag)
+}
+
+// forgetETag discards the ETag recorded for guid.
+func (s *entityService[T]) forgetETag(guid string) {
+	s.etags.remove(guid)
+}
+
+// decodeEntity unmarshals a single entity and records its ETag.
+func (s *entityService[T]) decodeEntity(data []byte) (T, error) {
+	entity, err := s.initialEntity()
+	if err != nil {
+		return s.zeroValue, err
+	}
+	if err := json.Unmarshal(data, &entity); err != nil {
+		return s.zeroValue, err
+	}
+
+	var tag entityTag
+	if err := json.Unmarshal(data, &tag); err == nil {
+		s.storeETag(entity.ID(), tag.ETag)
+	}
+	return entity, nil
+}
+
+// initialEntity returns the value into which an entity is decoded, created
+// with the NewEntity option if set.
+func (s *entityService[T]) initialEntity() (T, error) {
+	if s.newEntity == nil {
+		return s.zeroValue, nil
+	}
+	entity, ok := s.newEntity().(T)
+	if !ok {
+		return s.zeroValue, fmt.Errorf("NewEntity returned %T, want %T", s.newEntity(), s.zeroValue)
+	}
+	return entity, nil
+}
+
+// decodeEntityCollection unmarshals a page of entities, recording the ETag of
+// each.
+func (s *entityService[T]) decodeEntityCollection(data []byte) (*model.GetManyResponse[T], error) {
+	raw := model.GetManyResponse[json.RawMessage]{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	gmr := &model.GetManyResponse[T]{
+		Next: raw.Next,
+		Data: make([]T, len(raw.Data)),
+	}
+	for i, r := range raw.Data {
+		entity, err := s.decodeEntity(r)
+		if err != nil {
+			return nil, err
+		}
+		gmr.Data[i] = entity
+	}
+	return gmr, nil
+}
+
+// Upsert creates or updates the entity identified by key. The API reports
+// 201 Created for a new record and 200 OK for an updated one when the
+// representation is requested, which is used to populate UpsertResult.Created.
+func (s *entityService[T]) Upsert(key requestBuilder.AlternateKey, entity T, mode UpsertMode) (UpsertResult[T], error) {
+	return s.UpsertContext(context.Background(), key, entity, mode)
+}
+
+// UpsertContext creates or updates an entity like Upsert, abandoning the
+// request if ctx is cancelled.
+func (s *entityService[T]) UpsertContext(ctx context.Context, key requestBuilder.AlternateKey, entity T, mode UpsertMode) (UpsertResult[T], error) {
+	req, err := s.NewUpsertRequest(key, entity, mode)
+	if err != nil {
+		return UpsertResult[T]{}, err
+	}
+
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return UpsertResult[T]{}, fmt.Errorf("failed to upsert entity: %w", err)
+	}
+
+	if !res.IsSuccessful {
+		return UpsertResult[T]{}, s.upsertError(res, mode)
+	}
+
+	upserted, err := s.decodeEntity(res.Body)
+	if err != nil {
+		return UpsertResult[T]{}, fmt.Errorf("failed to unmarshal upserted entity: %w", err)
+	}
+
+	return UpsertResult[T]{
+		Entity:  upserted,
+		Created: res.StatusCode == http.StatusCreated,
+	}, nil
+}
+
+// NewUpsertRequest builds a PATCH request addressing the entity by alternate
+// key, e.g. [Organization URI]/api/data/v9.2/accounts(accountnumber='X').
+func (s *entityService[T]) NewUpsertRequest(key requestBuilder.AlternateKey, entity T, mode UpsertMode) (*http.Request, error) {
+	segment, err := key.Segment()
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.buildUrlWithKey(segment)
+	payload, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise entity %w", err)
+	}
+
+	rb := requestBuilder.NewRequestBuilder(http.MethodPatch, path, bytes.NewReader(payload)).
+		AddHeader(headerContentType, contentTypeJSON).
+		AddHeader(headerPrefer, s.preferences(preferReturnRepresentation))
+
+	switch mode {
+	case UpsertCreateOnly:
+		rb.AddHeader(headerIfNoneMatch, matchAny)
+	case UpsertUpdateOnly:
+		rb.AddHeader(headerIfMatch, matchAny)
+	}
+	return rb.Build()
+}
+
+// upsertError converts an unsuccessful upsert response into an error. The
+// precondition failure caused by a create-only upsert is reported as a
+// DataverseError matching ErrAlreadyExists rather than
+// ErrConcurrencyConflict; an update-only upsert of a missing record is
+// reported as a DataverseError matching ErrNotFound.
+func (s *entityService[T]) upsertError(res *DataverseResponse, mode UpsertMode) error {
+	if mode == UpsertCreateOnly && res.StatusCode == http.StatusPreconditionFailed {
+		dataverseErr := NewDataverseError(res)
+		dataverseErr.createOnly = true
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, dataverseErr)
+	}
+	return NewDataverseError(res)
+}
+
+// preferences joins the given preferences with the preference requesting the
+// configured annotations, if any, for use in a Prefer header.
+func (s *entityService[T]) preferences(preferences ...string) string {
+	if s.annotations != "" {
+		preferences = append(preferences, fmt.Sprintf(preferIncludeAnnotations, s.annotations))
+	}
+	return strings.Join(preferences, ",")
+}
+
 // buildUrlWithGuid constructs a URL targeting a specific entity by appending
 // its GUID to the resource URL.
 func (s *entityService[T]) buildUrlWithGuid(guid string) string {
-	return fmt.Sprintf("%s(%s)", s.resourceUrl.String(), guid)
+	return s.buildUrlWithKey(guid)
+}
+
+// buildUrlWithKey constructs a URL targeting a specific entity by appending a
+// key segment, either a GUID or an alternate key, to the resource URL.
+func (s *entityService[T]) buildUrlWithKey(key string) string {
+	return fmt.Sprintf("%s(%s)", s.resourceUrl.String(), key)
 }
 
-// buildFilterQuery constructs an OData filter query string from a search term
-// using the configured search fields.
-func (s *entityService[T]) buildFilterQuery(searchTerm string) string {
-	if len(s.searchFields) == 0 {
-		return ""
+// SearchFilter constructs a filter matching entities where any of the
+// configured search fields contain searchTerm.
+func (s *entityService[T]) SearchFilter(searchTerm string) requestBuilder.Filter {
+	if searchTerm == "" || len(s.searchFields) == 0 {
+		return nil
 	}
-	filters := make([]string, len(s.searchFields))
+	filters := make([]requestBuilder.Filter, len(s.searchFields))
 	for i, sf := range s.searchFields {
-		filters[i] = fmt.Sprintf("contains(%s,'%s')", sf, searchTerm)
+		filters[i] = requestBuilder.Contains(sf, searchTerm)
 	}
 
-	return url.QueryEscape(strings.Join(filters, " or "))
+	return requestBuilder.Or(filters...)
 }
 
 // getNextResult fetches the next page of results during list pagination
@@ -306,26 +778,15 @@ func (s *entityService[T]) getNextResult(url string) (*model.GetManyResponse[T],
 		return nil, err
 	}
 
-	req.Header.Set(headerPrefer, fmt.Sprintf(preferMaxPageSizeFormat, s.pageLimit))
+	req.Header.Set(headerPrefer, s.preferences(fmt.Sprintf(preferMaxPageSizeFormat, s.pageLimit)))
 
 	dr, err := s.dataverseService.Execute(req)
 	if err != nil {
 		return nil, err
 	}
-
-	gmr := &model.GetManyResponse[T]{}
-	err = json.Unmarshal(dr.Body, gmr)
-	if err != nil {
-		return nil, err
+	if !dr.IsSuccessful {
+		return nil, NewDataverseError(dr)
 	}
-	return gmr, err
-}
 
-func (s *entityService[T]) ParseErrorMessage(body []byte) string {
-	errMsg := body
-	var errRes model.ErrorResponse
-	if err := json.Unmarshal(errMsg, &errRes); err == nil {
-		errMsg = []byte(errRes.Error.Message)
-	}
-	return string(errMsg)
+	return s.decodeEntityCollection(dr.Body)
 }
@@ -0,0 +1,194 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/model"
+)
+
+// ErrNotFound is returned when the requested record does not exist (HTTP 404
+// Not Found).
+var ErrNotFound = errors.New("the record does not exist")
+
+// ErrAlreadyExists is returned when a create-only upsert targets a record that
+// already exists.
+var ErrAlreadyExists = errors.New("a record with the same key already exists")
+
+// ErrConcurrencyConflict is returned when a conditional update or delete
+// fails because the record has been changed since it was retrieved (HTTP 412
+// Precondition Failed).
+var ErrConcurrencyConflict = errors.New("the record has been changed by another user")
+
+// ErrPrivilegeDenied is returned when the caller lacks the privileges required
+// for the operation (HTTP 403 Forbidden).
+var ErrPrivilegeDenied = errors.New("the user does not have the required privileges")
+
+// ErrThrottled is returned when a request is rejected by Dataverse service
+// protection limits (HTTP 429 Too Many Requests).
+var ErrThrottled = errors.New("the request was throttled by service protection limits")
+
+// ErrDuplicate is returned when a record is rejected because it duplicates an
+// existing record, either by alternate key or by a duplicate detection rule.
+var ErrDuplicate = errors.New("a duplicate record already exists")
+
+// ErrAuthentication is returned when an access token cannot be acquired for a
+// request. Requests failing with this error are not retried.
+var ErrAuthentication = errors.New("failed to acquire an access token")
+
+// Dataverse error codes used to classify a DataverseError.
+const (
+	errorCodeRecordNotFound          = "0x80040217"
+	errorCodePrivilegeDenied         = "0x80040220"
+	errorCodeDuplicateRecord         = "0x80040237"
+	errorCodeDuplicateDetected       = "0x80040333"
+	errorCodeDuplicateAlternateKey   = "0x80060892"
+	errorCodeConcurrencyVersionCheck = "0x80060882"
+)
+
+// Response headers identifying a request in Dataverse logs.
+const (
+	headerServiceRequestID = "x-ms-service-request-id"
+	headerReqID            = "REQ_ID"
+)
+
+// DataverseError describes an unsuccessful response from the Dataverse Web
+// API.
+//
+// Use errors.As to access the details of the failure, or errors.Is with the
+// sentinel errors in this package (ErrNotFound, ErrPrivilegeDenied,
+// ErrThrottled, ErrDuplicate, ErrAlreadyExists and ErrConcurrencyConflict) to
+// test its classification, which is derived from the HTTP status code and the
+// OData error code.
+type DataverseError struct {
+	// StatusCode contains the HTTP status code of the response
+	StatusCode int
+	// Code contains the OData error code, e.g. "0x80040217"
+	Code string
+	// Message contains the human-readable error message. If the response was
+	// not an OData error, it contains the raw response body
+	Message string
+	// Annotations contains the @Microsoft.PowerApps.CDS.* annotations included
+	// with the error, keyed by annotation name without the leading "@"
+	Annotations map[string]string
+	// RequestID contains the value of the x-ms-service-request-id header,
+	// which identifies the request when raising a support ticket
+	RequestID string
+	// ReqID contains the value of the REQ_ID header
+	ReqID string
+	// createOnly is set if the request was conditional on the record not
+	// existing (If-None-Match: *), so that a precondition failure means the
+	// record already exists rather than that it has changed
+	createOnly bool
+}
+
+// Error returns the error message, or a description of the status code if the
+// response contained no message.
+func (e *DataverseError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("request failed with status %d %s",
+		e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is reports whether the error belongs to the category represented by
+// target.
+func (e *DataverseError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound ||
+			e.Code == errorCodeRecordNotFound
+	case ErrPrivilegeDenied:
+		return e.StatusCode == http.StatusForbidden ||
+			e.Code == errorCodePrivilegeDenied
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			isServiceProtectionCode(e.Code)
+	case ErrDuplicate:
+		return e.Code == errorCodeDuplicateRecord ||
+			e.Code == errorCodeDuplicateDetected ||
+			e.Code == errorCodeDuplicateAlternateKey
+	case ErrAlreadyExists:
+		return e.createOnly && e.StatusCode == http.StatusPreconditionFailed
+	case ErrConcurrencyConflict:
+		return !e.createOnly && (e.StatusCode == http.StatusPreconditionFailed ||
+			e.Code == errorCodeConcurrencyVersionCheck)
+	default:
+		return false
+	}
+}
+
+// NewDataverseError creates a DataverseError from an unsuccessful response.
+// It is exported for packages that send their own requests with a
+// DataverseService, so that their failures can be classified in the same way.
+func NewDataverseError(res *DataverseResponse) *DataverseError {
+	return newDataverseErrorFromParts(res.StatusCode, res.Header, res.Body)
+}
+
+// newDataverseErrorFromParts creates a DataverseError from the status code,
+// headers and body of an unsuccessful response. If the body is not an OData
+// error, it is used as the message.
+func newDataverseErrorFromParts(statusCode int, header http.Header, body []byte) *DataverseError {
+	dvErr := &DataverseError{
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RequestID:  header.Get(headerServiceRequestID),
+		ReqID:      header.Get(headerReqID),
+	}
+
+	var errRes model.ErrorResponse
+	if err := json.Unmarshal(body, &errRes); err == nil {
+		dvErr.Code = errRes.Error.Code
+		dvErr.Message = errRes.Error.Message
+		dvErr.Annotations = errRes.Error.Annotations
+	}
+	return dvErr
+}
+
+// IsNotFound reports whether err indicates that the record does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsPrivilegeDenied reports whether err indicates that the caller lacks the
+// privileges required for the operation.
+func IsPrivilegeDenied(err error) bool {
+	return errors.Is(err, ErrPrivilegeDenied)
+}
+
+// IsThrottled reports whether err indicates that the request was rejected by
+// service protection limits.
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
+// IsDuplicate reports whether err indicates that the record duplicates an
+// existing record.
+func IsDuplicate(err error) bool {
+	return errors.Is(err, ErrDuplicate)
+}
+
+// IsAlreadyExists reports whether err indicates that a create-only upsert
+// targeted a record that already exists.
+func IsAlreadyExists(err error) bool {
+	return errors.Is(err, ErrAlreadyExists)
+}
+
+// IsConcurrencyConflict reports whether err indicates that the record was
+// changed since it was retrieved.
+func IsConcurrencyConflict(err error) bool {
+	return errors.Is(err, ErrConcurrencyConflict)
+}
+
+// AsDataverseError returns the DataverseError in err's chain, if any.
+func AsDataverseError(err error) (*DataverseError, bool) {
+	var dvErr *DataverseError
+	ok := errors.As(err, &dvErr)
+	return dvErr, ok
+}
@@ -0,0 +1,84 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxTrackedETags is the number of ETags an entity service keeps. Once it is
+// reached, the ETag of the entity seen least recently is discarded.
+const maxTrackedETags = 1000
+
+// etagCache holds the most recent "@odata.etag" seen for each entity, keyed
+// by GUID, for use in If-Match headers. It holds at most capacity ETags,
+// discarding the least recently used, so that browsing a large table does
+// not grow it without limit. An update or delete of an entity whose ETag has
+// been discarded is sent unconditionally.
+type etagCache struct {
+	mu       sync.Mutex
+	capacity int
+	// order lists the entries, most recently used first
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// etagEntry is an element of etagCache.order.
+type etagEntry struct {
+	guid string
+	etag string
+}
+
+// newETagCache creates an etagCache holding at most capacity ETags.
+func newETagCache(capacity int) *etagCache {
+	return &etagCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the ETag recorded for guid, and false if there is none.
+func (c *etagCache) get(guid string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[guid]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*etagEntry).etag, true
+}
+
+// set records the ETag for guid, discarding the least recently used ETag if
+// the cache is full.
+func (c *etagCache) set(guid, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[guid]; ok {
+		element.Value.(*etagEntry).etag = etag
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[guid] = c.order.PushFront(&etagEntry{guid: guid, etag: etag})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*etagEntry).guid)
+	}
+}
+
+// remove discards the ETag recorded for guid.
+func (c *etagCache) remove(guid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[guid]; ok {
+		c.order.Remove(element)
+		delete(c.entries, guid)
+	}
+}
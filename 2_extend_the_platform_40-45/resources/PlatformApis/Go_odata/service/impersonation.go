@@ -0,0 +1,122 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Headers identifying the user a request is made on behalf of.
+const (
+	callerObjectIDHeader = "CallerObjectId"
+	callerIDHeader       = "MSCRMCallerID"
+)
+
+// Caller identifies a Dataverse user to impersonate. Requests are made on
+// behalf of the user, so that their security roles apply and plugins run in
+// their context. The authenticated identity must hold the "Act on Behalf of
+// Another User" privilege.
+type Caller struct {
+	// ObjectID is the user's Microsoft Entra ID object ID, the
+	// azureactivedirectoryobjectid column of the systemuser table. It is
+	// sent in the CallerObjectId header
+	ObjectID string
+
+	// SystemUserID is the user's systemuserid. It is sent in the
+	// MSCRMCallerID header if ObjectID is empty
+	SystemUserID string
+}
+
+// IsZero returns true if the caller identifies no user.
+func (c Caller) IsZero() bool {
+	return c.ObjectID == "" && c.SystemUserID == ""
+}
+
+// setHeader sets the impersonation header identifying the caller on req,
+// removing any previously set.
+func (c Caller) setHeader(req *http.Request) {
+	req.Header.Del(callerObjectIDHeader)
+	req.Header.Del(callerIDHeader)
+
+	switch {
+	case c.ObjectID != "":
+		req.Header.Set(callerObjectIDHeader, c.ObjectID)
+	case c.SystemUserID != "":
+		req.Header.Set(callerIDHeader, c.SystemUserID)
+	}
+}
+
+// callerContextKey is the context key of a per-request caller.
+type callerContextKey struct{}
+
+// WithCaller returns a copy of ctx under which requests are made on behalf
+// of caller, in place of any caller set with DataverseService.Impersonate.
+// A zero Caller makes requests as the authenticated identity.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, caller)
+}
+
+// WithoutCaller returns a copy of ctx under which requests are made as the
+// authenticated identity, ignoring any caller set with
+// DataverseService.Impersonate.
+func WithoutCaller(ctx context.Context) context.Context {
+	return WithCaller(ctx, Caller{})
+}
+
+// CallerFromContext returns the caller set on ctx with WithCaller or
+// WithoutCaller, and false if none is set.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerContextKey{}).(Caller)
+	return caller, ok
+}
+
+// impersonation holds the caller that a service's requests are made on
+// behalf of for the rest of the session.
+type impersonation struct {
+	mu     sync.Mutex
+	caller Caller
+}
+
+// get returns the session's caller, which is zero when not impersonating.
+func (i *impersonation) get() Caller {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.caller
+}
+
+// set replaces the session's caller.
+func (i *impersonation) set(caller Caller) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.caller = caller
+}
+
+// Impersonate makes subsequent requests on behalf of caller, unless a
+// request's context sets another caller with WithCaller or WithoutCaller.
+func (s dataverseService) Impersonate(caller Caller) {
+	s.impersonation.set(caller)
+}
+
+// StopImpersonating makes subsequent requests as the authenticated identity.
+func (s dataverseService) StopImpersonating() {
+	s.impersonation.set(Caller{})
+}
+
+// Impersonation returns the caller set with Impersonate, and false if the
+// service is not impersonating a user.
+func (s dataverseService) Impersonation() (Caller, bool) {
+	caller := s.impersonation.get()
+	return caller, !caller.IsZero()
+}
+
+// withSessionCaller returns req, or a copy of req whose context sets the
+// session's caller if its context does not already set a caller, so that
+// CallerMiddleware identifies the caller of every request.
+func (s dataverseService) withSessionCaller(req *http.Request) *http.Request {
+	if _, ok := CallerFromContext(req.Context()); ok {
+		return req
+	}
+	return req.WithContext(WithCaller(req.Context(), s.impersonation.get()))
+}
@@ -0,0 +1,298 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/turnerbenjamin/go_odata/model"
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+)
+
+// Resource paths and properties of the metadata endpoints.
+const (
+	entityDefinitionsPath       = "EntityDefinitions"
+	attributesPath              = "Attributes"
+	metadataPropertyLogicalName = "LogicalName"
+	metadataPropertyOptions     = "Options"
+	navigationOptionSet         = "OptionSet"
+	navigationGlobalOptionSet   = "GlobalOptionSet"
+)
+
+// ErrNotChoice is returned when requesting the options of a column that is
+// not a choice column.
+var ErrNotChoice = errors.New("column is not a choice column")
+
+// entityDefinitionSelects lists the EntityDefinition properties retrieved by
+// MetadataService.
+var entityDefinitionSelects = []string{
+	"MetadataId",
+	"LogicalName",
+	"EntitySetName",
+	"PrimaryIdAttribute",
+	"PrimaryNameAttribute",
+	"DisplayName",
+	"DisplayCollectionName",
+}
+
+// attributeDefinitionSelects lists the AttributeDefinition properties
+// retrieved by MetadataService.
+var attributeDefinitionSelects = []string{
+	"MetadataId",
+	"LogicalName",
+	"AttributeType",
+	"DisplayName",
+	"RequiredLevel",
+	"IsValidForCreate",
+	"IsValidForUpdate",
+	"IsValidForRead",
+	"AttributeTypeName",
+}
+
+// MetadataService provides read access to the definitions of Dataverse
+// tables and columns.
+type MetadataService interface {
+	// EntityDefinitions retrieves the definitions of tables that can be
+	// queried, i.e. those valid for Advanced Find.
+	EntityDefinitions() ([]model.EntityDefinition, error)
+
+	// EntityDefinitionsContext retrieves table definitions like
+	// EntityDefinitions, abandoning the request if ctx is cancelled.
+	EntityDefinitionsContext(ctx context.Context) ([]model.EntityDefinition, error)
+
+	// EntityDefinition retrieves the definition of the table with the given
+	// logical name.
+	EntityDefinition(logicalName string) (*model.EntityDefinition, error)
+
+	// EntityDefinitionContext retrieves a table definition like
+	// EntityDefinition, abandoning the request if ctx is cancelled.
+	EntityDefinitionContext(ctx context.Context, logicalName string) (*model.EntityDefinition, error)
+
+	// Attributes retrieves the definitions of the readable columns of the
+	// table with the given logical name. Columns that are part of another
+	// column, such as the name of a lookup, are excluded.
+	Attributes(logicalName string) ([]model.AttributeDefinition, error)
+
+	// AttributesContext retrieves column definitions like Attributes,
+	// abandoning the request if ctx is cancelled.
+	AttributesContext(ctx context.Context, logicalName string) ([]model.AttributeDefinition, error)
+
+	// ChoiceOptions retrieves the options of a choice column, including
+	// multi-select choice and status columns, of the table with the given
+	// logical name. Returns ErrNotChoice if the column is not a choice.
+	ChoiceOptions(entityLogicalName string, attribute model.AttributeDefinition) ([]model.OptionMetadata, error)
+
+	// ChoiceOptionsContext retrieves the options of a choice column like
+	// ChoiceOptions, abandoning the request if ctx is cancelled.
+	ChoiceOptionsContext(ctx context.Context, entityLogicalName string, attribute model.AttributeDefinition) ([]model.OptionMetadata, error)
+}
+
+// MetadataServiceOptions contains configuration parameters for creating a
+// MetadataService instance
+type MetadataServiceOptions struct {
+	// DataverseService handles the actual HTTP communication with the API
+	DataverseService DataverseService
+
+	// BaseUrl is the root URL of the API
+	BaseUrl *url.URL
+}
+
+// metadataService implements MetadataService using the EntityDefinitions
+// endpoint of the Web API.
+type metadataService struct {
+	dataverseService     DataverseService
+	entityDefinitionsUrl *url.URL
+}
+
+// NewMetadataService creates a new MetadataService with the provided options.
+func NewMetadataService(options MetadataServiceOptions) MetadataService {
+	entityDefinitionsUrl := *options.BaseUrl
+	entityDefinitionsUrl.Path = path.Join(entityDefinitionsUrl.Path, entityDefinitionsPath)
+
+	return &metadataService{
+		dataverseService:     options.DataverseService,
+		entityDefinitionsUrl: &entityDefinitionsUrl,
+	}
+}
+
+// EntityDefinitions retrieves the definitions of tables that can be queried.
+func (s *metadataService) EntityDefinitions() ([]model.EntityDefinition, error) {
+	return s.EntityDefinitionsContext(context.Background())
+}
+
+// EntityDefinitionsContext retrieves the definitions of tables that can be
+// queried, abandoning the request if ctx is cancelled.
+func (s *metadataService) EntityDefinitionsContext(ctx context.Context) ([]model.EntityDefinition, error) {
+	//e.g. [Organization URI]/api/data/v9.2/EntityDefinitions
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, s.entityDefinitionsUrl.String(), nil).
+		AddQueryParam(queryParamKeySelect, strings.Join(entityDefinitionSelects, ",")).
+		AddFilter(requestBuilder.Eq("IsValidForAdvancedFind", requestBuilder.Bool(true))).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := getMetadata[model.EntityDefinition](ctx, s.dataverseService, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve table definitions: %w", err)
+	}
+	return definitions, nil
+}
+
+// EntityDefinition retrieves the definition of a table.
+func (s *metadataService) EntityDefinition(logicalName string) (*model.EntityDefinition, error) {
+	return s.EntityDefinitionContext(context.Background(), logicalName)
+}
+
+// EntityDefinitionContext retrieves the definition of a table, abandoning the
+// request if ctx is cancelled.
+func (s *metadataService) EntityDefinitionContext(ctx context.Context, logicalName string) (*model.EntityDefinition, error) {
+	key, err := requestBuilder.NewAlternateKey(
+		metadataPropertyLogicalName, requestBuilder.String(logicalName)).Segment()
+	if err != nil {
+		return nil, err
+	}
+
+	//e.g. [Organization URI]/api/data/v9.2/EntityDefinitions(LogicalName='account')
+	path := fmt.Sprintf("%s(%s)", s.entityDefinitionsUrl.String(), key)
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
+		AddQueryParam(queryParamKeySelect, strings.Join(entityDefinitionSelects, ",")).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve table definition for %s: %w", logicalName, err)
+	}
+	if !res.IsSuccessful {
+		return nil, NewDataverseError(res)
+	}
+
+	definition := &model.EntityDefinition{}
+	if err := json.Unmarshal(res.Body, definition); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// Attributes retrieves the definitions of the readable columns of a table.
+func (s *metadataService) Attributes(logicalName string) ([]model.AttributeDefinition, error) {
+	return s.AttributesContext(context.Background(), logicalName)
+}
+
+// AttributesContext retrieves the definitions of the readable columns of a
+// table, abandoning the request if ctx is cancelled.
+func (s *metadataService) AttributesContext(ctx context.Context, logicalName string) ([]model.AttributeDefinition, error) {
+	key, err := requestBuilder.NewAlternateKey(
+		metadataPropertyLogicalName, requestBuilder.String(logicalName)).Segment()
+	if err != nil {
+		return nil, err
+	}
+
+	//e.g. [Organization URI]/api/data/v9.2/EntityDefinitions(LogicalName='account')/Attributes
+	path := fmt.Sprintf("%s(%s)/%s", s.entityDefinitionsUrl.String(), key, attributesPath)
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
+		AddQueryParam(queryParamKeySelect, strings.Join(attributeDefinitionSelects, ",")).
+		AddFilter(requestBuilder.And(
+			requestBuilder.Eq("IsValidForRead", requestBuilder.Bool(true)),
+			requestBuilder.IsNull("AttributeOf"),
+		)).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := getMetadata[model.AttributeDefinition](ctx, s.dataverseService, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve column definitions for %s: %w", logicalName, err)
+	}
+	return attributes, nil
+}
+
+// ChoiceOptions retrieves the options of a choice column.
+func (s *metadataService) ChoiceOptions(entityLogicalName string, attribute model.AttributeDefinition) ([]model.OptionMetadata, error) {
+	return s.ChoiceOptionsContext(context.Background(), entityLogicalName, attribute)
+}
+
+// ChoiceOptionsContext retrieves the options of a choice column, abandoning
+// the request if ctx is cancelled. The attribute is cast to its derived
+// metadata type, e.g. PicklistAttributeMetadata, to expand its option sets.
+func (s *metadataService) ChoiceOptionsContext(ctx context.Context, entityLogicalName string, attribute model.AttributeDefinition) ([]model.OptionMetadata, error) {
+	metadataType := attribute.ChoiceMetadataType()
+	if metadataType == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotChoice, attribute.LogicalName)
+	}
+
+	entityKey, err := requestBuilder.NewAlternateKey(
+		metadataPropertyLogicalName, requestBuilder.String(entityLogicalName)).Segment()
+	if err != nil {
+		return nil, err
+	}
+	attributeKey, err := requestBuilder.NewAlternateKey(
+		metadataPropertyLogicalName, requestBuilder.String(attribute.LogicalName)).Segment()
+	if err != nil {
+		return nil, err
+	}
+
+	//e.g. [Organization URI]/api/data/v9.2/EntityDefinitions(LogicalName='account')/Attributes(LogicalName='industrycode')/Microsoft.Dynamics.CRM.PicklistAttributeMetadata
+	path := fmt.Sprintf("%s(%s)/%s(%s)/%s",
+		s.entityDefinitionsUrl.String(), entityKey, attributesPath, attributeKey, metadataType)
+	req, err := requestBuilder.NewRequestBuilder(http.MethodGet, path, nil).
+		AddQueryParam(queryParamKeySelect, metadataPropertyLogicalName).
+		AddExpand(
+			requestBuilder.Expand{
+				NavigationProperty: navigationOptionSet,
+				Select:             []string{metadataPropertyOptions},
+			},
+			requestBuilder.Expand{
+				NavigationProperty: navigationGlobalOptionSet,
+				Select:             []string{metadataPropertyOptions},
+			},
+		).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve options for %s: %w", attribute.LogicalName, err)
+	}
+	if !res.IsSuccessful {
+		return nil, NewDataverseError(res)
+	}
+
+	var metadata model.ChoiceAttributeMetadata
+	if err := json.Unmarshal(res.Body, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata.Options(), nil
+}
+
+// getMetadata executes a request for a metadata collection and decodes the
+// items in its "value" property. Metadata collections are not paged.
+func getMetadata[T any](ctx context.Context, dataverseService DataverseService, req *http.Request) ([]T, error) {
+	res, err := dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !res.IsSuccessful {
+		return nil, NewDataverseError(res)
+	}
+
+	var gmr model.GetManyResponse[T]
+	if err := json.Unmarshal(res.Body, &gmr); err != nil {
+		return nil, err
+	}
+	return gmr.Data, nil
+}
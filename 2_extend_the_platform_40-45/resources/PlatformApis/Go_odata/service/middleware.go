@@ -0,0 +1,241 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/turnerbenjamin/go_odata/msal"
+)
+
+// Middleware wraps the RoundTripper sending a request to Dataverse, so that
+// cross-cutting behaviour such as headers, retries, logging or metrics can be
+// added to every request made by a DataverseService. A middleware calls
+// next.RoundTrip to continue the request, and may inspect or replace the
+// request before and the response after.
+//
+// As with any RoundTripper, a middleware must not modify the request it is
+// given; use req.Clone to change headers.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface, for
+// use when writing middleware.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// DefaultMiddleware returns the built-in middleware used by
+// NewDataverseService when options.Middleware is nil, outermost first:
+//
+//   - RetryMiddleware with options.RetryPolicy, or DefaultRetryPolicy
+//   - AcceptJSONMiddleware
+//   - CallerMiddleware
+//   - AuthMiddleware with options.Client
+//   - TimeoutMiddleware with options.Timeout, or a default of 30 seconds
+//
+// To add, remove, replace or reorder middleware, modify the returned list
+// and set it as options.Middleware. Middleware placed before RetryMiddleware
+// sees each request once however many times it is retried; middleware placed
+// after AuthMiddleware runs for each attempt and sees its final headers.
+func DefaultMiddleware(options DataverseServiceOptions) []Middleware {
+	retryPolicy := DefaultRetryPolicy()
+	if options.RetryPolicy != nil {
+		retryPolicy = *options.RetryPolicy
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return []Middleware{
+		RetryMiddleware(retryPolicy),
+		AcceptJSONMiddleware(),
+		CallerMiddleware(),
+		AuthMiddleware(options.Client),
+		TimeoutMiddleware(timeout),
+	}
+}
+
+// chainMiddleware wraps base with middleware, so that the first middleware
+// is the outermost and sees each request first.
+func chainMiddleware(base http.RoundTripper, middleware ...Middleware) http.RoundTripper {
+	rt := base
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt = middleware[i](rt)
+	}
+	return rt
+}
+
+// AuthMiddleware acquires an access token from client for each attempt,
+// using a cached token while it is valid, and sends it as a Bearer token in
+// the Authorization header. Token acquisition is abandoned if the request's
+// context is cancelled. Failures are wrapped with ErrAuthentication, so that
+// RetryMiddleware does not prompt for sign in again.
+func AuthMiddleware(client msal.DataverseClient) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			accessToken, err := client.AcquireTokenContext(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrAuthentication, err)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set(authHeader, bearerTokenPrefix+accessToken)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// AcceptJSONMiddleware requests a JSON response unless the request already
+// sets an Accept header.
+func AcceptJSONMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(acceptHeader) != "" {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set(acceptHeader, contentTypeJSON)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// RetryMiddleware sends requests failing with transient errors again
+// according to policy. The request body is buffered, if it cannot already
+// be replayed, so that it can be sent on each attempt. Waits between attempts
+// are abandoned if the request's context is cancelled.
+//
+// To decide whether to retry, the response body is read into memory; the
+// returned response's body reads from that copy.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req, err := withReplayableBody(req)
+			if err != nil {
+				return nil, err
+			}
+
+			for attempt := 1; ; attempt++ {
+				res, dr, err := roundTripBuffered(next, req)
+
+				delay, retry := policy.retryDelay(req, dr, err, attempt)
+				if !retry {
+					return res, err
+				}
+				if err := sleepContext(req.Context(), delay); err != nil {
+					return nil, err
+				}
+
+				if req.GetBody != nil {
+					req = req.Clone(req.Context())
+					req.Body, err = req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		})
+	}
+}
+
+// roundTripBuffered sends a single attempt of req and reads the response
+// body, replacing it with an in-memory copy.
+// Returns the response and a DataverseResponse describing it, for
+// RetryPolicy, or the error of the attempt.
+func roundTripBuffered(next http.RoundTripper, req *http.Request) (*http.Response, *DataverseResponse, error) {
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dr, err := readDataverseResponse(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(dr.Body))
+	return res, dr, nil
+}
+
+// TimeoutMiddleware limits the time taken by each attempt, including reading
+// the response body, to timeout. Use request contexts to limit the total time
+// taken, including retries.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			res, err := next.RoundTrip(req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+			return res, nil
+		})
+	}
+}
+
+// cancelOnClose cancels the context of an attempt once its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt's context.
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// CallerMiddleware sets the impersonation header identifying the caller set
+// on the request's context with WithCaller or WithoutCaller. A
+// DataverseService sets the caller of each request without one to the
+// session's caller, set with Impersonate, before the middleware runs.
+func CallerMiddleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			caller, ok := CallerFromContext(req.Context())
+			if !ok {
+				return next.RoundTrip(req)
+			}
+
+			req = req.Clone(req.Context())
+			caller.setHeader(req)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// withReplayableBody returns req, or a copy of req whose body can be read
+// again on retry if it has a body but no GetBody function, in which case the
+// body is buffered in memory.
+func withReplayableBody(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return req, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return req, nil
+}
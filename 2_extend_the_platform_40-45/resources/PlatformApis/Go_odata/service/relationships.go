@@ -0,0 +1,152 @@
+// Package service provides interfaces and implementations for data access
+// operations against OData endpoints. It offers generic entity services
+// that handle CRUD operations with support for pagination and filtering.
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	requestBuilder "github.com/turnerbenjamin/go_odata/request_builder"
+)
+
+// Annotations used in association request bodies.
+const (
+	annotationODataBind = "@odata.bind"
+	annotationODataID   = "@odata.id"
+)
+
+// Relationship identifies the navigation property used to link an entity to
+// other entities.
+type Relationship struct {
+	// NavigationProperty is the name of the navigation property on the
+	// source entity, e.g. "primarycontactid" or "contact_customer_accounts"
+	NavigationProperty string
+
+	// IsCollection is true for collection-valued navigation properties,
+	// including both sides of a many-to-many relationship, and false for
+	// single-valued lookups
+	IsCollection bool
+}
+
+// Associate links two entities through a navigation property.
+func (s *entityService[T]) Associate(guid string, relationship Relationship, target requestBuilder.EntityReference) error {
+	return s.AssociateContext(context.Background(), guid, relationship, target)
+}
+
+// AssociateContext links two entities through a navigation property,
+// abandoning the request if ctx is cancelled.
+func (s *entityService[T]) AssociateContext(ctx context.Context, guid string, relationship Relationship, target requestBuilder.EntityReference) error {
+	req, err := s.NewAssociateRequest(guid, relationship, target)
+	if err != nil {
+		return err
+	}
+	return s.executeRelationshipRequest(ctx, guid, relationship, req)
+}
+
+// NewAssociateRequest builds the request that links two entities.
+//
+// A single-valued lookup is set with a PATCH binding the navigation property,
+// e.g. {"primarycontactid@odata.bind": "/contacts(guid)"}. An entity is added
+// to a collection with a POST to the collection's $ref, e.g.
+// accounts(guid)/contact_customer_accounts/$ref.
+func (s *entityService[T]) NewAssociateRequest(guid string, relationship Relationship, target requestBuilder.EntityReference) (*http.Request, error) {
+	targetPath, err := target.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	if !relationship.IsCollection {
+		//e.g. [Organization URI]/api/data/v9.2/accounts(guid)
+		payload, err := json.Marshal(map[string]string{
+			relationship.NavigationProperty + annotationODataBind: "/" + targetPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// If-Match: * prevents the PATCH from creating the source entity when
+		// it does not exist
+		return requestBuilder.NewRequestBuilder(http.MethodPatch, s.buildUrlWithGuid(guid), bytes.NewReader(payload)).
+			AddHeader(headerContentType, contentTypeJSON).
+			AddHeader(headerIfMatch, matchAny).
+			Build()
+	}
+
+	refPath, err := requestBuilder.NavigationRefPath(relationship.NavigationProperty, "")
+	if err != nil {
+		return nil, err
+	}
+
+	targetUrl := s.baseUrl.JoinPath(targetPath)
+	payload, err := json.Marshal(map[string]string{
+		annotationODataID: targetUrl.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//e.g. [Organization URI]/api/data/v9.2/accounts(guid)/contact_customer_accounts/$ref
+	path := s.buildUrlWithGuid(guid) + "/" + refPath
+	return requestBuilder.NewRequestBuilder(http.MethodPost, path, bytes.NewReader(payload)).
+		AddHeader(headerContentType, contentTypeJSON).
+		Build()
+}
+
+// Disassociate removes the link between two entities.
+func (s *entityService[T]) Disassociate(guid string, relationship Relationship, target requestBuilder.EntityReference) error {
+	return s.DisassociateContext(context.Background(), guid, relationship, target)
+}
+
+// DisassociateContext removes the link between two entities, abandoning the
+// request if ctx is cancelled.
+func (s *entityService[T]) DisassociateContext(ctx context.Context, guid string, relationship Relationship, target requestBuilder.EntityReference) error {
+	req, err := s.NewDisassociateRequest(guid, relationship, target)
+	if err != nil {
+		return err
+	}
+	return s.executeRelationshipRequest(ctx, guid, relationship, req)
+}
+
+// NewDisassociateRequest builds the DELETE request that removes the link
+// between two entities, addressing the reference through the navigation
+// property, e.g. accounts(guid)/primarycontactid/$ref or
+// accounts(guid)/contact_customer_accounts(guid)/$ref.
+func (s *entityService[T]) NewDisassociateRequest(guid string, relationship Relationship, target requestBuilder.EntityReference) (*http.Request, error) {
+	targetID := ""
+	if relationship.IsCollection {
+		targetID = target.ID
+		if targetID == "" {
+			return nil, fmt.Errorf("disassociating %s requires a target", relationship.NavigationProperty)
+		}
+	}
+
+	refPath, err := requestBuilder.NavigationRefPath(relationship.NavigationProperty, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.buildUrlWithGuid(guid) + "/" + refPath
+	return requestBuilder.NewRequestBuilder(http.MethodDelete, path, nil).Build()
+}
+
+// executeRelationshipRequest sends an association request. Setting or
+// clearing a single-valued lookup changes the source entity, so its captured
+// ETag is discarded.
+func (s *entityService[T]) executeRelationshipRequest(ctx context.Context, guid string, relationship Relationship, req *http.Request) error {
+	res, err := s.dataverseService.ExecuteContext(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to update %s of entity (%s): %w", relationship.NavigationProperty, guid, err)
+	}
+
+	if !res.IsSuccessful {
+		return NewDataverseError(res)
+	}
+
+	if !relationship.IsCollection {
+		s.forgetETag(guid)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package service provides functionality for interacting with Microsoft
+// Dataverse APIs.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/turnerbenjamin/go_odata/model"
+)
+
+// headerRetryAfter is the response header giving the time to wait before
+// retrying a throttled request.
+const headerRetryAfter = "Retry-After"
+
+// Default values used by DefaultRetryPolicy.
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+)
+
+// Dataverse service protection API limit error codes. Requests failing with
+// these codes were not processed and can be retried after the Retry-After
+// interval.
+const (
+	errorCodeNumberOfRequestsExceeded   = "0x80072322"
+	errorCodeExecutionTimeExceeded      = "0x80072321"
+	errorCodeConcurrentRequestsExceeded = "0x80072326"
+)
+
+// RetryPolicy configures how DataverseService retries requests that fail
+// with transient errors, including HTTP 429 Too Many Requests and Dataverse
+// service protection limits.
+//
+// Delays grow exponentially from BaseDelay up to MaxDelay, with random
+// jitter. If the response includes a Retry-After header, that interval is used
+// instead, even if it exceeds MaxDelay.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including
+	// the first attempt. Values of 1 or less disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the exponential backoff delay
+	MaxDelay time.Duration
+
+	// RetryNonIdempotent allows POST and PATCH requests to be retried after
+	// network errors and unavailable service responses. By default only
+	// idempotent methods (GET, HEAD, OPTIONS, PUT, DELETE) are retried in
+	// those cases. Requests of any method are retried when throttled, since
+	// Dataverse does not process a request rejected with HTTP 429 or a
+	// service protection error.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the retry policy used when
+// DataverseServiceOptions.RetryPolicy is nil.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultRetryMaxAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    defaultRetryMaxDelay,
+	}
+}
+
+// NoRetryPolicy returns a retry policy that sends each request once.
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// retryDelay determines whether a request should be sent again after the
+// given attempt, returning the delay before the next attempt. Either res or
+// err describes the outcome of the attempt.
+//
+// Only network timeouts, connection resets and transient responses are
+// retried. Authentication errors are never retried, so that a declined or
+// failed sign in is not prompted for again, and nor is any error once the
+// request's context is done.
+func (p RetryPolicy) retryDelay(req *http.Request, res *DataverseResponse, err error, attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || req.Context().Err() != nil {
+		return 0, false
+	}
+
+	if err != nil {
+		if !isTransientError(err) || !p.canRetryMethod(req.Method) {
+			return 0, false
+		}
+		return p.backoff(attempt), true
+	}
+
+	if !isTransientResponse(res) {
+		return 0, false
+	}
+	if !isThrottledResponse(res) && !p.canRetryMethod(req.Method) {
+		return 0, false
+	}
+
+	if delay, ok := retryAfter(res.Header); ok {
+		return delay, true
+	}
+	return p.backoff(attempt), true
+}
+
+// isTransientError returns true if err is a network timeout or a connection
+// reset, and not an authentication error or a cancellation.
+func isTransientError(err error) bool {
+	if errors.Is(err, ErrAuthentication) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// canRetryMethod returns true if requests with the given method may be
+// retried under this policy.
+func (p RetryPolicy) canRetryMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return p.RetryNonIdempotent
+	}
+}
+
+// backoff returns the exponential backoff delay for the given attempt with
+// jitter applied. The delay is chosen at random between half and all of the
+// capped exponential delay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// isTransientResponse returns true if the response indicates a temporary
+// failure: throttling, a service protection limit, or an unavailable
+// service.
+func isTransientResponse(res *DataverseResponse) bool {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	if res.IsSuccessful {
+		return false
+	}
+	return isServiceProtectionError(res.Body)
+}
+
+// isThrottledResponse returns true if the request was rejected by service
+// protection limits without being processed.
+func isThrottledResponse(res *DataverseResponse) bool {
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return !res.IsSuccessful && isServiceProtectionError(res.Body)
+}
+
+// isServiceProtectionError returns true if the body is an OData error with a
+// service protection limit error code.
+func isServiceProtectionError(body []byte) bool {
+	var errRes model.ErrorResponse
+	if err := json.Unmarshal(body, &errRes); err != nil {
+		return false
+	}
+	return isServiceProtectionCode(errRes.Error.Code)
+}
+
+// isServiceProtectionCode returns true if code is a service protection limit
+// error code.
+func isServiceProtectionCode(code string) bool {
+	switch code {
+	case errorCodeNumberOfRequestsExceeded,
+		errorCodeExecutionTimeExceeded,
+		errorCodeConcurrentRequestsExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an
+// HTTP date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get(headerRetryAfter)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,267 @@
+// Package tokencache persists the tokens acquired by MSAL clients between
+// runs in an encrypted file, so that users are not asked to sign in each
+// time the application starts.
+package tokencache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AzureAD/microsoft-authentication-library-for-go/apps/cache"
+	"github.com/gofrs/flock"
+	"github.com/turnerbenjamin/go_odata/msal"
+)
+
+// File and directory permissions of the cache, readable by the user only.
+const (
+	cacheFilePerm = 0o600
+	cacheDirPerm  = 0o700
+)
+
+// Locking settings. The lock is retried every lockRetryDelay until it is
+// acquired or the context is done; contexts without a deadline are given
+// defaultLockTimeout, as required by cache.ExportReplace.
+const (
+	lockFileSuffix     = ".lock"
+	lockRetryDelay     = 50 * time.Millisecond
+	defaultLockTimeout = 10 * time.Second
+)
+
+// Names of the cache files in the application's configuration directory.
+const (
+	cacheFileName          = "msal_token_cache.bin"
+	profileCacheFileFormat = "msal_token_cache_%s.bin"
+	// profileHashLength is the number of hex digits of the profile hash
+	// used in a profile's cache file name
+	profileHashLength = 16
+)
+
+// ErrInvalidCacheFile is returned when the cache file is too short to hold
+// encrypted data.
+var ErrInvalidCacheFile = errors.New("invalid token cache file")
+
+// DefaultPath returns the path of a token cache file in the user's
+// configuration directory, e.g. ~/.config/go_odata/msal_token_cache.bin. The
+// file is shared by every caller; use ProfilePath to keep the tokens of
+// each profile apart.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go_odata", cacheFileName), nil
+}
+
+// ProfilePath returns the path of a token cache file in the user's
+// configuration directory for the profile identified by parts, e.g. its
+// name, tenant ID, client ID and environment URL. Each profile has its own
+// file, so that signing out of one does not remove the tokens of another.
+func ProfilePath(parts ...string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, part := range parts {
+		// The separator keeps ("ab", "c") distinct from ("a", "bc")
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	id := hex.EncodeToString(hash.Sum(nil))[:profileHashLength]
+	return filepath.Join(dir, "go_odata", fmt.Sprintf(profileCacheFileFormat, id)), nil
+}
+
+// encryptedFileCache implements msal.TokenCache, storing the MSAL cache in a
+// file encrypted with AES-256-GCM. A lock file serialises access by
+// concurrent processes.
+type encryptedFileCache struct {
+	path string
+	keys KeyStore
+	lock string
+}
+
+// NewEncryptedFileCache creates a token cache stored in the file at path,
+// encrypted with the key provided by keys. The file and its directory are
+// created when tokens are first exported, readable by the user only. A file
+// that cannot be decrypted, e.g. because the key has changed, is treated as
+// an empty cache, so the user signs in again.
+func NewEncryptedFileCache(path string, keys KeyStore) msal.TokenCache {
+	return &encryptedFileCache{
+		path: path,
+		keys: keys,
+		lock: path + lockFileSuffix,
+	}
+}
+
+// Replace loads the tokens in the cache file into the MSAL cache. A missing
+// file leaves the MSAL cache unchanged.
+func (c *encryptedFileCache) Replace(ctx context.Context, u cache.Unmarshaler, hints cache.ReplaceHints) error {
+	unlock, err := c.acquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key, err := c.keys.Key(ctx)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := decrypt(key, data)
+	if err != nil {
+		// An unreadable cache only costs a sign in
+		return nil
+	}
+	return u.Unmarshal(plaintext)
+}
+
+// Export encrypts the MSAL cache and writes it to the cache file.
+func (c *encryptedFileCache) Export(ctx context.Context, m cache.Marshaler, hints cache.ExportHints) error {
+	unlock, err := c.acquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	plaintext, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+
+	key, err := c.keys.Key(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := encrypt(key, plaintext)
+	if err != nil {
+		return err
+	}
+	return c.write(data)
+}
+
+// Clear deletes the cache file.
+func (c *encryptedFileCache) Clear(ctx context.Context) error {
+	unlock, err := c.acquireLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = os.Remove(c.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// acquireLock waits for the cache's lock file.
+// Returns a function releasing the lock, or an error if ctx is done first.
+func (c *encryptedFileCache) acquireLock(ctx context.Context) (func(), error) {
+	return lockFile(ctx, c.lock)
+}
+
+// lockFile waits for an exclusive lock on the file at path, creating the file
+// and its directory if needed. Contexts without a deadline are given
+// defaultLockTimeout.
+// Returns a function releasing the lock, or an error if ctx is done first.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultLockTimeout)
+		defer cancel()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), cacheDirPerm); err != nil {
+		return nil, err
+	}
+
+	lock := flock.New(path)
+	locked, err := lock.TryLockContext(ctx, lockRetryDelay)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock %s: %w", path, err)
+	}
+	if !locked {
+		return nil, fmt.Errorf("unable to lock %s", path)
+	}
+	return func() { lock.Unlock() }, nil
+}
+
+// write replaces the cache file with data by writing a temporary file in the
+// same directory and renaming it.
+func (c *encryptedFileCache) write(data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(cacheFilePerm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// encrypt seals plaintext with AES-GCM, prefixing the random nonce.
+func encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decrypt opens data sealed by encrypt.
+func decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrInvalidCacheFile
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// newGCM creates an AES-GCM cipher using key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,235 @@
+// Package tokencache persists the tokens acquired by MSAL clients between
+// runs in an encrypted file, so that users are not asked to sign in each
+// time the application starts.
+package tokencache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keySize is the size in bytes of the AES-256 encryption key.
+const keySize = 32
+
+// Keyring entry holding the key by default.
+const (
+	DefaultKeyringService = "go_odata"
+	DefaultKeyringUser    = "token-cache-key"
+)
+
+// keyFileSuffix is appended to the cache file's path to name the key file
+// used when the OS keyring is unavailable.
+const keyFileSuffix = ".key"
+
+// ErrInvalidKey is returned when a stored key is not a base64 encoded
+// 256-bit key.
+var ErrInvalidKey = errors.New("invalid token cache key")
+
+// KeyStore provides the key used to encrypt the token cache.
+type KeyStore interface {
+	// Key returns the encryption key, generating and storing a new key if
+	// none has been stored.
+	Key(ctx context.Context) ([]byte, error)
+}
+
+// keyringLockFileFormat names the lock file serialising the creation of a
+// keyring entry's key, from the entry's service and user.
+const keyringLockFileFormat = "keyring_%s_%s.lock"
+
+// keyringKeyStore keeps the key in the OS keyring.
+type keyringKeyStore struct {
+	service string
+	user    string
+	// lock is the path of the lock file held while creating the key
+	lock string
+}
+
+// NewKeyringKeyStore creates a KeyStore keeping the key under service and
+// user in the OS keyring: the Secret Service API (libsecret) on Linux, the
+// Keychain on macOS or the Credential Manager on Windows. Processes creating
+// the key at the same time agree on a single key.
+func NewKeyringKeyStore(service, user string) KeyStore {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	lockName := fmt.Sprintf(keyringLockFileFormat, safeFileName(service), safeFileName(user))
+
+	return &keyringKeyStore{
+		service: service,
+		user:    user,
+		lock:    filepath.Join(dir, "go_odata", lockName),
+	}
+}
+
+// Key returns the key stored in the keyring, generating one if none is
+// stored. The key is created while holding a lock file, and the keyring is
+// checked again once the lock is held, so that a concurrent process's key
+// is not overwritten.
+func (s *keyringKeyStore) Key(ctx context.Context) ([]byte, error) {
+	encoded, err := keyring.Get(s.service, s.user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		unlock, err := lockFile(ctx, s.lock)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		encoded, err = keyring.Get(s.service, s.user)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return s.createKey()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s/%s: %w", s.service, s.user, err)
+	}
+	return decodeKey(encoded)
+}
+
+// createKey generates a key and stores it in the keyring.
+func (s *keyringKeyStore) createKey() ([]byte, error) {
+	key, encoded, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.Set(s.service, s.user, encoded); err != nil {
+		return nil, fmt.Errorf("keyring %s/%s: %w", s.service, s.user, err)
+	}
+	return key, nil
+}
+
+// fileKeyStore keeps the key in a file readable by the user only.
+type fileKeyStore struct {
+	path string
+}
+
+// NewFileKeyStore creates a KeyStore keeping the key in the file at path,
+// readable by the user only. It protects the cache from other users but not
+// from anyone able to read the user's files, so the OS keyring is preferred
+// where available.
+func NewFileKeyStore(path string) KeyStore {
+	return &fileKeyStore{path: path}
+}
+
+// Key returns the key stored in the file, generating one if the file does not
+// exist. The file is read and created while holding a lock file, so that a
+// key being written by a concurrent process is never read incomplete.
+func (s *fileKeyStore) Key(ctx context.Context) ([]byte, error) {
+	unlock, err := lockFile(ctx, s.path+lockFileSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err == nil {
+		return decodeKey(string(data))
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	key, encoded, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, cacheFilePerm)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(encoded); err != nil {
+		f.Close()
+		os.Remove(s.path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(s.path)
+		return nil, err
+	}
+	return key, nil
+}
+
+// fallbackKeyStore uses its primary KeyStore, or its fallback if the primary
+// fails, e.g. when no keyring service is running. The choice is remembered
+// so that the same key is used for the life of the process.
+type fallbackKeyStore struct {
+	primary  KeyStore
+	fallback KeyStore
+	mu       sync.Mutex
+	key      []byte
+}
+
+// NewDefaultKeyStore creates a KeyStore keeping the key in the OS keyring,
+// falling back to a key file next to the cache file at cachePath if the
+// keyring is unavailable, as in many SSH sessions and containers.
+func NewDefaultKeyStore(cachePath string) KeyStore {
+	return &fallbackKeyStore{
+		primary:  NewKeyringKeyStore(DefaultKeyringService, DefaultKeyringUser),
+		fallback: NewFileKeyStore(cachePath + keyFileSuffix),
+	}
+}
+
+// Key returns the primary store's key, or the fallback store's key if the
+// primary fails.
+func (s *fallbackKeyStore) Key(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != nil {
+		return s.key, nil
+	}
+
+	key, err := s.primary.Key(ctx)
+	if err != nil {
+		key, err = s.fallback.Key(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.key = key
+	return key, nil
+}
+
+// newKey generates a random key.
+// Returns the key and its base64 encoding.
+func newKey() ([]byte, string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", err
+	}
+	return key, base64.StdEncoding.EncodeToString(key), nil
+}
+
+// safeFileName replaces the characters of name that are not letters, digits,
+// dashes or underscores, for use in a file name.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// decodeKey decodes a base64 encoded key.
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
@@ -0,0 +1,45 @@
+// Package view provides UI components for terminal-based applications.
+// It includes interactive elements like inputs, lists, and navigation controls.
+package view
+
+import (
+	"fmt"
+
+	"github.com/eiannone/keyboard"
+)
+
+// cancelPrompt represents an interactive terminal component displayed while a
+// long-running task is in progress. It signals completion when the user
+// presses Esc, which the UI treats as a request to cancel the task. It
+// implements the InteractiveComponent interface.
+type cancelPrompt struct {
+}
+
+// NewCancelPromptComponent creates and returns a new cancelPrompt component.
+// It is intended for use with UI.RunCancellable.
+// Returns an InteractiveComponent that can be used in a screen.
+func NewCancelPromptComponent() InteractiveComponent {
+	return &cancelPrompt{}
+}
+
+// render displays the "Press Esc to cancel" message in the terminal.
+func (c *cancelPrompt) render() {
+	fmt.Print("\n\nPress Esc to cancel")
+}
+
+// handleKeyboardInput signals completion when Esc is pressed and ignores all
+// other keys.
+//
+// Parameters:
+//   - char: The character code (rune) of the pressed key
+//   - key: The keyboard key that was pressed
+//
+// Returns:
+//   - *updateResponse: A response indicating whether the prompt is done
+//   - error: Always nil in this implementation
+func (c *cancelPrompt) handleKeyboardInput(char rune, key keyboard.Key) (*updateResponse, error) {
+	if key == keyboard.KeyEsc {
+		return newUpdateResponse().setContinue(false), nil
+	}
+	return newUpdateResponse().setContinue(true), nil
+}
@@ -0,0 +1,239 @@
+// Package view provides UI components for terminal-based applications.
+// It includes interactive elements like inputs, lists, and navigation controls.
+package view
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/eiannone/keyboard"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// Help text describing the keys used by a choice input.
+const (
+	choiceInputHelp      = "Use ↑/↓ and Enter to choose an option, or Esc to keep the current value."
+	multiChoiceInputHelp = "Use ↑/↓ to move, Space to select or deselect an option and Enter to confirm, or Esc to keep the current value."
+	choiceInputClearHelp = "Press Delete to clear the value."
+)
+
+// Separators used to join the values and labels of the options chosen in a
+// multi-select choice input.
+const (
+	choiceValueSeparator = ","
+	choiceLabelSeparator = "; "
+)
+
+// ErrNoChoiceOptions is returned when creating a choice input without any
+// options.
+var ErrNoChoiceOptions = errors.New("choice input requires at least one option")
+
+// ChoiceOption is one of the options offered by a choice input.
+type ChoiceOption struct {
+	// Value is the integer stored for the option
+	Value int
+
+	// Label is the text displayed for the option
+	Label string
+}
+
+// ChoiceInputOptions contains configuration for creating a choice input
+// component.
+type ChoiceInputOptions struct {
+	// PropertyName is the label of the choice column
+	PropertyName string
+
+	// Options lists the available options in display order
+	Options []ChoiceOption
+
+	// Selected holds the values of the currently selected options
+	Selected []int
+
+	// IsRequired prevents the user from leaving the choice without a value
+	IsRequired bool
+
+	// MultiSelect allows any number of options to be selected
+	MultiSelect bool
+}
+
+// choiceInput represents a choice (option set) field in a terminal UI. In
+// single-select mode the user picks one option with Enter; in multi-select
+// mode options are toggled with Space and confirmed with Enter.
+//
+// When the user confirms a choice, the screen output's Target is the chosen
+// values separated by commas, e.g. "1,3", and its UserInput is their labels
+// separated by semicolons. If the user keeps the current value, both are
+// empty. If the user clears an optional choice, the Target is ClearedTarget
+// and the UserInput is empty.
+type choiceInput struct {
+	options      ChoiceInputOptions
+	cursor       int
+	selected     map[int]bool
+	errorMessage string
+	requiredFlag string
+}
+
+// NewChoiceInputComponent creates a new choice input with the given options.
+// The cursor starts on the first currently selected option.
+// Returns an error if no options are provided.
+func NewChoiceInputComponent(options ChoiceInputOptions) (InteractiveComponent, error) {
+	if len(options.Options) == 0 {
+		return nil, ErrNoChoiceOptions
+	}
+
+	ci := &choiceInput{
+		options:  options,
+		selected: make(map[int]bool, len(options.Selected)),
+	}
+	for _, v := range options.Selected {
+		ci.selected[v] = true
+	}
+	if i := slices.IndexFunc(options.Options, func(o ChoiceOption) bool {
+		return ci.selected[o.Value]
+	}); i >= 0 {
+		ci.cursor = i
+	}
+	if options.IsRequired {
+		ci.requiredFlag = "(" + colours.ApplyColour("*", colours.Red) + ")"
+	}
+	return ci, nil
+}
+
+// render displays the options with the cursor and, in multi-select mode, a
+// check box for each option.
+func (ci *choiceInput) render() {
+	help := choiceInputHelp
+	if ci.options.MultiSelect {
+		help = multiChoiceInputHelp
+	}
+	if !ci.options.IsRequired {
+		help += " " + choiceInputClearHelp
+	}
+	fmt.Printf("\n%s%s: %s", ci.options.PropertyName, ci.requiredFlag, ci.currentLabel())
+	fmt.Printf("\n\n%s\n\n", help)
+
+	for i, option := range ci.options.Options {
+		checkBox := ""
+		if ci.options.MultiSelect {
+			checkBox = "[ ] "
+			if ci.selected[option.Value] {
+				checkBox = "[x] "
+			}
+		}
+		fmt.Printf("%s %s%s\n", menuIndicator(i == ci.cursor), checkBox, option.Label)
+	}
+
+	if ci.errorMessage != "" {
+		fmt.Printf("\n%s%s%s", colours.Red, ci.errorMessage, colours.Reset)
+	}
+}
+
+// handleKeyboardInput routes keypresses to the appropriate handlers. This
+// component reports validation errors via the UI and never returns an error.
+func (ci *choiceInput) handleKeyboardInput(c rune, k keyboard.Key) (*updateResponse, error) {
+	ci.errorMessage = ""
+	switch k {
+	case keyboard.KeyArrowUp:
+		if ci.cursor > 0 {
+			ci.cursor--
+		}
+		return newUpdateResponse().setContinue(true), nil
+	case keyboard.KeyArrowDown:
+		if ci.cursor < len(ci.options.Options)-1 {
+			ci.cursor++
+		}
+		return newUpdateResponse().setContinue(true), nil
+	case keyboard.KeySpace:
+		if ci.options.MultiSelect {
+			value := ci.options.Options[ci.cursor].Value
+			ci.selected[value] = !ci.selected[value]
+		}
+		return newUpdateResponse().setContinue(true).setFullRefresh(), nil
+	case keyboard.KeyEnter:
+		return ci.handleEnterPressed(), nil
+	case keyboard.KeyEsc:
+		return ci.handleEscPressed(), nil
+	case keyboard.KeyDelete, keyboard.KeyBackspace, keyboard.KeyBackspace2:
+		return ci.handleClearPressed(), nil
+	default:
+		return newUpdateResponse().setContinue(true), nil
+	}
+}
+
+// handleEnterPressed confirms the option under the cursor or, in
+// multi-select mode, the selected options. Confirming a multi-select choice
+// with no options selected clears it.
+func (ci *choiceInput) handleEnterPressed() *updateResponse {
+	chosen := []ChoiceOption{ci.options.Options[ci.cursor]}
+	if ci.options.MultiSelect {
+		chosen = ci.selectedOptions()
+	}
+
+	if len(chosen) == 0 {
+		return ci.handleClearPressed()
+	}
+
+	values := make([]string, len(chosen))
+	labels := make([]string, len(chosen))
+	for i, o := range chosen {
+		values[i] = strconv.Itoa(o.Value)
+		labels[i] = o.Label
+	}
+	return newUpdateResponse().
+		setUserInput(strings.Join(labels, choiceLabelSeparator)).
+		setTarget(strings.Join(values, choiceValueSeparator))
+}
+
+// handleEscPressed keeps the current value, unless the choice is required and
+// has no value.
+func (ci *choiceInput) handleEscPressed() *updateResponse {
+	if ci.options.IsRequired && len(ci.options.Selected) == 0 {
+		ci.showPropertyRequiredError()
+		return newUpdateResponse().setContinue(true)
+	}
+	return newUpdateResponse()
+}
+
+// handleClearPressed clears the value, unless the choice is required.
+func (ci *choiceInput) handleClearPressed() *updateResponse {
+	if ci.options.IsRequired {
+		ci.showPropertyRequiredError()
+		return newUpdateResponse().setContinue(true)
+	}
+	return newUpdateResponse().setTarget(ClearedTarget)
+}
+
+// selectedOptions returns the selected options in display order.
+func (ci *choiceInput) selectedOptions() []ChoiceOption {
+	selected := []ChoiceOption{}
+	for _, o := range ci.options.Options {
+		if ci.selected[o.Value] {
+			selected = append(selected, o)
+		}
+	}
+	return selected
+}
+
+// currentLabel returns the labels of the options selected when the input was
+// created, or "(none)".
+func (ci *choiceInput) currentLabel() string {
+	labels := []string{}
+	for _, o := range ci.options.Options {
+		if slices.Contains(ci.options.Selected, o.Value) {
+			labels = append(labels, o.Label)
+		}
+	}
+	if len(labels) == 0 {
+		return "(none)"
+	}
+	return strings.Join(labels, choiceLabelSeparator)
+}
+
+// showPropertyRequiredError updates the error message to inform the user that
+// they must choose a value to continue.
+func (ci *choiceInput) showPropertyRequiredError() {
+	ci.errorMessage = fmt.Sprintf("%s is required", ci.options.PropertyName)
+}
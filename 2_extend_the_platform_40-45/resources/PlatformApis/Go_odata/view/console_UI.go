@@ -3,8 +3,12 @@
 package view
 
 import (
+	"context"
 	"errors"
+	"sync/atomic"
+	"time"
 
+	"github.com/eiannone/keyboard"
 	"github.com/turnerbenjamin/go_odata/view/console_input_reader"
 )
 
@@ -13,6 +17,11 @@ import (
 // screen.
 var ErrNilScreen = errors.New("cannot navigate to nil screen")
 
+// ErrTaskRunning is returned by RunCancellable when it is called while
+// another task is running, for example from within the task itself. Only one
+// screen can read keyboard input at a time.
+var ErrTaskRunning = errors.New("cannot run a task while another task is running")
+
 // UI defines the interface for user interface controllers.
 // It provides methods for screen navigation and application termination.
 type UI interface {
@@ -20,6 +29,18 @@ type UI interface {
 	// mounts it, and returns the screen's output after user interaction.
 	NavigateTo(Screen) (ScreenOutput, error)
 
+	// RunCancellable navigates to the provided Screen and runs task while it
+	// is displayed. Key presses are passed to the screen as usual; if the
+	// screen signals completion before task returns, the context passed to
+	// task is cancelled and context.Canceled is returned once task exits.
+	// Returns ErrTaskRunning if another task is already running.
+	RunCancellable(Screen, func(ctx context.Context) error) error
+
+	// SetHeader sets a line of text displayed at the top of every screen,
+	// such as the signed in identity, or removes it if text is empty. It
+	// applies from the next screen navigated to.
+	SetHeader(text string)
+
 	// Exit performs cleanup operations including screen dismounting
 	// and input reader closure.
 	Exit()
@@ -32,6 +53,10 @@ type consoleUI struct {
 	currentScreen Screen
 	// inputReader provides terminal input capabilities
 	inputReader console_input_reader.InputReader
+	// header is displayed at the top of every screen, or is nil
+	header Component
+	// running is true while RunCancellable is running a task
+	running atomic.Bool
 }
 
 // NewConsoleUI creates and initializes a new console UI controller.
@@ -72,10 +97,102 @@ func (c *consoleUI) NavigateTo(s Screen) (ScreenOutput, error) {
 	}
 
 	c.currentScreen = s
+	c.currentScreen.setHeader(c.header)
 	c.currentScreen.Mount()
 	return c.AwaitOutput()
 }
 
+// RunCancellable displays a screen while a task runs, allowing the user to
+// cancel the task from the keyboard.
+// The task is started in its own goroutine with a cancellable context. Key
+// presses are delivered to the screen while the task runs, and the context is
+// cancelled when the screen signals completion, for example when the user
+// presses Esc on a cancel prompt. Screens with live components, such as a
+// countdown, are refreshed periodically while the task runs.
+//
+// Parameters:
+//   - s: The screen to display while the task runs. Must not be nil.
+//   - task: The work to perform. It should return promptly once its context
+//     is cancelled.
+//
+// Returns:
+//   - error: ErrNilScreen if s is nil, ErrTaskRunning if another task is
+//     running, context.Canceled if the user cancelled the task, otherwise the
+//     error returned by task
+func (c *consoleUI) RunCancellable(s Screen, task func(ctx context.Context) error) error {
+	if s == nil {
+		return ErrNilScreen
+	}
+	if !c.running.CompareAndSwap(false, true) {
+		return ErrTaskRunning
+	}
+	defer c.running.Store(false)
+
+	if c.currentScreen != nil {
+		c.currentScreen.Dismount()
+	}
+	c.currentScreen = s
+	c.currentScreen.setHeader(c.header)
+	c.currentScreen.Mount()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- task(ctx)
+	}()
+
+	var ticks <-chan time.Time
+	if interval := s.refreshInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	events := c.inputReader.Events()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticks:
+			c.currentScreen.Refresh()
+		case event := <-events:
+			if event.Err != nil {
+				cancel()
+				<-done
+				return event.Err
+			}
+
+			updateResponse, err := c.currentScreen.handleKeyboardInput(event.Rune, event.Key)
+			if err != nil {
+				cancel()
+				<-done
+				return err
+			}
+
+			if !updateResponse.doContinue {
+				cancel()
+				if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+					return err
+				}
+				return context.Canceled
+			}
+			c.currentScreen.Refresh()
+		}
+	}
+}
+
+// SetHeader sets the text displayed at the top of every screen, or removes
+// the header if text is empty.
+func (c *consoleUI) SetHeader(text string) {
+	if text == "" {
+		c.header = nil
+		return
+	}
+	c.header = newHeaderComponent(text)
+}
+
 // Exit performs cleanup operations when exiting the application.
 // It dismounts the current screen if one exists and closes the input reader.
 //
@@ -94,7 +211,9 @@ func (c *consoleUI) Exit() {
 // AwaitOutput enters a processing loop for user input on the current screen.
 // It continually reads input, passes it to the current screen for handling, and
 // returns when the screen signals completion or an error occurs.
-// The screen is refreshed after each input that doesn't result in navigation.
+// The screen is refreshed after each input that doesn't result in navigation,
+// and periodically if it has live components, such as a lookup input waiting
+// for search results.
 //
 // Parameters:
 //   - None
@@ -103,8 +222,15 @@ func (c *consoleUI) Exit() {
 //   - ScreenOutput: The output from the screen after user interaction
 //   - error: Any error that occurs during input handling
 func (c *consoleUI) AwaitOutput() (ScreenOutput, error) {
+	var ticks <-chan time.Time
+	if interval := c.currentScreen.refreshInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
 	for {
-		char, key, err := c.inputReader.AwaitInput()
+		char, key, err := c.awaitInput(ticks)
 		if err != nil {
 			return nil, err
 		}
@@ -120,3 +246,25 @@ func (c *consoleUI) AwaitOutput() (ScreenOutput, error) {
 		c.currentScreen.Refresh()
 	}
 }
+
+// awaitInput blocks until a key is pressed, refreshing the current screen each
+// time ticks delivers a value. If ticks is nil, it waits for input only.
+// Returns the character, the key code, and any error that occurred.
+func (c *consoleUI) awaitInput(ticks <-chan time.Time) (rune, keyboard.Key, error) {
+	if ticks == nil {
+		return c.inputReader.AwaitInput()
+	}
+
+	events := c.inputReader.Events()
+	if events == nil {
+		return 0, 0, console_input_reader.ErrNotOpen
+	}
+	for {
+		select {
+		case <-ticks:
+			c.currentScreen.Refresh()
+		case event := <-events:
+			return event.Rune, event.Key, event.Err
+		}
+	}
+}
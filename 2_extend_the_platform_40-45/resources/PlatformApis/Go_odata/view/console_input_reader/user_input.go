@@ -3,9 +3,18 @@
 package console_input_reader
 
 import (
+	"errors"
+
 	"github.com/eiannone/keyboard"
 )
 
+// keyEventBufferSize is the number of key presses buffered before the
+// keyboard package blocks.
+const keyEventBufferSize = 10
+
+// ErrNotOpen is returned when reading input before Open has been called.
+var ErrNotOpen = errors.New("input reader has not been opened")
+
 // InputReader defines the interface for reading keyboard input from the
 // console.
 // Implementations must support opening a keyboard connection, waiting for user
@@ -15,6 +24,11 @@ type InputReader interface {
 	// key code, and any error.
 	AwaitInput() (rune, keyboard.Key, error)
 
+	// Events returns the channel on which key presses are delivered. It
+	// allows callers to wait for input alongside other work using select.
+	// Events returns nil if the reader has not been opened.
+	Events() <-chan keyboard.KeyEvent
+
 	// Open initializes the keyboard input connection. This must be called
 	// before AwaitInput can be used.
 	Open() error
@@ -25,7 +39,9 @@ type InputReader interface {
 }
 
 // inputReader implements the InputReader interface using the keyboard package.
-type inputReader struct{}
+type inputReader struct {
+	events <-chan keyboard.KeyEvent
+}
 
 // NewInputReader creates and returns a new instance of InputReader.
 // Usage:
@@ -37,18 +53,33 @@ func NewInputReader() InputReader {
 	return &inputReader{}
 }
 
-// Open initializes the keyboard connection.
+// Open initializes the keyboard connection and stores the key event channel.
 func (r *inputReader) Open() error {
-	return keyboard.Open()
+	events, err := keyboard.GetKeys(keyEventBufferSize)
+	if err != nil {
+		return err
+	}
+	r.events = events
+	return nil
 }
 
 // Close terminates the keyboard connection and releases resources.
 func (r *inputReader) Close() error {
+	r.events = nil
 	return keyboard.Close()
 }
 
+// Events returns the channel on which key presses are delivered.
+func (r *inputReader) Events() <-chan keyboard.KeyEvent {
+	return r.events
+}
+
 // AwaitInput blocks until a key is pressed and returns the character,
 // the key code, and any error that occurred.
 func (r *inputReader) AwaitInput() (rune, keyboard.Key, error) {
-	return keyboard.GetKey()
+	if r.events == nil {
+		return 0, 0, ErrNotOpen
+	}
+	event := <-r.events
+	return event.Rune, event.Key, event.Err
 }
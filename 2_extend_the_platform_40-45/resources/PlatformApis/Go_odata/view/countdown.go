@@ -0,0 +1,56 @@
+// Package view provides UI components for terminal-based applications.
+// It includes interactive elements like inputs, lists, and navigation controls.
+package view
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/turnerbenjamin/go_odata/constants/ansi"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// countdownRefreshInterval is the time between refreshes of a countdown.
+const countdownRefreshInterval = time.Second
+
+// countdown represents a live component displaying the time remaining until a
+// deadline. Screens containing a countdown are refreshed every second by
+// UI.RunCancellable.
+type countdown struct {
+	label    string
+	deadline time.Time
+	// now returns the current time
+	now func() time.Time
+}
+
+// NewCountdownComponent creates a new countdown component displaying the
+// label followed by the minutes and seconds remaining until deadline. Once
+// the deadline has passed, it displays that the time has expired.
+// It returns an implementation of the Component interface that can be added
+// to a screen.
+func NewCountdownComponent(label string, deadline time.Time) Component {
+	return &countdown{
+		label:    label,
+		deadline: deadline,
+		now:      time.Now,
+	}
+}
+
+// render displays the time remaining, clearing the rest of the line so that
+// a partial refresh leaves no trace of a longer previous value.
+func (c *countdown) render() {
+	remaining := c.deadline.Sub(c.now()).Round(time.Second)
+	if remaining <= 0 {
+		fmt.Printf("%s%s\n\n", colours.ApplyColour("Expired", colours.Red), ansi.ClearToLineEnd)
+		return
+	}
+
+	minutes := int(remaining / time.Minute)
+	seconds := int(remaining % time.Minute / time.Second)
+	fmt.Printf("%s %02d:%02d%s\n\n", c.label, minutes, seconds, ansi.ClearToLineEnd)
+}
+
+// refreshInterval returns the time between refreshes of the countdown.
+func (c *countdown) refreshInterval() time.Duration {
+	return countdownRefreshInterval
+}
@@ -0,0 +1,27 @@
+// Package view provides UI components for terminal-based applications.
+// It includes interactive elements like inputs, lists, and navigation controls.
+package view
+
+import (
+	"fmt"
+
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// header represents a banner displayed above every screen by the UI, such as
+// the signed in identity.
+type header struct {
+	content string // The pre-formatted content ready for display
+}
+
+// render displays the header followed by newlines for spacing.
+func (h *header) render() {
+	fmt.Printf("%s\n\n", h.content)
+}
+
+// newHeaderComponent creates a new header component displaying text in grey.
+func newHeaderComponent(text string) Component {
+	return &header{
+		content: colours.ApplyColour(text, colours.Grey),
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/eiannone/keyboard"
 	"github.com/turnerbenjamin/go_odata/utilities"
@@ -24,6 +25,12 @@ const (
 	rightArrowChar               = "🡒"
 	leftArrowChar                = "🡐"
 	defaultConsoleWidth          = 80
+	listSelectSortColumnKey      = "Tab"
+	listSelectSortColumnLabel    = "Select sort column"
+	listCycleSortOrderKey        = 'o'
+	listCycleSortOrderLabel      = "Cycle sort order"
+	sortAscendingIndicator       = " ▲"
+	sortDescendingIndicator      = " ▼"
 )
 
 // ErrNoData is returned when attempting to render a list with no data rows.
@@ -61,6 +68,17 @@ type EntityList[T Entity] interface {
 	Previous() EntityList[T]
 }
 
+// ListSort describes the order in which a list component's data is sorted.
+// The zero value represents an unsorted list.
+type ListSort struct {
+	// LogicalName is the logical name of the attribute being sorted by, or an
+	// empty string if the list is unsorted.
+	LogicalName string
+
+	// Descending is true if the list is sorted in descending order.
+	Descending bool
+}
+
 // ListComponentOptions configures the behaviour and appearance of a list
 // component.
 type ListComponentOptions[T Entity] struct {
@@ -72,8 +90,22 @@ type ListComponentOptions[T Entity] struct {
 
 	// EntityList provides the data to be displayed.
 	EntityList EntityList[T]
+
+	// Sort is the order applied to EntityList.
+	Sort ListSort
+
+	// SelectSort is called with the sort order chosen by the user, after
+	// which the list completes with the UserInput SortRequested so that the
+	// caller can fetch the data again in that order. If nil, the list cannot
+	// be sorted. Only columns with a logical name can be sorted.
+	SelectSort func(ListSort)
 }
 
+// SortRequested is the UserInput of the output of a list component when the
+// user has chosen a new sort order, which is passed to
+// ListComponentOptions.SelectSort.
+const SortRequested = "(sort)"
+
 // listComponent implements an interactive, terminal-based data table with
 // navigation controls.
 type listComponent[T Entity] struct {
@@ -103,6 +135,16 @@ type listComponent[T Entity] struct {
 
 	// controlsString contains the formatted help text for keyboard controls
 	controlsString string
+
+	// sort is the order applied to the current entity list
+	sort ListSort
+
+	// selectSort receives a new sort order chosen by the user
+	selectSort func(ListSort)
+
+	// sortColumn is the index of the column selected for sorting, or -1 if
+	// no column can be sorted
+	sortColumn int
 }
 
 // navigationControl represents a keyboard navigation command that allows users
@@ -127,11 +169,37 @@ func BuildListComponent[T Entity](options ListComponentOptions[T]) (InteractiveC
 		entityList:     options.EntityList,
 		customControls: options.Controls,
 		selected:       0,
+		sort:           options.Sort,
+		selectSort:     options.SelectSort,
 	}
+	lc.sortColumn = lc.initialSortColumn()
 	err := lc.refreshDataAndCalculateLayout()
 	return &lc, err
 }
 
+// initialSortColumn returns the index of the column matching the current sort,
+// falling back to the first sortable column. Returns -1 if sorting is disabled
+// or no column is sortable.
+func (lc *listComponent[T]) initialSortColumn() int {
+	if lc.selectSort == nil {
+		return -1
+	}
+
+	first := -1
+	for i, c := range lc.columns {
+		if c.LogicalName() == "" {
+			continue
+		}
+		if c.LogicalName() == lc.sort.LogicalName {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
 // refreshDataAndCalculateLayout resets the component state and recalculates
 // layout.
 // It retrieves the current page of data, validates it, and initializes
@@ -212,11 +280,59 @@ func (lc *listComponent[T]) handleKeyboardInput(char rune, key keyboard.Key) (*u
 		return lc.handleArrowLeftPressed()
 	case keyboard.KeyArrowRight:
 		return lc.handleArrowRightPressed()
+	case keyboard.KeyTab:
+		return lc.handleTabPressed()
 	default:
+		if char == listCycleSortOrderKey && lc.isSortable() {
+			return lc.handleCycleSortOrderPressed()
+		}
 		return lc.handleCustomControlInput(char)
 	}
 }
 
+// handleTabPressed selects the next sortable column, wrapping around to the
+// first. The header is rebuilt to highlight the newly selected column.
+func (lc *listComponent[T]) handleTabPressed() (*updateResponse, error) {
+	if !lc.isSortable() {
+		return newUpdateResponse().setContinue(true), nil
+	}
+
+	for i := 1; i <= len(lc.columns); i++ {
+		next := (lc.sortColumn + i) % len(lc.columns)
+		if lc.columns[next].LogicalName() != "" {
+			lc.sortColumn = next
+			break
+		}
+	}
+	lc.tableHeaderStrings = lc.buildFormattedTableHeader()
+	return newUpdateResponse().setContinue(true), nil
+}
+
+// handleCycleSortOrderPressed cycles the sort on the selected column through
+// ascending, descending and unsorted. The new order is passed to selectSort
+// and the list completes, so that the data can be fetched again in that
+// order.
+func (lc *listComponent[T]) handleCycleSortOrderPressed() (*updateResponse, error) {
+	logicalName := lc.columns[lc.sortColumn].LogicalName()
+
+	var nextSort ListSort
+	switch {
+	case lc.sort.LogicalName != logicalName:
+		nextSort = ListSort{LogicalName: logicalName}
+	case !lc.sort.Descending:
+		nextSort = ListSort{LogicalName: logicalName, Descending: true}
+	}
+
+	lc.selectSort(nextSort)
+	return newUpdateResponse().setUserInput(SortRequested), nil
+}
+
+// isSortable returns true if the list can be sorted and has at least one
+// sortable column.
+func (lc *listComponent[T]) isSortable() bool {
+	return lc.selectSort != nil && lc.sortColumn >= 0
+}
+
 // handleArrowUpPressed moves selection to the previous row if available.
 // Returns an error if there's no data to navigate.
 func (lc *listComponent[T]) handleArrowUpPressed() (*updateResponse, error) {
@@ -334,15 +450,33 @@ func (lc *listComponent[T]) buildColumnWidths() []int {
 }
 
 // buildFormattedTableHeader creates formatted strings for each column header.
+// The column selected for sorting is highlighted in blue.
 func (lc *listComponent[T]) buildFormattedTableHeader() []string {
 	tableHeaderStrings := make([]string, len(lc.columns))
 
-	for i, c := range lc.columns {
-		tableHeaderStrings[i] = lc.formatCellString(c.Label(), lc.columnWidths[i])
+	for i := range lc.columns {
+		cell := lc.formatCellString(lc.headerLabel(i), lc.columnWidths[i])
+		if i == lc.sortColumn {
+			cell = string(colours.Blue) + cell
+		}
+		tableHeaderStrings[i] = cell
 	}
 	return tableHeaderStrings
 }
 
+// headerLabel returns the header text for a column, with an arrow indicating
+// the sort direction if the list is sorted by that column.
+func (lc *listComponent[T]) headerLabel(columnIndex int) string {
+	column := lc.columns[columnIndex]
+	if column.LogicalName() == "" || column.LogicalName() != lc.sort.LogicalName {
+		return column.Label()
+	}
+	if lc.sort.Descending {
+		return column.Label() + sortDescendingIndicator
+	}
+	return column.Label() + sortAscendingIndicator
+}
+
 // buildFormattedTableData creates a 2D array of formatted strings for all data
 // cells.
 func (lc *listComponent[T]) buildFormattedTableData() [][]string {
@@ -367,7 +501,9 @@ func (lc *listComponent[T]) buildFormattedTableRow(entity T) []string {
 
 // calculateNaturalTableDimensions determines the natural width needed for each
 // column based on its content and returns both individual column widths and
-// total table width.
+// total table width. Widths are counted in runes rather than bytes, so that
+// the sort indicators and other non-ASCII text are measured by their display
+// width.
 func (lc *listComponent[T]) calculateNaturalTableDimensions() (
 	naturalTableWidth int,
 	naturalColumnWidths []int,
@@ -376,10 +512,10 @@ func (lc *listComponent[T]) calculateNaturalTableDimensions() (
 	naturalTableWidth = 0
 
 	for i, column := range lc.columns {
-		maxCellStringLength := len(column.Label()) + listCellPadding
+		maxCellStringLength := utf8.RuneCountInString(lc.headerLabel(i)) + listCellPadding
 
 		for _, rowData := range lc.data {
-			cellStringLength := len(column.CellString(rowData)) + listCellPadding
+			cellStringLength := utf8.RuneCountInString(column.CellString(rowData)) + listCellPadding
 
 			if cellStringLength > maxCellStringLength {
 				maxCellStringLength = cellStringLength
@@ -400,14 +536,15 @@ func (lc *listComponent[T]) formatCellString(rawString string, cellWidth int) st
 	return lc.paddedString(content, leftPadding, cellWidth)
 }
 
-// truncatedString ensures a string doesn't exceed the specified length by
-// truncating and adding an ellipsis if necessary.
+// truncatedString ensures a string doesn't exceed the specified length in
+// runes by truncating and adding an ellipsis if necessary.
 func (lc *listComponent[T]) truncatedString(s string, maxLength int) string {
-	ts := s
-	if len(ts) > maxLength {
-		ts = ts[:maxLength-len(truncationMarker)] + truncationMarker
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return s
 	}
-	return ts
+	keep := max(maxLength-utf8.RuneCountInString(truncationMarker), 0)
+	return string(runes[:keep]) + truncationMarker
 }
 
 // paddedString adds left padding to a string and ensures it fills exactly the
@@ -461,9 +598,9 @@ func (lc *listComponent[T]) getControlString(key, label string, isActive bool) s
 }
 
 // getNavigationControls returns the array of navigation controls with their
-// current enabled state.
+// current enabled state. Sort controls are included if the list can be sorted.
 func (lc *listComponent[T]) getNavigationControls() []navigationControl {
-	return []navigationControl{
+	controls := []navigationControl{
 		{
 			key:       rightArrowChar,
 			label:     listNextPageLabel,
@@ -475,6 +612,23 @@ func (lc *listComponent[T]) getNavigationControls() []navigationControl {
 			isEnabled: lc.entityList.HasPrevious(),
 		},
 	}
+
+	if lc.selectSort == nil {
+		return controls
+	}
+
+	return append(controls,
+		navigationControl{
+			key:       listSelectSortColumnKey,
+			label:     listSelectSortColumnLabel,
+			isEnabled: lc.isSortable(),
+		},
+		navigationControl{
+			key:       string(listCycleSortOrderKey),
+			label:     listCycleSortOrderLabel,
+			isEnabled: lc.isSortable(),
+		},
+	)
 }
 
 // validateData ensures the component has data to display.
@@ -16,6 +16,10 @@ type ListColumn[T Entity] interface {
 	// CellString formats and returns the string representation of an entity's
 	// data for this column
 	CellString(T) string
+
+	// LogicalName returns the logical name of the attribute backing this
+	// column, or an empty string if the column cannot be sorted
+	LogicalName() string
 }
 
 // listColumn is the standard implementation of the ListColumn interface
@@ -26,6 +30,9 @@ type listColumn[T Entity] struct {
 	// cellStringGetter is a function that extracts and formats data from an
 	// entity
 	cellStringGetter func(T) string
+
+	// logicalName is the backing attribute used when sorting by this column
+	logicalName string
 }
 
 // NewListColumn creates a new ListColumn with the specified label and cell
@@ -41,6 +48,21 @@ func NewListColumn[T Entity](label string, cellStringGetter func(T) string) (Lis
 	}, nil
 }
 
+// NewSortableListColumn creates a new ListColumn backed by the attribute with
+// the given logical name. Lists displaying the column can be sorted by that
+// attribute. Returns an error if the cellStringGetter function is nil.
+func NewSortableListColumn[T Entity](label, logicalName string, cellStringGetter func(T) string) (ListColumn[T], error) {
+	if cellStringGetter == nil {
+		return nil, ErrNilCellStringFunc
+	}
+
+	return &listColumn[T]{
+		label:            label,
+		cellStringGetter: cellStringGetter,
+		logicalName:      logicalName,
+	}, nil
+}
+
 // Label returns the header text for the column
 func (lc *listColumn[T]) Label() string {
 	return lc.label
@@ -51,3 +73,9 @@ func (lc *listColumn[T]) Label() string {
 func (lc *listColumn[T]) CellString(entity T) string {
 	return lc.cellStringGetter(entity)
 }
+
+// LogicalName returns the logical name of the backing attribute, or an empty
+// string if the column is not sortable
+func (lc *listColumn[T]) LogicalName() string {
+	return lc.logicalName
+}
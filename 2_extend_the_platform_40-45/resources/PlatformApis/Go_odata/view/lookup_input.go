@@ -0,0 +1,295 @@
+// Package view provides UI components for terminal-based applications.
+// It includes interactive elements like inputs, lists, and navigation controls.
+package view
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eiannone/keyboard"
+	"github.com/turnerbenjamin/go_odata/constants/ansi"
+	"github.com/turnerbenjamin/go_odata/view/colours"
+)
+
+// defaultLookupMaxResults is the number of search results shown by a lookup
+// input when LookupInputOptions.MaxResults is zero.
+const defaultLookupMaxResults = 10
+
+// lookupRefreshInterval is the time between refreshes of a lookup input, so
+// that search results are shown once they arrive.
+const lookupRefreshInterval = 100 * time.Millisecond
+
+// Help text describing the keys used by a lookup input.
+const (
+	lookupInputHelp = "Type a search term and press Enter to search. " +
+		"Use ↑/↓ and Enter to pick a record, or Esc to keep the current value."
+	lookupInputClearHelp     = "Press Delete to clear the value."
+	lookupInputSearchingHelp = "Searching... Press Esc to cancel."
+)
+
+// ErrNilLookupSearch is returned when creating a lookup input without a
+// search function.
+var ErrNilLookupSearch = errors.New("lookup input requires a search function")
+
+// LookupSearchFunc searches the table referenced by a lookup, returning the
+// records matching searchTerm. An empty search term matches every record.
+// The context is cancelled if the user abandons the search.
+type LookupSearchFunc func(ctx context.Context, searchTerm string) ([]Entity, error)
+
+// LookupInputOptions contains configuration for creating a lookup input
+// component.
+type LookupInputOptions struct {
+	// PropertyName is the label of the lookup column
+	PropertyName string
+
+	// CurrentLabel is the label of the record currently referenced by the
+	// lookup, or an empty string if it is not set
+	CurrentLabel string
+
+	// IsRequired prevents the user from keeping an unset lookup
+	IsRequired bool
+
+	// Search retrieves the records matching a search term
+	Search LookupSearchFunc
+
+	// MaxResults limits the number of search results shown. If zero, 10
+	// results are shown.
+	MaxResults int
+}
+
+// lookupInput represents a lookup field in a terminal UI. The user enters a
+// search term, searches the referenced table and picks one of the matching
+// records.
+//
+// When a record is picked, the screen output's Target is its ID and its
+// UserInput is its label. If the user keeps the current value, both are
+// empty. If the user clears an optional lookup, the Target is ClearedTarget
+// and the UserInput is empty.
+type lookupInput struct {
+	options      LookupInputOptions
+	searchTerm   string
+	results      []Entity
+	selected     int
+	hasSearched  bool
+	isStale      bool
+	errorMessage string
+	requiredFlag string
+	// searchDone receives the outcome of the running search, or is nil if no
+	// search is running
+	searchDone chan lookupSearchResult
+	// cancelSearch cancels the running search
+	cancelSearch context.CancelFunc
+}
+
+// lookupSearchResult is the outcome of a lookup search.
+type lookupSearchResult struct {
+	results []Entity
+	err     error
+}
+
+// NewLookupInputComponent creates a new lookup input with the given options.
+// Searches run in the background when the user presses Enter, and may be
+// abandoned with Esc.
+// Returns an error if options.Search is nil.
+func NewLookupInputComponent(options LookupInputOptions) (InteractiveComponent, error) {
+	if options.Search == nil {
+		return nil, ErrNilLookupSearch
+	}
+	if options.MaxResults <= 0 {
+		options.MaxResults = defaultLookupMaxResults
+	}
+
+	li := &lookupInput{
+		options: options,
+	}
+	if options.IsRequired {
+		li.requiredFlag = "(" + colours.ApplyColour("*", colours.Red) + ")"
+	}
+	return li, nil
+}
+
+// render displays the current value, the search term and any search results,
+// with the selected result highlighted. The results of a completed search are
+// collected first.
+func (li *lookupInput) render() {
+	li.collectSearchResults()
+
+	current := li.options.CurrentLabel
+	if current == "" {
+		current = "(none)"
+	}
+	help := lookupInputHelp
+	if !li.options.IsRequired {
+		help += " " + lookupInputClearHelp
+	}
+	if li.isSearching() {
+		help = lookupInputSearchingHelp
+	}
+	fmt.Print(ansi.CursorShow)
+	fmt.Printf("\n%s%s: %s", li.options.PropertyName, li.requiredFlag, current)
+	fmt.Printf("\n\n%s%s\n", help, ansi.ClearToLineEnd)
+	fmt.Printf("\nSearch: %s%s\n\n", li.searchTerm, ansi.ClearToLineEnd)
+
+	for i, result := range li.results {
+		fmt.Printf("%s %s%s\n", menuIndicator(i == li.selected), result.Label(), ansi.ClearToLineEnd)
+	}
+
+	if li.errorMessage != "" {
+		fmt.Printf("\n%s%s%s", colours.Red, li.errorMessage, colours.Reset)
+	}
+	// Results and messages may have been replaced by shorter ones since the
+	// last render
+	fmt.Print(ansi.ClearToEnd)
+}
+
+// refreshInterval returns the time between refreshes of the lookup input,
+// which shows the results of a background search once they arrive.
+func (li *lookupInput) refreshInterval() time.Duration {
+	return lookupRefreshInterval
+}
+
+// handleKeyboardInput routes keypresses to the appropriate handlers. Search
+// failures are displayed as error messages rather than returned, so the user
+// can retry.
+func (li *lookupInput) handleKeyboardInput(c rune, k keyboard.Key) (*updateResponse, error) {
+	li.collectSearchResults()
+	if li.isSearching() {
+		return li.handleKeyWhileSearching(k), nil
+	}
+
+	li.errorMessage = ""
+	switch k {
+	case keyboard.KeyEnter:
+		return li.handleEnterPressed(), nil
+	case keyboard.KeyEsc:
+		return li.handleEscPressed(), nil
+	case keyboard.KeyArrowUp:
+		if li.selected > 0 {
+			li.selected--
+		}
+		return newUpdateResponse().setContinue(true), nil
+	case keyboard.KeyArrowDown:
+		if li.selected < len(li.results)-1 {
+			li.selected++
+		}
+		return newUpdateResponse().setContinue(true), nil
+	case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+		if len(li.searchTerm) > 0 {
+			li.searchTerm = li.searchTerm[:len(li.searchTerm)-1]
+			li.isStale = true
+		}
+		return newUpdateResponse().setContinue(true).setFullRefresh(), nil
+	case keyboard.KeyDelete:
+		return li.handleClearPressed(), nil
+	case keyboard.KeySpace:
+		return li.handleCharEntered(' '), nil
+	default:
+		if c == 0 {
+			return newUpdateResponse().setContinue(true), nil
+		}
+		return li.handleCharEntered(c), nil
+	}
+}
+
+// handleEnterPressed searches if the search term has changed since the last
+// search, and otherwise picks the selected result.
+func (li *lookupInput) handleEnterPressed() *updateResponse {
+	if !li.hasSearched || li.isStale || len(li.results) == 0 {
+		li.startSearch()
+		return newUpdateResponse().setContinue(true).setFullRefresh()
+	}
+
+	picked := li.results[li.selected]
+	return newUpdateResponse().setUserInput(picked.Label()).setTarget(picked.ID())
+}
+
+// handleEscPressed keeps the current value, unless the lookup is required and
+// has no value.
+func (li *lookupInput) handleEscPressed() *updateResponse {
+	if li.options.IsRequired && li.options.CurrentLabel == "" {
+		li.errorMessage = fmt.Sprintf("%s is required", li.options.PropertyName)
+		return newUpdateResponse().setContinue(true)
+	}
+	return newUpdateResponse()
+}
+
+// handleClearPressed clears the value, unless the lookup is required.
+func (li *lookupInput) handleClearPressed() *updateResponse {
+	if li.options.IsRequired {
+		li.errorMessage = fmt.Sprintf("%s is required", li.options.PropertyName)
+		return newUpdateResponse().setContinue(true)
+	}
+	return newUpdateResponse().setTarget(ClearedTarget)
+}
+
+// handleKeyWhileSearching abandons the running search if Esc is pressed, and
+// otherwise ignores the key.
+func (li *lookupInput) handleKeyWhileSearching(k keyboard.Key) *updateResponse {
+	if k == keyboard.KeyEsc {
+		li.cancelSearch()
+		li.searchDone = nil
+		li.errorMessage = "Search cancelled"
+		return newUpdateResponse().setContinue(true).setFullRefresh()
+	}
+	return newUpdateResponse().setContinue(true)
+}
+
+// handleCharEntered appends the given character to the search term.
+func (li *lookupInput) handleCharEntered(c rune) *updateResponse {
+	li.searchTerm += string(c)
+	li.isStale = true
+	return newUpdateResponse().setContinue(true)
+}
+
+// startSearch runs the search function with the current search term in the
+// background. Its results are collected by collectSearchResults.
+func (li *lookupInput) startSearch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan lookupSearchResult, 1)
+	searchTerm := li.searchTerm
+	go func() {
+		results, err := li.options.Search(ctx, searchTerm)
+		done <- lookupSearchResult{results: results, err: err}
+	}()
+
+	li.searchDone = done
+	li.cancelSearch = cancel
+	li.hasSearched = true
+	li.isStale = false
+	li.selected = 0
+	li.results = nil
+}
+
+// isSearching returns true while a search is running.
+func (li *lookupInput) isSearching() bool {
+	return li.searchDone != nil
+}
+
+// collectSearchResults stores up to MaxResults results of the running search
+// if it has completed.
+func (li *lookupInput) collectSearchResults() {
+	var result lookupSearchResult
+	select {
+	case result = <-li.searchDone:
+	default:
+		return
+	}
+	li.cancelSearch()
+	li.searchDone = nil
+
+	if result.err != nil {
+		li.errorMessage = fmt.Sprintf("Search failed: %s", result.err)
+		return
+	}
+
+	results := result.results
+	if len(results) > li.options.MaxResults {
+		results = results[:li.options.MaxResults]
+	}
+	li.results = results
+	if len(results) == 0 {
+		li.errorMessage = "No matching records"
+	}
+}
@@ -5,6 +5,7 @@ package view
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/eiannone/keyboard"
 	"github.com/turnerbenjamin/go_odata/constants/ansi"
@@ -51,6 +52,15 @@ type InteractiveComponent interface {
 	handleKeyboardInput(rune, keyboard.Key) (*updateResponse, error)
 }
 
+// liveComponent is implemented by components whose content changes over
+// time, such as a countdown, and so must be refreshed periodically while
+// displayed.
+type liveComponent interface {
+	Component
+	// refreshInterval returns the time between refreshes.
+	refreshInterval() time.Duration
+}
+
 // Screen represents a complete terminal UI view composed of multiple
 // components.
 // It manages component layout, rendering, and input handling.
@@ -69,13 +79,25 @@ type Screen interface {
 	// handleKeyboardInput delegates keyboard input to the interactive
 	// component.
 	handleKeyboardInput(rune, keyboard.Key) (*updateResponse, error)
+
+	// refreshInterval returns the time between periodic refreshes of the
+	// screen, or zero if it only changes in response to input.
+	refreshInterval() time.Duration
+
+	// setHeader sets a component rendered above the screen's components,
+	// or removes it if nil.
+	setHeader(Component)
 }
 
 // screen implements the Screen interface.
 type screen struct {
+	header               Component
 	components           []Component
 	interactiveComponent InteractiveComponent
 	needsFullRefresh     bool
+	// liveRefreshInterval is the shortest refresh interval of the screen's
+	// live components, or zero if it has none
+	liveRefreshInterval time.Duration
 }
 
 // MakeScreen creates a new Screen from the provided components.
@@ -116,6 +138,13 @@ func (s *screen) registerComponent(c Component) error {
 		}
 		s.interactiveComponent = ic
 	}
+
+	if lc, ok := c.(liveComponent); ok {
+		interval := lc.refreshInterval()
+		if s.liveRefreshInterval == 0 || interval < s.liveRefreshInterval {
+			s.liveRefreshInterval = interval
+		}
+	}
 	return nil
 }
 
@@ -143,8 +172,12 @@ func (s *screen) Refresh() {
 	s.render()
 }
 
-// render displays all components of the screen in sequence.
+// render displays the header, if any, then all components of the screen in
+// sequence.
 func (s *screen) render() {
+	if s.header != nil {
+		s.header.render()
+	}
 	for _, c := range s.components {
 		c.render()
 	}
@@ -160,6 +193,17 @@ func (s *screen) handleKeyboardInput(char rune, key keyboard.Key) (*updateRespon
 	return sr, err
 }
 
+// setHeader sets the component rendered above the screen's components.
+func (s *screen) setHeader(header Component) {
+	s.header = header
+}
+
+// refreshInterval returns the time between periodic refreshes needed by the
+// screen's live components, or zero if it has none.
+func (s *screen) refreshInterval() time.Duration {
+	return s.liveRefreshInterval
+}
+
 // shouldDoFullRefresh determines whether a complete screen redraw is needed
 // based on terminal size and internal state. It also updates the internal
 // refresh flag based on current terminal dimensions.
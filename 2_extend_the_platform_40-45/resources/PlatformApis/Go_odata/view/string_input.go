@@ -4,12 +4,17 @@ package view
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/eiannone/keyboard"
 	"github.com/turnerbenjamin/go_odata/constants/ansi"
 	"github.com/turnerbenjamin/go_odata/view/colours"
 )
 
+// maskCharacter is displayed in place of each character of a masked input.
+const maskCharacter = "*"
+
 // stringInput represents a text input field in a terminal UI.
 // It handles user text entry, validation for required fields,
 // and displays error messages when validation fails.
@@ -17,6 +22,7 @@ type stringInput struct {
 	propertyName string
 	value        string
 	isRequired   bool
+	isMasked     bool
 	errorMessage string
 	requiredFlag string
 }
@@ -36,16 +42,36 @@ func NewStringInputComponent(propertyName, value string, isRequired bool) Intera
 	return si
 }
 
+// NewMaskedStringInputComponent creates a new text input field for a secret,
+// such as a password or client secret. Each character entered is displayed
+// as an asterisk, and the field starts empty so that an existing secret is
+// never displayed. If isRequired is true, the field will be marked as
+// required and validated before submission.
+func NewMaskedStringInputComponent(propertyName string, isRequired bool) InteractiveComponent {
+	si := NewStringInputComponent(propertyName, "", isRequired).(*stringInput)
+	si.isMasked = true
+	return si
+}
+
 // render displays the string input component with its current value.
 // It shows the property name, current input value, and any error messages.
 func (si *stringInput) render() {
 	fmt.Print(ansi.CursorShow)
-	fmt.Printf("\n%s%s: %s", si.propertyName, si.requiredFlag, si.value)
+	fmt.Printf("\n%s%s: %s", si.propertyName, si.requiredFlag, si.displayValue())
 	if si.errorMessage != "" {
 		fmt.Printf("\n\n%s%s%s", colours.Red, si.errorMessage, colours.Reset)
 	}
 }
 
+// displayValue returns the value as displayed: the value itself, or an
+// asterisk for each character if the input is masked.
+func (si *stringInput) displayValue() string {
+	if si.isMasked {
+		return strings.Repeat(maskCharacter, utf8.RuneCountInString(si.value))
+	}
+	return si.value
+}
+
 // handleKeyboardInput routes keypresses to the appropriate handlers.
 // This component handles all validation errors via UI and never returns
 // actual errors through the error return value. The error return is
@@ -12,6 +12,11 @@ type ScreenOutput interface {
 	Target() string
 }
 
+// ClearedTarget is the Target of the output of an input whose value the user
+// chose to clear, as opposed to an empty Target, which keeps the current
+// value.
+const ClearedTarget = "(cleared)"
+
 // updateResponse implements the ScreenOutput interface and handles screen
 // interaction results.
 // This type is package-private and used internally to manage UI component